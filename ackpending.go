@@ -0,0 +1,108 @@
+package vnats
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+// AckPendingEntry describes a message that was delivered to the Subscriber, but is not acknowledged yet.
+type AckPendingEntry struct {
+	// Sequence is the stream sequence of the message.
+	Sequence uint64
+
+	// NumDelivered is the number of times the message was delivered, including this delivery.
+	NumDelivered uint64
+
+	// SinceDelivered is the time elapsed since the message was delivered to the Subscriber.
+	SinceDelivered time.Duration
+}
+
+type ackPendingTracker struct {
+	mu      sync.Mutex
+	entries map[uint64]ackPendingMsg
+	now     func() time.Time
+}
+
+type ackPendingMsg struct {
+	numDelivered uint64
+	deliveredAt  time.Time
+
+	// expiresAt is set, once the message was released by a NAK. If it is not redelivered to the Subscriber
+	// until then, it was redelivered to another Subscriber of the consumer.
+	expiresAt time.Time
+}
+
+func newAckPendingTracker() *ackPendingTracker {
+	return &ackPendingTracker{
+		entries: map[uint64]ackPendingMsg{},
+		now:     time.Now,
+	}
+}
+
+func (t *ackPendingTracker) add(sequence, numDelivered uint64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.entries[sequence] = ackPendingMsg{numDelivered: numDelivered, deliveredAt: t.now()}
+}
+
+func (t *ackPendingTracker) remove(sequence uint64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.entries, sequence)
+}
+
+// expire keeps the message listed until it is redelivered, but at most for the given duration.
+func (t *ackPendingTracker) expire(sequence uint64, after time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := t.now()
+	t.pruneExpired(now)
+	if msg, ok := t.entries[sequence]; ok {
+		msg.expiresAt = now.Add(after)
+		t.entries[sequence] = msg
+	}
+}
+
+func (t *ackPendingTracker) pruneExpired(now time.Time) {
+	for seq, msg := range t.entries {
+		if !msg.expiresAt.IsZero() && now.After(msg.expiresAt) {
+			delete(t.entries, seq)
+		}
+	}
+}
+
+// list returns the entries ordered by sequence.
+func (t *ackPendingTracker) list() []AckPendingEntry {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	t.pruneExpired(now)
+	entries := make([]AckPendingEntry, 0, len(t.entries))
+	for seq, msg := range t.entries {
+		entries = append(entries, AckPendingEntry{
+			Sequence:       seq,
+			NumDelivered:   msg.numDelivered,
+			SinceDelivered: now.Sub(msg.deliveredAt),
+		})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Sequence < entries[j].Sequence })
+	return entries
+}
+
+// settleAckPending removes the ACKed or terminated message.
+func (s *Subscriber) settleAckPending(natsMsg *nats.Msg) {
+	if meta, err := natsMsg.Metadata(); err == nil {
+		s.ackPending.remove(meta.Sequence.Stream)
+	}
+}
+
+// expireAckPending keeps the released message listed until its redelivery is expected after the delay.
+func (s *Subscriber) expireAckPending(natsMsg *nats.Msg, delay time.Duration) {
+	if meta, err := natsMsg.Metadata(); err == nil {
+		s.ackPending.expire(meta.Sequence.Stream, delay+s.ackWait)
+	}
+}
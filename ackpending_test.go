@@ -0,0 +1,51 @@
+package vnats
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_ackPendingTracker(t *testing.T) {
+	now := time.Now()
+	tracker := newAckPendingTracker()
+	tracker.now = func() time.Time { return now }
+
+	tracker.add(7, 2)
+	tracker.add(3, 1)
+	tracker.add(5, 1)
+	tracker.remove(5)
+
+	now = now.Add(time.Second)
+
+	want := []AckPendingEntry{
+		{Sequence: 3, NumDelivered: 1, SinceDelivered: time.Second},
+		{Sequence: 7, NumDelivered: 2, SinceDelivered: time.Second},
+	}
+	if got := tracker.list(); !reflect.DeepEqual(got, want) {
+		t.Errorf("list() = %v, want %v", got, want)
+	}
+}
+
+func Test_ackPendingTracker_expire(t *testing.T) {
+	now := time.Now()
+	tracker := newAckPendingTracker()
+	tracker.now = func() time.Time { return now }
+
+	tracker.add(1, 1)
+	tracker.add(2, 1)
+	tracker.expire(1, time.Minute)
+	tracker.expire(2, time.Minute)
+	tracker.add(2, 2) // redelivered to the Subscriber
+
+	now = now.Add(time.Minute)
+	if got := tracker.list(); len(got) != 2 {
+		t.Errorf("list() within expiry = %v, want both entries", got)
+	}
+
+	now = now.Add(time.Second)
+	want := []AckPendingEntry{{Sequence: 2, NumDelivered: 2, SinceDelivered: time.Minute + time.Second}}
+	if got := tracker.list(); !reflect.DeepEqual(got, want) {
+		t.Errorf("list() after expiry = %v, want %v", got, want)
+	}
+}
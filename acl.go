@@ -0,0 +1,61 @@
+package vnats
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrSubjectNotAllowed is returned, if a subject to publish or subscribe to is not covered by the subjects
+// allowed by WithAllowedSubjects.
+var ErrSubjectNotAllowed = errors.New("subject not allowed")
+
+// WithAllowedSubjects restricts the subjects of Publishers and Subscribers to the given subjects, which may
+// contain the wildcards "*" and ">". A subscribe subject with wildcards must be covered completely, e.g.
+// "TENANT1.>" allows "TENANT1.orders.*", but "TENANT1.*" does not allow "TENANT1.>".
+// This option can be passed in the Connect function.
+// Without this option, all subjects are allowed and only the permissions of the server apply.
+func WithAllowedSubjects(subjects ...string) Option {
+	return func(c *Connection) {
+		c.allowedSubjects = subjects
+	}
+}
+
+// checkSubjectAllowed returns an error wrapping ErrSubjectNotAllowed, if the subject is not covered by any of
+// the allowed subjects of the Connection.
+func (c *Connection) checkSubjectAllowed(subject string) error {
+	if c.allowedSubjects == nil {
+		return nil
+	}
+	for _, allowed := range c.allowedSubjects {
+		if subjectIsSubset(subject, allowed) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrSubjectNotAllowed, subject)
+}
+
+// subjectIsSubset reports whether all subjects matched by subject are also matched by pattern.
+func subjectIsSubset(subject, pattern string) bool {
+	subjectTokens := strings.Split(subject, ".")
+	patternTokens := strings.Split(pattern, ".")
+	for i, patternToken := range patternTokens {
+		if i >= len(subjectTokens) {
+			return false
+		}
+		subjectToken := subjectTokens[i]
+		switch patternToken {
+		case ">":
+			return true
+		case "*":
+			if subjectToken == ">" {
+				return false
+			}
+		default:
+			if subjectToken != patternToken {
+				return false
+			}
+		}
+	}
+	return len(subjectTokens) == len(patternTokens)
+}
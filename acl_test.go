@@ -0,0 +1,53 @@
+package vnats
+
+import (
+	"errors"
+	"log/slog"
+	"testing"
+)
+
+func Test_subjectIsSubset(t *testing.T) {
+	tests := []struct {
+		subject string
+		pattern string
+		want    bool
+	}{
+		{subject: "TENANT1.orders", pattern: "TENANT1.orders", want: true},
+		{subject: "TENANT1.orders", pattern: "TENANT1.*", want: true},
+		{subject: "TENANT1.orders.created", pattern: "TENANT1.*", want: false},
+		{subject: "TENANT1.orders.created", pattern: "TENANT1.>", want: true},
+		{subject: "TENANT1.*.created", pattern: "TENANT1.>", want: true},
+		{subject: "TENANT1.>", pattern: "TENANT1.>", want: true},
+		{subject: "TENANT1.>", pattern: "TENANT1.*", want: false},
+		{subject: "TENANT1.*", pattern: "TENANT1.orders", want: false},
+		{subject: "TENANT1", pattern: "TENANT1.>", want: false},
+		{subject: "TENANT2.orders", pattern: "TENANT1.>", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.subject+" in "+tt.pattern, func(t *testing.T) {
+			if got := subjectIsSubset(tt.subject, tt.pattern); got != tt.want {
+				t.Errorf("subjectIsSubset() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConnection_AllowedSubjects(t *testing.T) {
+	conn := makeTestConnection(t, "TENANT1", 1, []byte("test message"), "msg-001", nil)
+	WithAllowedSubjects("TENANT1.orders.>")(conn)
+	pub := &Publisher{conn: conn, logger: slog.Default(), streamName: "TENANT1", backoff: defaultRetryBackoff}
+
+	if err := pub.Publish(NewMsg("TENANT1.orders.created", "msg-001", []byte("test message"))); err != nil {
+		t.Errorf("Publish() to allowed subject failed: %v", err)
+	}
+	if err := pub.Publish(NewMsg("TENANT1.invoices.created", "msg-001", []byte("test message"))); !errors.Is(err, ErrSubjectNotAllowed) {
+		t.Errorf("Publish() error = %v, want ErrSubjectNotAllowed", err)
+	}
+
+	if _, err := conn.NewSubscriber(SubscriberArgs{ConsumerName: "orders", Subject: "TENANT1.orders.*"}); err != nil {
+		t.Errorf("NewSubscriber() of allowed subject failed: %v", err)
+	}
+	if _, err := conn.NewSubscriber(SubscriberArgs{ConsumerName: "all", Subject: "TENANT1.>"}); !errors.Is(err, ErrSubjectNotAllowed) {
+		t.Errorf("NewSubscriber() error = %v, want ErrSubjectNotAllowed", err)
+	}
+}
@@ -0,0 +1,38 @@
+package vnats
+
+import "github.com/nats-io/nats.go"
+
+// WithUserCredentials authenticates the connection by username and password.
+// This option can be passed in the Connect function.
+// Without this option, only the credentials contained in the server URLs are used.
+func WithUserCredentials(user, password string) Option {
+	return withNATSOptions(nats.UserInfo(user, password))
+}
+
+// WithToken authenticates the connection by token.
+// This option can be passed in the Connect function.
+// Without this option, only the tokens contained in the server URLs are used.
+func WithToken(token string) Option {
+	return withNATSOptions(nats.Token(token))
+}
+
+// WithCredentialsFile authenticates the connection by the user JWT and NKey seed of a .creds file, as used
+// by decentralized auth. The file is read again on every reconnect, so it can be rotated in place.
+// This option can be passed in the Connect function. Connect fails, if the file cannot be read.
+func WithCredentialsFile(path string) Option {
+	return withNATSOptions(nats.UserCredentials(path))
+}
+
+// WithUserJWTAndSeed authenticates the connection by the user JWT and NKey seed, e.g. taken from a secret
+// store instead of a .creds file.
+// This option can be passed in the Connect function.
+func WithUserJWTAndSeed(jwt, seed string) Option {
+	return withNATSOptions(nats.UserJWTAndSeed(jwt, seed))
+}
+
+// withNATSOptions returns an Option, which passes the nats.Options to nats.Connect.
+func withNATSOptions(options ...nats.Option) Option {
+	return func(c *Connection) {
+		c.natsOptions = append(c.natsOptions, options...)
+	}
+}
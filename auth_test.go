@@ -0,0 +1,61 @@
+package vnats
+
+import (
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nats-io/nats.go"
+)
+
+// natsOptionsOf applies the nats.Options collected by the options to the default nats.Options.
+func natsOptionsOf(t *testing.T, options ...Option) nats.Options {
+	t.Helper()
+	conn := &Connection{}
+	conn.applyOptions(options...)
+
+	natsOpts := nats.GetDefaultOptions()
+	for _, option := range conn.natsOptions {
+		if err := option(&natsOpts); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return natsOpts
+}
+
+func TestWithUserCredentials(t *testing.T) {
+	got := natsOptionsOf(t, WithUserCredentials("ruser", "T0pS3cr3t"))
+	if got.User != "ruser" || got.Password != "T0pS3cr3t" {
+		t.Errorf("Got user %q and password %q, expected ruser and T0pS3cr3t", got.User, got.Password)
+	}
+}
+
+func TestWithToken(t *testing.T) {
+	got := natsOptionsOf(t, WithToken("s3cr3t-t0k3n"))
+	if got.Token != "s3cr3t-t0k3n" {
+		t.Errorf("Got token %q, expected s3cr3t-t0k3n", got.Token)
+	}
+	if got := natsOptionsOf(t); got.User != "" || got.Token != "" {
+		t.Errorf("Without options got user %q and token %q, expected none", got.User, got.Token)
+	}
+}
+
+func TestWithCredentialsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "user.creds")
+	_, err := Connect([]string{"nats://127.0.0.1:4222"}, WithCredentialsFile(path))
+	if !errors.Is(err, fs.ErrNotExist) || !strings.Contains(err.Error(), path) {
+		t.Errorf("Connect() with missing credentials file error = %v, want fs.ErrNotExist naming %s", err, path)
+	}
+}
+
+func TestWithUserJWTAndSeed(t *testing.T) {
+	got := natsOptionsOf(t, WithUserJWTAndSeed("eyJ0eXAiOiJKV1QifQ", "SUAIBDPBAUTWCWBKIO6XHQNINK5FWJW4OHLXC3HQ2KFE4PEJUA44CNHTC4"))
+	if got.UserJWT == nil || got.SignatureCB == nil {
+		t.Error("WithUserJWTAndSeed() did not set the user JWT and signature callbacks")
+	}
+	if jwt, err := got.UserJWT(); err != nil || jwt != "eyJ0eXAiOiJKV1QifQ" {
+		t.Errorf("UserJWT() = %v, %v, want the passed JWT", jwt, err)
+	}
+}
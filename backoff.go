@@ -0,0 +1,108 @@
+package vnats
+
+import (
+	"errors"
+	"math"
+	"math/rand/v2"
+	"time"
+)
+
+// BackoffStrategy defines the delay before a retry. The attempt starts at 1 for the first retry.
+type BackoffStrategy interface {
+	Next(attempt int) time.Duration
+}
+
+// RetryAfter wraps the error of a handler, so that the message is redelivered after the delay instead of
+// the delay of the NakBackoff, e.g. the Retry-After of a rate-limited downstream API. A zero delay
+// redelivers immediately. The error is unwrapped by errors.Is and errors.As as usual.
+func RetryAfter(delay time.Duration, err error) error {
+	return &retryAfterError{err: err, delay: delay}
+}
+
+type retryAfterError struct {
+	err   error
+	delay time.Duration
+}
+
+func (e *retryAfterError) Error() string {
+	return e.err.Error()
+}
+
+func (e *retryAfterError) Unwrap() error {
+	return e.err
+}
+
+// retryAfterDelay returns the delay of RetryAfter, if err wraps one.
+func retryAfterDelay(err error) (time.Duration, bool) {
+	var retryAfter *retryAfterError
+	if errors.As(err, &retryAfter) {
+		return retryAfter.delay, true
+	}
+	return 0, false
+}
+
+// LinearBackoff increases the delay by Step with every attempt, starting at Initial.
+type LinearBackoff struct {
+	Initial time.Duration
+	Step    time.Duration
+
+	// Max limits the delay. Default is 0, which means unlimited.
+	Max time.Duration
+}
+
+// Next returns Initial + (attempt-1) * Step.
+func (b LinearBackoff) Next(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	return capDelay(b.Initial+time.Duration(attempt-1)*b.Step, b.Max)
+}
+
+// ExponentialBackoff multiplies the delay by Multiplier with every attempt, starting at Initial.
+type ExponentialBackoff struct {
+	Initial time.Duration
+
+	// Multiplier is the growth factor of the delay. Default is 2.
+	Multiplier float64
+
+	// Max limits the delay. Default is 0, which means unlimited.
+	Max time.Duration
+}
+
+// Next returns Initial * Multiplier^(attempt-1).
+func (b ExponentialBackoff) Next(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	multiplier := b.Multiplier
+	if multiplier <= 0 {
+		multiplier = 2
+	}
+	delay := float64(b.Initial) * math.Pow(multiplier, float64(attempt-1))
+	if delay >= math.MaxInt64 {
+		delay = math.MaxInt64
+	}
+	return capDelay(time.Duration(delay), b.Max)
+}
+
+// FullJitterBackoff randomizes the delay of Backoff between zero and the delay, so that many clients,
+// which failed at the same time, do not retry at the same time.
+type FullJitterBackoff struct {
+	Backoff BackoffStrategy
+}
+
+// Next returns a random delay between zero and the delay of Backoff.
+func (b FullJitterBackoff) Next(attempt int) time.Duration {
+	delay := b.Backoff.Next(attempt)
+	if delay <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int64N(int64(delay)))
+}
+
+func capDelay(delay, maxDelay time.Duration) time.Duration {
+	if maxDelay > 0 && (delay > maxDelay || delay < 0) {
+		return maxDelay
+	}
+	return delay
+}
@@ -0,0 +1,158 @@
+package vnats
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+func TestBackoffStrategies(t *testing.T) {
+	tests := []struct {
+		name    string
+		backoff BackoffStrategy
+		want    []time.Duration
+	}{
+		{
+			name:    "Linear",
+			backoff: LinearBackoff{Initial: time.Second, Step: time.Second * 2, Max: time.Second * 6},
+			want:    []time.Duration{time.Second, time.Second * 3, time.Second * 5, time.Second * 6},
+		},
+		{
+			name:    "Exponential",
+			backoff: ExponentialBackoff{Initial: time.Second, Max: time.Second * 5},
+			want:    []time.Duration{time.Second, time.Second * 2, time.Second * 4, time.Second * 5},
+		},
+		{
+			name:    "Exponential with multiplier",
+			backoff: ExponentialBackoff{Initial: time.Second, Multiplier: 3},
+			want:    []time.Duration{time.Second, time.Second * 3, time.Second * 9, time.Second * 27},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i, want := range tt.want {
+				if got := tt.backoff.Next(i + 1); got != want {
+					t.Errorf("Next(%d) = %v, want %v", i+1, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestFullJitterBackoff(t *testing.T) {
+	backoff := FullJitterBackoff{Backoff: ExponentialBackoff{Initial: time.Second}}
+	for attempt := 1; attempt <= 5; attempt++ {
+		for i := 0; i < 100; i++ {
+			if got, upper := backoff.Next(attempt), time.Second<<(attempt-1); got < 0 || got > upper {
+				t.Fatalf("Next(%d) = %v, want between 0 and %v", attempt, got, upper)
+			}
+		}
+	}
+}
+
+func Test_publisher_Publish_Retries(t *testing.T) {
+	tests := []struct {
+		name          string
+		maxRetries    int
+		failPublishes int
+		wantErr       bool
+		wantDelays    []time.Duration
+	}{
+		{name: "No retries", maxRetries: 0, failPublishes: 1, wantErr: true, wantDelays: nil},
+		{name: "Succeeds after retries", maxRetries: 3, failPublishes: 2, wantErr: false, wantDelays: []time.Duration{time.Second, time.Second * 2}},
+		{name: "Retries exhausted", maxRetries: 2, failPublishes: 3, wantErr: true, wantDelays: []time.Duration{time.Second, time.Second * 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn := makeTestConnection(t, "MESSAGES", 1, []byte("test message"), "msg-001", nil)
+			conn.nats.(*testBridge).failPublishes = tt.failPublishes
+			var delays []time.Duration
+			pub := &Publisher{
+				conn:       conn,
+				logger:     conn.logger,
+				streamName: "MESSAGES",
+				maxRetries: tt.maxRetries,
+				backoff:    ExponentialBackoff{Initial: time.Second},
+				wait: func(_ context.Context, d time.Duration) error {
+					delays = append(delays, d)
+					return nil
+				},
+			}
+
+			err := pub.Publish(NewMsg("MESSAGES.Important", "msg-001", []byte("test message")))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Publish() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(delays) != len(tt.wantDelays) {
+				t.Fatalf("Got delays %v, want %v", delays, tt.wantDelays)
+			}
+			for i := range delays {
+				if delays[i] != tt.wantDelays[i] {
+					t.Errorf("Got delays %v, want %v", delays, tt.wantDelays)
+				}
+			}
+		})
+	}
+}
+
+func Test_publisher_Publish_Timeout(t *testing.T) {
+	conn := makeTestConnection(t, "MESSAGES", 1, []byte("test message"), "msg-001", nil)
+	bridge := conn.nats.(*testBridge)
+	pub := &Publisher{
+		conn:       conn,
+		logger:     conn.logger,
+		streamName: "MESSAGES",
+		maxRetries: 100,
+		backoff:    LinearBackoff{Initial: time.Millisecond * 20},
+		timeout:    time.Millisecond * 100,
+		wait:       waitContext,
+	}
+
+	bridge.failPublishes = 2
+	if err := pub.Publish(NewMsg("MESSAGES.Important", "msg-001", []byte("test message"))); err != nil {
+		t.Fatalf("Publish() after transient failures error = %v", err)
+	}
+
+	bridge.failPublishes = 100
+	start := time.Now()
+	err := pub.Publish(NewMsg("MESSAGES.Important", "msg-001", []byte("test message")))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Publish() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if elapsed := time.Since(start); elapsed > time.Millisecond*500 {
+		t.Errorf("Publish() gave up after %v, expected the PublishTimeout", elapsed)
+	}
+}
+
+func TestSubscriber_nakDelay(t *testing.T) {
+	meta := &nats.MsgMetadata{NumDelivered: 3}
+	handlerErr := errors.New("downstream unavailable")
+	if got := makeTestSubscriber(SubscriberArgs{}, nil).nakDelay(meta, handlerErr); got != defaultNakDelay {
+		t.Errorf("nakDelay() without NakBackoff = %v, want %v", got, defaultNakDelay)
+	}
+	sub := makeTestSubscriber(SubscriberArgs{NakBackoff: LinearBackoff{Initial: time.Second, Step: time.Second}}, nil)
+	if got := sub.nakDelay(meta, handlerErr); got != time.Second*3 {
+		t.Errorf("nakDelay() = %v, want %v", got, time.Second*3)
+	}
+	if got := sub.nakDelay(meta, fmt.Errorf("rate limited: %w", RetryAfter(time.Minute, handlerErr))); got != time.Minute {
+		t.Errorf("nakDelay() of RetryAfter = %v, want %v", got, time.Minute)
+	}
+	if got := sub.nakDelay(meta, RetryAfter(0, handlerErr)); got != 0 {
+		t.Errorf("nakDelay() of RetryAfter without delay = %v, want 0", got)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	handlerErr := errors.New("downstream unavailable")
+	err := RetryAfter(time.Second, handlerErr)
+	if !errors.Is(err, handlerErr) || err.Error() != handlerErr.Error() {
+		t.Errorf("RetryAfter() = %v, want it to wrap %v", err, handlerErr)
+	}
+	if _, ok := retryAfterDelay(handlerErr); ok {
+		t.Error("retryAfterDelay() of a plain error should report no delay")
+	}
+}
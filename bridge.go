@@ -1,11 +1,15 @@
 package vnats
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 
-	natsServer "github.com/nats-io/nats-server/v2/server"
 	"github.com/nats-io/nats.go"
 )
 
@@ -13,31 +17,42 @@ type natsBridge struct {
 	connection       *nats.Conn
 	jetStreamContext nats.JetStreamContext
 	logger           *slog.Logger
+	startupRetry     startupRetry
 }
 
-func newNATSBridge(servers []string, logger *slog.Logger) (*natsBridge, error) {
+// newNATSBridge connects to the servers. The options are applied after the handlers of the bridge.
+func newNATSBridge(servers []string, logger *slog.Logger, options ...nats.Option) (*natsBridge, error) {
 	nb := &natsBridge{
-		logger: logger,
+		logger:       logger,
+		startupRetry: defaultStartupRetry,
 	}
 
 	var err error
 	url := strings.Join(servers, ",")
 
-	nb.connection, err = nats.Connect(url,
+	nb.connection, err = nats.Connect(url, append([]nats.Option{
 		nats.DisconnectErrHandler(func(_ *nats.Conn, err error) {
+			if err == nil { // Disconnected by Close or Drain
+				return
+			}
 			logger.Error("Got disconnected", slog.String("error", err.Error()))
 		}),
 		nats.ReconnectHandler(func(nc *nats.Conn) {
 			logger.Error("Got reconnected to!", slog.String("url", nc.ConnectedUrl()))
 		}),
 		nats.ClosedHandler(func(nc *nats.Conn) {
-			logger.Error("Connection closed", slog.String("error", nc.LastError().Error()))
-		}))
+			logClosed(logger, nc)
+		}),
+	}, options...)...)
 	if err != nil {
 		return nil, fmt.Errorf("could not make NATS Connection to %s: %w", url, err)
 	}
 
-	nb.jetStreamContext, err = nb.connection.JetStream()
+	nb.jetStreamContext, err = nb.connection.JetStream(
+		nats.PublishAsyncErrHandler(func(_ nats.JetStream, msg *nats.Msg, err error) {
+			logger.Error("Async publish failed", slog.String("subject", msg.Subject),
+				slog.String("msgID", msg.Header.Get(nats.MsgIdHdr)), slog.String("error", err.Error()))
+		}))
 	if err != nil {
 		return nil, err
 	}
@@ -45,49 +60,501 @@ func newNATSBridge(servers []string, logger *slog.Logger) (*natsBridge, error) {
 	return nb, nil
 }
 
-func (b *natsBridge) PublishMsg(msg *nats.Msg, msgID string) error {
-	_, err := b.jetStreamContext.PublishMsg(msg, nats.MsgId(msgID))
+func (b *natsBridge) PublishMsg(ctx context.Context, msg *nats.Msg, msgID string) error {
+	ctx, cancel := publishContext(ctx)
+	defer cancel()
+	_, err := b.jetStreamContext.PublishMsg(msg, nats.MsgId(msgID), nats.Context(ctx))
 	return err
 }
 
+// publishContext limits the wait for the ack to the default timeout of the publish, unless ctx has a deadline
+// already, which may be longer.
+func publishContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, defaultPublishTimeout)
+}
+
+func (b *natsBridge) PublishMsgAsync(msg *nats.Msg, msgID string) (nats.PubAckFuture, error) {
+	return b.jetStreamContext.PublishMsgAsync(msg, nats.MsgId(msgID))
+}
+
 func (b *natsBridge) EnsureStreamExists(streamConfig *nats.StreamConfig) error {
-	if _, err := b.jetStreamContext.StreamInfo(streamConfig.Name); err != nil {
-		if err != nats.ErrStreamNotFound {
-			return fmt.Errorf("NATS streamInfo-info could not be fetched: %w", err)
-		}
-		b.logger.Info("Stream not found, about to add stream.", slog.String("name", streamConfig.Name))
+	return b.startupRetry.do(b.logger, "ensure stream exists", func() error {
+		return b.ensureStreamExists(streamConfig)
+	})
+}
 
-		_, err = b.jetStreamContext.AddStream(streamConfig)
-		if err != nil {
-			return fmt.Errorf("streamInfo %s could not be added: %w", streamConfig.Name, err)
+func (b *natsBridge) ensureStreamExists(streamConfig *nats.StreamConfig) error {
+	info, err := b.jetStreamContext.StreamInfo(streamConfig.Name)
+	if err == nil {
+		if conflicts := streamConfigConflicts(&info.Config, streamConfig); len(conflicts) > 0 {
+			b.logger.Warn("Stream exists with other config, the requested config is ignored",
+				slog.String("name", streamConfig.Name), slog.Any("conflicts", conflicts))
+		}
+		if missing := missingSubjects(&info.Config, streamConfig); len(missing) > 0 {
+			b.addStreamSubjects(info.Config, missing)
 		}
-		b.logger.Info("Added new NATS streamInfo", slog.String("name", streamConfig.Name))
+		return nil
+	}
+	if err != nats.ErrStreamNotFound {
+		return fmt.Errorf("NATS streamInfo-info could not be fetched: %w", err)
+	}
+	b.logger.Info("Stream not found, about to add stream.", slog.String("name", streamConfig.Name))
+
+	_, err = b.jetStreamContext.AddStream(streamConfig)
+	if errors.Is(err, nats.ErrStreamNameAlreadyInUse) {
+		// Another client added the stream meanwhile with a different configuration.
+		b.logger.Info("Stream was added concurrently", slog.String("name", streamConfig.Name))
+		return nil
 	}
+	if err != nil {
+		return fmt.Errorf("streamInfo %s could not be added: %w", streamConfig.Name, err)
+	}
+	b.logger.Info("Added new NATS streamInfo", slog.String("name", streamConfig.Name))
 	return nil
 }
 
-func (b *natsBridge) Subscribe(subject, consumerName string, mode SubscriptionMode) (*nats.Subscription, error) {
-	var maxAckPending int
-	switch mode {
-	case MultipleSubscribersAllowed:
-		maxAckPending = natsServer.JsDefaultMaxAckPending
-	case SingleSubscriberStrictMessageOrder:
-		maxAckPending = 1
-	default:
-		maxAckPending = natsServer.JsDefaultMaxAckPending
+// addStreamSubjects adds the missing subjects to the existing stream. A failure, e.g. because the subjects overlap
+// with another stream, is logged only, since the subjects of the stream name can still be published.
+func (b *natsBridge) addStreamSubjects(cfg nats.StreamConfig, missing []string) {
+	cfg.Subjects = append(slices.Clip(cfg.Subjects), missing...)
+	if _, err := b.jetStreamContext.UpdateStream(&cfg); err != nil {
+		b.logger.Warn("Stream lacks requested subjects, which could not be added", slog.String("name", cfg.Name),
+			slog.Any("subjects", missing), slog.String("error", err.Error()))
+		return
+	}
+	b.logger.Info("Added subjects to stream", slog.String("name", cfg.Name), slog.Any("subjects", missing))
+}
+
+func (b *natsBridge) Subscribe(streamName string, args SubscriberArgs) (*nats.Subscription, error) {
+	if args.Mode == OrderedConsumer {
+		return b.subscribeOrdered(streamName, args)
+	}
+	maxAckPending := args.MaxAckPending
+	if maxAckPending <= 0 {
+		maxAckPending = args.Mode.defaultMaxAckPending()
 	}
 
-	return b.jetStreamContext.PullSubscribe(subject, consumerName,
+	ackWait := args.AckWait
+	if ackWait <= 0 {
+		ackWait = args.Mode.defaultAckWait()
+	}
+
+	opts := []nats.SubOpt{
 		nats.AckExplicit(),
 		nats.MaxAckPending(maxAckPending),
-		nats.AckWait(defaultAckWait),
-	)
+		nats.AckWait(ackWait),
+	}
+	if streamName != "" {
+		opts = append(opts, nats.BindStream(streamName))
+	}
+	if deliver := deliverOpt(args); deliver != nil {
+		opts = append(opts, deliver)
+	}
+	if args.MaxDeliver > 0 {
+		opts = append(opts, nats.MaxDeliver(args.MaxDeliver))
+	}
+	if args.InactiveThreshold > 0 {
+		opts = append(opts, nats.InactiveThreshold(args.InactiveThreshold))
+	}
+	if args.MaxRequestBatch > 0 {
+		opts = append(opts, nats.MaxRequestBatch(args.MaxRequestBatch))
+	}
+	if args.MaxRequestMaxBytes > 0 {
+		opts = append(opts, nats.MaxRequestMaxBytes(args.MaxRequestMaxBytes))
+	}
+	if args.WatermarkBucket != "" {
+		startSeq, err := b.resetToWatermark(streamName, args)
+		if err != nil {
+			return nil, err
+		}
+		if startSeq > 0 {
+			opts = append(opts, nats.StartSequence(startSeq))
+		}
+	}
+	var subscription *nats.Subscription
+	err := b.startupRetry.do(b.logger, "subscribe", func() error {
+		if !args.Ephemeral {
+			if err := b.checkConsumer(streamName, args, maxAckPending); err != nil {
+				return err
+			}
+		}
+		var err error
+		if args.Push {
+			subscription, err = b.subscribePush(args, opts)
+		} else {
+			subscription, err = b.jetStreamContext.PullSubscribe(args.Subject, args.ConsumerName, opts...)
+		}
+		return err
+	})
+	return subscription, err
+}
+
+// subscribePush creates a push subscription, which is shared by the members of the QueueGroup of args.
+func (b *natsBridge) subscribePush(args SubscriberArgs, opts []nats.SubOpt) (*nats.Subscription, error) {
+	if !args.Ephemeral {
+		opts = append(opts, nats.Durable(args.ConsumerName))
+	}
+	if args.QueueGroup != "" {
+		return b.jetStreamContext.QueueSubscribeSync(args.Subject, args.QueueGroup, opts...)
+	}
+	return b.jetStreamContext.SubscribeSync(args.Subject, opts...)
+}
+
+// deliverOpt returns the option of the DeliverPolicy of args, or nil for the default policy.
+func deliverOpt(args SubscriberArgs) nats.SubOpt {
+	switch args.DeliverPolicy {
+	case nats.DeliverLastPolicy:
+		return nats.DeliverLast()
+	case nats.DeliverNewPolicy:
+		return nats.DeliverNew()
+	case nats.DeliverByStartSequencePolicy:
+		return nats.StartSequence(args.OptStartSeq)
+	case nats.DeliverByStartTimePolicy:
+		return nats.StartTime(args.OptStartTime)
+	case nats.DeliverLastPerSubjectPolicy:
+		return nats.DeliverLastPerSubject()
+	default:
+		return nil
+	}
+}
+
+// subscribeOrdered creates a push subscription of an ordered consumer, which the client recreates on gaps.
+func (b *natsBridge) subscribeOrdered(streamName string, args SubscriberArgs) (*nats.Subscription, error) {
+	opts := []nats.SubOpt{nats.OrderedConsumer()}
+	if streamName != "" {
+		opts = append(opts, nats.BindStream(streamName))
+	}
+	if deliver := deliverOpt(args); deliver != nil {
+		opts = append(opts, deliver)
+	}
+	if args.InactiveThreshold > 0 {
+		opts = append(opts, nats.InactiveThreshold(args.InactiveThreshold))
+	}
+	var subscription *nats.Subscription
+	err := b.startupRetry.do(b.logger, "subscribe", func() error {
+		var err error
+		subscription, err = b.jetStreamContext.SubscribeSync(args.Subject, opts...)
+		return err
+	})
+	return subscription, err
+}
+
+// checkConsumer returns a ModeMismatchError, if the consumer exists with another MaxAckPending, an error, if it
+// exists with another MaxDeliver, and an error wrapping nats.ErrSubjectMismatch, if it exists with another
+// filter subject. Unlike PullSubscribe, it also detects consumers without filter subject, e.g. created by the
+// NATS CLI, which would otherwise silently keep receiving all messages of the stream.
+func (b *natsBridge) checkConsumer(streamName string, args SubscriberArgs, maxAckPending int) error {
+	if streamName == "" {
+		var err error
+		if streamName, err = b.jetStreamContext.StreamNameBySubject(args.Subject); err != nil {
+			return nil // PullSubscribe reports the missing stream
+		}
+	}
+	info, err := b.jetStreamContext.ConsumerInfo(streamName, args.ConsumerName)
+	if errors.Is(err, nats.ErrConsumerNotFound) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("consumer info could not be fetched: %w", err)
+	}
+
+	if existing := info.Config.MaxAckPending; existing != maxAckPending {
+		return &ModeMismatchError{
+			ConsumerName:           args.ConsumerName,
+			Existing:               modeOfMaxAckPending(existing),
+			Requested:              args.Mode,
+			ExistingMaxAckPending:  existing,
+			RequestedMaxAckPending: maxAckPending,
+		}
+	}
+	// PullSubscribe only compares a requested MaxDeliver above 0, so it would silently keep the limit of the
+	// consumer, when unlimited deliveries are requested. The server stores unlimited deliveries as -1.
+	existingMaxDeliver, requestedMaxDeliver := info.Config.MaxDeliver, args.MaxDeliver
+	if existingMaxDeliver <= 0 {
+		existingMaxDeliver = -1
+	}
+	if requestedMaxDeliver <= 0 {
+		requestedMaxDeliver = -1
+	}
+	if existingMaxDeliver != requestedMaxDeliver {
+		return fmt.Errorf("consumer %s exists with MaxDeliver %d, but MaxDeliver %d was requested. The MaxDeliver "+
+			"cannot be changed, use Connection.MigrateConsumer", args.ConsumerName, existingMaxDeliver,
+			requestedMaxDeliver)
+	}
+
+	filter := info.Config.FilterSubject
+	if filter == args.Subject {
+		return nil
+	}
+	if filter == "" {
+		stream, err := b.jetStreamContext.StreamInfo(streamName)
+		if err != nil {
+			return fmt.Errorf("stream info could not be fetched: %w", err)
+		}
+		if subjects := stream.Config.Subjects; len(subjects) == 1 && subjects[0] == args.Subject {
+			return nil
+		}
+		filter = "all subjects of the stream"
+	}
+	return fmt.Errorf("%w: consumer %s filters %s, but subject %s was requested. The filter cannot be changed, "+
+		"use Connection.MigrateConsumer", nats.ErrSubjectMismatch, args.ConsumerName, filter, args.Subject)
+}
+
+func (b *natsBridge) GetMsg(streamName string, seq uint64) (*nats.RawStreamMsg, error) {
+	return b.jetStreamContext.GetMsg(streamName, seq)
+}
+
+func (b *natsBridge) DeleteMsg(streamName string, seq uint64, secureErase bool) error {
+	if secureErase {
+		return b.jetStreamContext.SecureDeleteMsg(streamName, seq)
+	}
+	return b.jetStreamContext.DeleteMsg(streamName, seq)
+}
+
+func (b *natsBridge) Ping(ctx context.Context) error {
+	if !b.connection.IsConnected() {
+		return fmt.Errorf("%w: connection is %s", ErrDisconnected, b.connection.Status())
+	}
+	if _, err := b.jetStreamContext.AccountInfo(nats.Context(ctx)); err != nil {
+		return fmt.Errorf("%w: %w", ErrJetStreamUnavailable, err)
+	}
+	return nil
+}
+
+func (b *natsBridge) StreamInfo(streamName string) (*nats.StreamInfo, error) {
+	return b.jetStreamContext.StreamInfo(streamName)
+}
+
+func (b *natsBridge) StreamSubjects(streamName, filter string) (map[string]uint64, error) {
+	info, err := b.jetStreamContext.StreamInfo(streamName, &nats.StreamInfoRequest{SubjectsFilter: filter})
+	if err != nil {
+		return nil, err
+	}
+	if info.State.Subjects == nil {
+		return map[string]uint64{}, nil
+	}
+	return info.State.Subjects, nil
+}
+
+func (b *natsBridge) PurgeStream(streamName string, req *nats.StreamPurgeRequest) error {
+	return b.jetStreamContext.PurgeStream(streamName, req)
+}
+
+func (b *natsBridge) MigrateConsumer(streamName, consumerName string, cfg *nats.ConsumerConfig) error {
+	info, err := b.jetStreamContext.ConsumerInfo(streamName, consumerName)
+	if err != nil {
+		return fmt.Errorf("consumer info could not be fetched: %w", err)
+	}
+
+	startSeq := info.AckFloor.Stream + 1
+	newCfg := *cfg
+	newCfg.Durable = consumerName
+	newCfg.DeliverPolicy = nats.DeliverByStartSequencePolicy
+	newCfg.OptStartSeq = startSeq
+	newCfg.OptStartTime = nil
+
+	// The consumer is deleted only, if the server accepts the new config, so that it is not lost.
+	if err := b.validateConsumerConfig(streamName, newCfg); err != nil {
+		return fmt.Errorf("consumer config is invalid: %w", err)
+	}
+	if info.Delivered.Stream > info.AckFloor.Stream {
+		b.logger.Warn("Messages above the ack floor are delivered again after the migration, even if they were acked",
+			slog.String("name", consumerName), slog.Uint64("startSequence", startSeq),
+			slog.Uint64("deliveredSequence", info.Delivered.Stream))
+	}
+
+	if err := b.jetStreamContext.DeleteConsumer(streamName, consumerName); err != nil {
+		return fmt.Errorf("consumer could not be deleted: %w", err)
+	}
+	b.logger.Info("Deleted consumer for migration", slog.String("name", consumerName),
+		slog.Uint64("startSequence", startSeq))
+
+	if _, err := b.jetStreamContext.AddConsumer(streamName, &newCfg); err != nil {
+		err = fmt.Errorf("consumer could not be recreated from sequence %d: %w", startSeq, err)
+		// Restore the previous config at the same position, so that the consumer is not lost.
+		oldCfg := info.Config
+		oldCfg.DeliverPolicy = nats.DeliverByStartSequencePolicy
+		oldCfg.OptStartSeq = startSeq
+		oldCfg.OptStartTime = nil
+		if _, restoreErr := b.jetStreamContext.AddConsumer(streamName, &oldCfg); restoreErr != nil {
+			return errors.Join(err, fmt.Errorf("previous consumer could not be restored: %w", restoreErr))
+		}
+		return err
+	}
+	return nil
+}
+
+// validateConsumerConfig creates a temporary consumer with the config, which the server deletes again after
+// being inactive, if deleting it fails.
+func (b *natsBridge) validateConsumerConfig(streamName string, cfg nats.ConsumerConfig) error {
+	cfg.Durable = cfg.Durable + migrationConsumerSuffix
+	cfg.InactiveThreshold = migrationConsumerInactiveThreshold
+	if cfg.DeliverSubject != "" { // nobody listens, so that the temporary push consumer delivers nothing
+		cfg.DeliverSubject = nats.NewInbox()
+	}
+	if _, err := b.jetStreamContext.AddConsumer(streamName, &cfg); err != nil {
+		return err
+	}
+	if err := b.jetStreamContext.DeleteConsumer(streamName, cfg.Durable); err != nil {
+		b.logger.Warn("Temporary consumer of migration could not be deleted", slog.String("name", cfg.Durable),
+			slog.String("error", err.Error()))
+	}
+	return nil
+}
+
+func (b *natsBridge) DrainConsumer(ctx context.Context, streamName, consumerName string) (uint64, error) {
+	info, err := b.jetStreamContext.ConsumerInfo(streamName, consumerName)
+	if err != nil {
+		return 0, fmt.Errorf("consumer info could not be fetched: %w", err)
+	}
+	subscription, err := b.jetStreamContext.PullSubscribe(info.Config.FilterSubject, consumerName,
+		nats.Bind(streamName, consumerName))
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if err := subscription.Unsubscribe(); err != nil {
+			b.logger.Error("Subscription of drained consumer could not be closed", slog.String("error", err.Error()))
+		}
+	}()
+
+	var drained uint64
+	for ctx.Err() == nil {
+		msgs, err := subscription.Fetch(defaultDrainBatch, nats.MaxWait(defaultDrainFetchWait))
+		if errors.Is(err, nats.ErrTimeout) {
+			return drained, nil
+		} else if err != nil {
+			return drained, err
+		}
+		for i, msg := range msgs {
+			if i < len(msgs)-1 {
+				err = msg.Ack()
+			} else { // Acks are handled in order, so the last one confirms the batch
+				err = msg.AckSync()
+			}
+			if err != nil {
+				return drained, err
+			}
+		}
+		drained += uint64(len(msgs))
+	}
+	return drained, ctx.Err()
+}
+
+// resetToWatermark deletes the consumer, if a watermark is stored, and returns the sequence to recreate it
+// from. It returns 0, if no watermark is stored and the consumer keeps its state.
+func (b *natsBridge) resetToWatermark(streamName string, args SubscriberArgs) (uint64, error) {
+	watermark, err := b.Watermark(args.WatermarkBucket, watermarkKey(args))
+	if err != nil {
+		return 0, fmt.Errorf("watermark could not be read: %w", err)
+	}
+	if watermark == 0 {
+		return 0, nil
+	}
+
+	if streamName == "" {
+		if streamName, err = b.jetStreamContext.StreamNameBySubject(args.Subject); err != nil {
+			return 0, fmt.Errorf("stream of subject %s could not be found: %w", args.Subject, err)
+		}
+	}
+	if err := b.jetStreamContext.DeleteConsumer(streamName, args.ConsumerName); err != nil &&
+		!errors.Is(err, nats.ErrConsumerNotFound) {
+		return 0, fmt.Errorf("consumer could not be deleted: %w", err)
+	}
+	b.logger.Info("Reset consumer to watermark", slog.String("name", args.ConsumerName),
+		slog.Uint64("watermark", watermark))
+	return watermark + 1, nil
+}
+
+func (b *natsBridge) Watermark(bucket, key string) (uint64, error) {
+	kv, err := b.jetStreamContext.KeyValue(bucket)
+	if err != nil {
+		return 0, err
+	}
+	entry, err := kv.Get(key)
+	if errors.Is(err, nats.ErrKeyNotFound) {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+	return strconv.ParseUint(string(entry.Value()), 10, 64)
+}
+
+func (b *natsBridge) PutWatermark(bucket, key string, seq uint64) error {
+	kv, err := b.jetStreamContext.KeyValue(bucket)
+	if err != nil {
+		return err
+	}
+	_, err = kv.Put(key, []byte(strconv.FormatUint(seq, 10)))
+	return err
+}
+
+func (b *natsBridge) KeyValue(cfg *nats.KeyValueConfig) (nats.KeyValue, error) {
+	kv, err := b.jetStreamContext.KeyValue(cfg.Bucket)
+	if errors.Is(err, nats.ErrBucketNotFound) {
+		b.logger.Info("Bucket not found, about to create bucket.", slog.String("name", cfg.Bucket))
+		return b.jetStreamContext.CreateKeyValue(cfg)
+	}
+	return kv, err
+}
+
+func (b *natsBridge) ObjectStore(cfg *nats.ObjectStoreConfig) (nats.ObjectStore, error) {
+	store, err := b.jetStreamContext.ObjectStore(cfg.Bucket)
+	if errors.Is(err, nats.ErrStreamNotFound) {
+		b.logger.Info("Object store not found, about to create object store.", slog.String("name", cfg.Bucket))
+		return b.jetStreamContext.CreateObjectStore(cfg)
+	}
+	return store, err
+}
+
+func (b *natsBridge) Request(ctx context.Context, msg *nats.Msg) (*nats.Msg, error) {
+	return b.connection.RequestMsgWithContext(ctx, msg)
+}
+
+func (b *natsBridge) QueueSubscribe(subject, queue string, handler nats.MsgHandler) (*nats.Subscription, error) {
+	return b.connection.QueueSubscribe(subject, queue, handler)
 }
 
 func (b *natsBridge) Servers() []string {
 	return b.connection.Servers()
 }
 
-func (b *natsBridge) Drain() error {
-	return b.connection.Drain()
+func (b *natsBridge) MaxPayload() int64 {
+	return b.connection.MaxPayload()
+}
+
+func (b *natsBridge) Drain(timeout time.Duration) error {
+	select {
+	case <-b.jetStreamContext.PublishAsyncComplete():
+	case <-time.After(defaultPublishAsyncTimeout):
+		b.logger.Error("Timeout while waiting for acks of async published messages",
+			slog.Int("pending", b.jetStreamContext.PublishAsyncPending()))
+	}
+
+	closed := make(chan struct{})
+	b.connection.SetClosedHandler(func(nc *nats.Conn) {
+		logClosed(b.logger, nc)
+		close(closed)
+	})
+	if err := b.connection.Drain(); err != nil {
+		return err
+	}
+
+	select {
+	case <-closed:
+	case <-time.After(timeout):
+		return fmt.Errorf("%w: connection was not closed within %v", nats.ErrDrainTimeout, timeout)
+	}
+	if err := b.connection.LastError(); errors.Is(err, nats.ErrDrainTimeout) {
+		return err
+	}
+	return nil
+}
+
+func logClosed(logger *slog.Logger, nc *nats.Conn) {
+	if err := nc.LastError(); err != nil {
+		logger.Error("Connection closed", slog.String("error", err.Error()))
+	}
 }
@@ -0,0 +1,64 @@
+package vnats
+
+import (
+	"context"
+	"sync"
+)
+
+// ByteBudget caps the total payload bytes of messages, which are handled concurrently by the Subscribers
+// sharing the budget. A handler starts only once the size of its message fits into the remaining budget.
+// A message larger than the whole budget is handled alone, as soon as no other message is in progress.
+type ByteBudget struct {
+	max      int64
+	mu       sync.Mutex
+	inUse    int64
+	released chan struct{}
+}
+
+// NewByteBudget creates a ByteBudget of maxConcurrentBytes to pass to the Subscribers.
+func NewByteBudget(maxConcurrentBytes int64) *ByteBudget {
+	return &ByteBudget{max: maxConcurrentBytes, released: make(chan struct{})}
+}
+
+// InUse returns the payload bytes of the messages in progress.
+func (b *ByteBudget) InUse() int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.inUse
+}
+
+// acquire blocks until size fits into the budget. It returns false, if ctx is done or quit is closed meanwhile.
+func (b *ByteBudget) acquire(ctx context.Context, quit <-chan bool, size int64) bool {
+	if b == nil {
+		return true
+	}
+	for {
+		b.mu.Lock()
+		if b.inUse == 0 || b.inUse+size <= b.max {
+			b.inUse += size
+			b.mu.Unlock()
+			return true
+		}
+		released := b.released
+		b.mu.Unlock()
+
+		select {
+		case <-released:
+		case <-ctx.Done():
+			return false
+		case <-quit:
+			return false
+		}
+	}
+}
+
+func (b *ByteBudget) release(size int64) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.inUse -= size
+	close(b.released)
+	b.released = make(chan struct{})
+}
@@ -0,0 +1,50 @@
+package vnats
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestByteBudget(t *testing.T) {
+	budget := NewByteBudget(100)
+	ctx := context.Background()
+	quit := make(chan bool)
+
+	if !budget.acquire(ctx, quit, 60) || !budget.acquire(ctx, quit, 40) {
+		t.Fatal("Messages within the budget should be acquired")
+	}
+	if got := budget.InUse(); got != 100 {
+		t.Errorf("InUse() = %d, want 100", got)
+	}
+
+	acquired := make(chan bool)
+	go func() { acquired <- budget.acquire(ctx, quit, 50) }()
+	select {
+	case <-acquired:
+		t.Fatal("Message exceeding the remaining budget should wait")
+	case <-time.After(time.Millisecond * 20):
+	}
+	budget.release(60)
+	if !<-acquired {
+		t.Error("Waiting message should be acquired after release")
+	}
+
+	budget.release(40)
+	budget.release(50)
+	if !budget.acquire(ctx, quit, 500) {
+		t.Error("Message larger than the budget should be acquired, if no other message is in progress")
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if budget.acquire(cancelled, quit, 1) {
+		t.Error("acquire() should return false after ctx was cancelled")
+	}
+
+	var noBudget *ByteBudget
+	if !noBudget.acquire(ctx, quit, 1000) {
+		t.Error("acquire() without budget should not wait")
+	}
+	noBudget.release(1000)
+}
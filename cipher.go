@@ -0,0 +1,44 @@
+package vnats
+
+import (
+	"fmt"
+)
+
+// CipherKeyHeader is the name of the header, which contains the KeyID of the Cipher a message was sealed with.
+const CipherKeyHeader = "Vnats-Cipher-Key"
+
+// Cipher encrypts the Data of messages, so that it is encrypted at rest in the stream.
+// The Publisher seals the Data after it was encoded and the Subscriber opens it before it is passed
+// to the handler.
+type Cipher interface {
+	// KeyID identifies the key of the Cipher, like "2024-01". It is sent in the CipherKeyHeader, so that
+	// the Subscriber can pick the matching Cipher while keys are rotated.
+	KeyID() string
+
+	// Seal encrypts the plaintext.
+	Seal(plaintext []byte) ([]byte, error)
+
+	// Open decrypts the ciphertext, which was sealed by this Cipher.
+	Open(ciphertext []byte) ([]byte, error)
+}
+
+// openMsg decrypts the Data of the message with the Cipher matching its CipherKeyHeader.
+// Messages without the header are returned unchanged, so that unencrypted messages can still be read.
+func openMsg(msg Msg, ciphers []Cipher) (Msg, error) {
+	keyID := msg.Header.Get(CipherKeyHeader)
+	if keyID == "" {
+		return msg, nil
+	}
+	for _, cipher := range ciphers {
+		if cipher.KeyID() != keyID {
+			continue
+		}
+		data, err := cipher.Open(msg.Data)
+		if err != nil {
+			return msg, fmt.Errorf("message with msgID: %s could not be decrypted: %w", msg.MsgID, err)
+		}
+		msg.Data = data
+		return msg, nil
+	}
+	return msg, fmt.Errorf("message with msgID: %s is sealed with unknown key %q", msg.MsgID, keyID)
+}
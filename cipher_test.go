@@ -0,0 +1,97 @@
+package vnats
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+)
+
+// xorCipher is a Cipher for tests, which XORs the data with its key.
+type xorCipher struct {
+	keyID string
+	key   byte
+}
+
+func (c xorCipher) KeyID() string {
+	return c.keyID
+}
+
+func (c xorCipher) Seal(plaintext []byte) ([]byte, error) {
+	return c.xor(plaintext), nil
+}
+
+func (c xorCipher) Open(ciphertext []byte) ([]byte, error) {
+	return c.xor(ciphertext), nil
+}
+
+func (c xorCipher) xor(data []byte) []byte {
+	out := make([]byte, len(data))
+	for i, b := range data {
+		out[i] = b ^ c.key
+	}
+	return out
+}
+
+func Test_publisher_Publish_Cipher(t *testing.T) {
+	cipher := xorCipher{keyID: "v2", key: 0x2a}
+	sealed, _ := cipher.Seal([]byte("test message"))
+	conn := makeTestConnection(t, "MESSAGES", 1, sealed, "msg-001", nil)
+	pub := &Publisher{
+		conn:       conn,
+		logger:     slog.Default(),
+		streamName: "MESSAGES",
+		cipher:     cipher,
+	}
+
+	msg := &Msg{Subject: "MESSAGES.Important", MsgID: "msg-001", Data: []byte("test message")}
+	if err := pub.Publish(msg); err != nil {
+		t.Fatal(err)
+	}
+
+	published := conn.nats.(*testBridge).published[0]
+	if got := published.Header.Get(CipherKeyHeader); got != "v2" {
+		t.Errorf("%s = %q, want %q", CipherKeyHeader, got, "v2")
+	}
+	if string(msg.Data) != "test message" {
+		t.Errorf("Data of caller was modified: %s", msg.Data)
+	}
+}
+
+func TestSubscriber_Ciphers(t *testing.T) {
+	oldKey := xorCipher{keyID: "v1", key: 0x11}
+	newKey := xorCipher{keyID: "v2", key: 0x2a}
+	tests := []struct {
+		name        string
+		keyID       string
+		data        []byte
+		wantHandled bool
+	}{
+		{name: "Message sealed with current key", keyID: "v2", data: newKey.xor([]byte("hello")), wantHandled: true},
+		{name: "Message sealed with rotated key", keyID: "v1", data: oldKey.xor([]byte("hello")), wantHandled: true},
+		{name: "Unencrypted message", keyID: "", data: []byte("hello"), wantHandled: true},
+		{name: "Message sealed with unknown key", keyID: "v0", data: []byte("secret"), wantHandled: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []byte
+			sub := makeTestSubscriber(SubscriberArgs{Ciphers: []Cipher{newKey, oldKey}}, func(_ context.Context, msg Msg) error {
+				got = msg.Data
+				return nil
+			})
+
+			natsMsg := makeTestJSMsg(integrationTestStreamName+".cipher", tt.data, 1)
+			if tt.keyID != "" {
+				natsMsg.Header.Set(CipherKeyHeader, tt.keyID)
+			}
+			sub.handleMsg(context.Background(), natsMsg)
+
+			if handled := got != nil; handled != tt.wantHandled {
+				t.Fatalf("Handler called = %v, want %v", handled, tt.wantHandled)
+			}
+			if tt.wantHandled && !bytes.Equal(got, []byte("hello")) {
+				t.Errorf("Data = %q, want %q", got, "hello")
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package vnats
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// BreakerState is the state of the CircuitBreaker of a Subscriber.
+type BreakerState int
+
+const (
+	// BreakerClosed is the normal state, messages are fetched and handled.
+	BreakerClosed BreakerState = iota
+
+	// BreakerOpen means the Subscriber pauses fetching until the cooldown elapsed.
+	BreakerOpen
+
+	// BreakerHalfOpen means the cooldown elapsed and a single message is fetched as probe.
+	// If the probe succeeds the breaker is closed again, otherwise it is reopened.
+	BreakerHalfOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// CircuitBreaker configures the Subscriber to pause fetching after consecutive handler errors,
+// so that a failing downstream dependency is not hammered with retries. Errors wrapping ErrInvalidMsg
+// or context.Canceled are not counted.
+type CircuitBreaker struct {
+	// Threshold is the number of consecutive handler errors that opens the breaker.
+	Threshold int
+
+	// Cooldown is the time the Subscriber pauses fetching while the breaker is open.
+	Cooldown time.Duration
+
+	// OnStateChange is called whenever the breaker changes its state. Optional.
+	OnStateChange func(state BreakerState)
+}
+
+type circuitBreaker struct {
+	config   CircuitBreaker
+	mu       sync.Mutex
+	state    BreakerState
+	failures int
+	openedAt time.Time
+	now      func() time.Time
+}
+
+func newCircuitBreaker(config *CircuitBreaker) *circuitBreaker {
+	if config == nil || config.Threshold < 1 {
+		return nil
+	}
+	return &circuitBreaker{
+		config: *config,
+		now:    time.Now,
+	}
+}
+
+// wait returns the remaining cooldown if the breaker is open. When the cooldown elapsed,
+// the breaker is switched to BreakerHalfOpen and zero is returned.
+func (b *circuitBreaker) wait() time.Duration {
+	if b == nil {
+		return 0
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state != BreakerOpen {
+		return 0
+	}
+	if remaining := b.config.Cooldown - b.now().Sub(b.openedAt); remaining > 0 {
+		return remaining
+	}
+	b.setState(BreakerHalfOpen)
+	return 0
+}
+
+// isOpen returns true, if the breaker is open, so that no further messages should be handled.
+func (b *circuitBreaker) isOpen() bool {
+	if b == nil {
+		return false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state == BreakerOpen
+}
+
+// batchSize returns the number of messages to fetch. While the breaker is half-open, a single message is
+// fetched as probe.
+func (b *circuitBreaker) batchSize(batch int) int {
+	if b == nil {
+		return batch
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == BreakerHalfOpen {
+		return 1
+	}
+	return batch
+}
+
+// record updates the breaker with the result of a handled message. Invalid messages and handlers cancelled by
+// a shutdown say nothing about the downstream dependency, so they neither count as failure nor as success.
+func (b *circuitBreaker) record(err error) {
+	if b == nil || errors.Is(err, ErrInvalidMsg) || errors.Is(err, context.Canceled) {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err == nil {
+		b.failures = 0
+		b.setState(BreakerClosed)
+		return
+	}
+
+	b.failures++
+	if b.state == BreakerHalfOpen || b.failures >= b.config.Threshold {
+		b.openedAt = b.now()
+		b.setState(BreakerOpen)
+	}
+}
+
+func (b *circuitBreaker) setState(state BreakerState) {
+	if b.state == state {
+		return
+	}
+	b.state = state
+	if b.config.OnStateChange != nil {
+		b.config.OnStateChange(state)
+	}
+}
@@ -0,0 +1,127 @@
+package vnats
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+func Test_circuitBreaker(t *testing.T) {
+	var states []BreakerState
+	now := time.Now()
+
+	b := newCircuitBreaker(&CircuitBreaker{
+		Threshold:     2,
+		Cooldown:      time.Second,
+		OnStateChange: func(state BreakerState) { states = append(states, state) },
+	})
+	b.now = func() time.Time { return now }
+
+	errHandler := errors.New("downstream is down")
+
+	b.record(errHandler)
+	if wait := b.wait(); wait != 0 {
+		t.Fatalf("breaker opened before threshold was reached, wait = %v", wait)
+	}
+
+	b.record(errHandler)
+	if wait := b.wait(); wait != time.Second {
+		t.Fatalf("breaker should be open for the cooldown, wait = %v", wait)
+	}
+
+	now = now.Add(time.Second)
+	if wait := b.wait(); wait != 0 {
+		t.Fatalf("breaker should be half-open after cooldown, wait = %v", wait)
+	}
+
+	b.record(errHandler)
+	if wait := b.wait(); wait != time.Second {
+		t.Fatalf("failed probe should reopen the breaker, wait = %v", wait)
+	}
+
+	now = now.Add(time.Second)
+	b.wait()
+	b.record(nil)
+
+	want := []BreakerState{BreakerOpen, BreakerHalfOpen, BreakerOpen, BreakerHalfOpen, BreakerClosed}
+	if !reflect.DeepEqual(states, want) {
+		t.Errorf("state changes = %v, want %v", states, want)
+	}
+}
+
+func Test_circuitBreaker_IgnoredErrors(t *testing.T) {
+	b := newCircuitBreaker(&CircuitBreaker{Threshold: 2, Cooldown: time.Second})
+	errHandler := errors.New("downstream is down")
+
+	b.record(errHandler)
+	b.record(fmt.Errorf("%w: unknown type", ErrInvalidMsg))
+	b.record(fmt.Errorf("shutdown: %w", context.Canceled))
+	if b.isOpen() || b.failures != 1 {
+		t.Fatalf("Invalid messages and cancelled handlers should not count, got %d failures", b.failures)
+	}
+	b.record(errHandler)
+	if !b.isOpen() {
+		t.Errorf("Breaker should be open after consecutive handler errors")
+	}
+}
+
+func Test_newCircuitBreaker_Disabled(t *testing.T) {
+	if b := newCircuitBreaker(nil); b != nil {
+		t.Errorf("newCircuitBreaker(nil) = %v, want nil", b)
+	}
+	if b := newCircuitBreaker(&CircuitBreaker{}); b != nil {
+		t.Errorf("newCircuitBreaker() without threshold = %v, want nil", b)
+	}
+
+	var b *circuitBreaker
+	b.record(errors.New("nil breaker must be usable"))
+	if wait := b.wait(); wait != 0 {
+		t.Errorf("nil breaker wait = %v, want 0", wait)
+	}
+}
+
+func Test_circuitBreaker_batchSize(t *testing.T) {
+	now := time.Now()
+	b := newCircuitBreaker(&CircuitBreaker{Threshold: 1, Cooldown: time.Second})
+	b.now = func() time.Time { return now }
+
+	if got := b.batchSize(100); got != 100 {
+		t.Errorf("batchSize() while closed = %d, want 100", got)
+	}
+	b.record(errors.New("downstream is down"))
+	now = now.Add(time.Second)
+	b.wait()
+	if got := b.batchSize(100); got != 1 {
+		t.Errorf("batchSize() while half-open = %d, want 1", got)
+	}
+
+	var disabled *circuitBreaker
+	if got := disabled.batchSize(100); got != 100 {
+		t.Errorf("nil breaker batchSize() = %d, want 100", got)
+	}
+}
+
+func TestSubscriber_handleMessages_CircuitBreaker(t *testing.T) {
+	calls := 0
+	sub := makeTestSubscriber(SubscriberArgs{
+		CircuitBreaker: &CircuitBreaker{Threshold: 2, Cooldown: time.Minute},
+	}, func(_ context.Context, _ Msg) error {
+		calls++
+		return errors.New("downstream is down")
+	})
+
+	natsMsgs := make([]*nats.Msg, 5)
+	for i := range natsMsgs {
+		natsMsgs[i] = makeTestJSMsg(integrationTestStreamName+".breaker", []byte("hello"), 1)
+	}
+	sub.handleMessages(context.Background(), natsMsgs)
+
+	if calls != 2 {
+		t.Errorf("Handler called %d times, want 2 until the breaker opened", calls)
+	}
+}
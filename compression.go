@@ -0,0 +1,78 @@
+package vnats
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"maps"
+)
+
+// ContentEncodingHeader is the name of the header, which contains the Compression of the Data of a message.
+const ContentEncodingHeader = "Content-Encoding"
+
+// Compression describes how the Data of a message is compressed, like "gzip". It is sent in the
+// ContentEncodingHeader, so that the Subscriber knows how to decompress the Data before it is passed to the
+// handler.
+type Compression string
+
+const (
+	// CompressionNone publishes the Data unchanged and sends no ContentEncodingHeader.
+	CompressionNone Compression = ""
+
+	// CompressionGzip compresses the Data with gzip, which pays off for large and repetitive payloads like JSON.
+	CompressionGzip Compression = "gzip"
+)
+
+func (c Compression) compress(data []byte) ([]byte, error) {
+	switch c {
+	case CompressionNone:
+		return data, nil
+	case CompressionGzip:
+		var buf bytes.Buffer
+		w := gzip.NewWriter(&buf)
+		if _, err := w.Write(data); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	default:
+		return nil, fmt.Errorf("compression %q is not supported", c)
+	}
+}
+
+func (c Compression) decompress(data []byte) ([]byte, error) {
+	switch c {
+	case CompressionNone:
+		return data, nil
+	case CompressionGzip:
+		r, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+		return io.ReadAll(r)
+	default:
+		return nil, fmt.Errorf("compression %q is not supported", c)
+	}
+}
+
+// decompressMsg decompresses the Data of the message according to its ContentEncodingHeader, which is removed
+// from a copy of the Header, so that the message can be published again. Messages without the header are
+// returned unchanged, so that uncompressed messages can still be read.
+func decompressMsg(msg Msg) (Msg, error) {
+	compression := Compression(msg.Header.Get(ContentEncodingHeader))
+	if compression == CompressionNone {
+		return msg, nil
+	}
+	data, err := compression.decompress(msg.Data)
+	if err != nil {
+		return msg, fmt.Errorf("message with msgID: %s could not be decompressed: %w", msg.MsgID, err)
+	}
+	msg.Data = data
+	msg.Header = maps.Clone(msg.Header)
+	delete(msg.Header, ContentEncodingHeader)
+	return msg, nil
+}
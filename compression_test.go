@@ -0,0 +1,98 @@
+package vnats
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func Test_publisher_Publish_Compression_RoundTrip(t *testing.T) {
+	data := []byte(`[` + strings.Repeat(`{"sku":"4711","name":"Shirt","price":19.99},`, 10000) + `{}]`)
+	compressed, err := CompressionGzip.compress(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(compressed) >= len(data)/10 {
+		t.Errorf("Compressed size = %d, want less than a tenth of %d", len(compressed), len(data))
+	}
+	conn := makeTestConnection(t, integrationTestStreamName, 1, compressed, "msg-001", nil)
+	pub := &Publisher{
+		conn:        conn,
+		logger:      slog.Default(),
+		streamName:  integrationTestStreamName,
+		compression: CompressionGzip,
+	}
+
+	msg := &Msg{Subject: integrationTestStreamName + ".Important", MsgID: "msg-001", Data: data, Encoding: EncJSON}
+	if err := pub.Publish(msg); err != nil {
+		t.Fatal(err)
+	}
+
+	published := conn.nats.(*testBridge).published[0]
+	if got := published.Header.Get(ContentEncodingHeader); got != string(CompressionGzip) {
+		t.Errorf("%s = %q, want %q", ContentEncodingHeader, got, CompressionGzip)
+	}
+	if !bytes.Equal(msg.Data, data) {
+		t.Error("Data of caller was modified")
+	}
+
+	var got []byte
+	sub := makeTestSubscriber(SubscriberArgs{}, func(_ context.Context, msg Msg) error {
+		got = msg.Data
+		return nil
+	})
+	natsMsg := makeTestJSMsg(published.Subject, published.Data, 1)
+	natsMsg.Header = published.Header
+	sub.handleMsg(context.Background(), natsMsg)
+
+	if !bytes.Equal(got, data) {
+		t.Errorf("Handled Data differs from published Data, len = %d, want %d", len(got), len(data))
+	}
+}
+
+func TestSubscriber_Compression(t *testing.T) {
+	gzipped, _ := CompressionGzip.compress([]byte("hello"))
+	tests := []struct {
+		name        string
+		compression string
+		data        []byte
+		wantHandled bool
+	}{
+		{name: "Gzip compressed message", compression: "gzip", data: gzipped, wantHandled: true},
+		{name: "Uncompressed message", compression: "", data: []byte("hello"), wantHandled: true},
+		{name: "Corrupt gzip message", compression: "gzip", data: []byte("hello"), wantHandled: false},
+		{name: "Unknown compression", compression: "br", data: []byte("hello"), wantHandled: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []byte
+			sub := makeTestSubscriber(SubscriberArgs{}, func(_ context.Context, msg Msg) error {
+				got = msg.Data
+				return nil
+			})
+
+			natsMsg := makeTestJSMsg(integrationTestStreamName+".compression", tt.data, 1)
+			if tt.compression != "" {
+				natsMsg.Header.Set(ContentEncodingHeader, tt.compression)
+			}
+			sub.handleMsg(context.Background(), natsMsg)
+
+			if handled := got != nil; handled != tt.wantHandled {
+				t.Fatalf("Handler called = %v, want %v", handled, tt.wantHandled)
+			}
+			if tt.wantHandled && !bytes.Equal(got, []byte("hello")) {
+				t.Errorf("Data = %q, want %q", got, "hello")
+			}
+		})
+	}
+}
+
+func TestConnection_NewPublisher_UnsupportedCompression(t *testing.T) {
+	conn := makeTestConnection(t, integrationTestStreamName, 1, nil, "", nil)
+	_, err := conn.NewPublisher(PublisherArgs{StreamName: integrationTestStreamName, Compression: "br"})
+	if err == nil {
+		t.Fatal("NewPublisher() error = nil, want error for unsupported compression")
+	}
+}
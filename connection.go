@@ -1,11 +1,17 @@
 package vnats
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"os"
+	"slices"
 	"strings"
+	"sync"
+	"time"
 
+	natsServer "github.com/nats-io/nats-server/v2/server"
 	"github.com/nats-io/nats.go"
 )
 
@@ -23,8 +29,101 @@ const (
 	// message returns error, the Subscriber of consumer will retry the failed message until resolved. This blocks the
 	// entire consumer, so that horizontal scaling is not effectively possible.
 	SingleSubscriberStrictMessageOrder
+
+	// OrderedConsumer mode delivers the messages in order to a single Subscriber by a NATS ordered consumer,
+	// which is much faster than SingleSubscriberStrictMessageOrder, since messages are pushed without waiting
+	// for acknowledgements. The consumer is ephemeral and recreated by the client on gaps or reconnects, so the
+	// ConsumerName is a label for logs and metrics only and a restarted Subscriber starts again at the
+	// DeliverPolicy. Messages are not acknowledged and cannot be redelivered, so a failed message is retried
+	// in place until its handler succeeds, returns ErrInvalidMsg or the Subscriber quits, and messages, which
+	// cannot be decrypted, are skipped. Options depending on acknowledgements, like AckWait or MaxDeliver,
+	// cannot be combined with it.
+	OrderedConsumer
+)
+
+const (
+	// DefaultAckWaitMultipleSubscribers is the AckWait of MultipleSubscribersAllowed consumers. It is long, since
+	// other Subscribers continue with further messages, while a message of a failed Subscriber waits for redelivery.
+	DefaultAckWaitMultipleSubscribers = time.Second * 30
+
+	// DefaultAckWaitStrictMessageOrder is the AckWait of SingleSubscriberStrictMessageOrder consumers. The whole
+	// consumer is blocked until the message of a failed Subscriber is redelivered, so a shorter SubscriberArgs.AckWait
+	// may be preferable. It equals the AckWait of consumers created by earlier versions, since the AckWait of an
+	// existing consumer cannot be changed without Connection.MigrateConsumer.
+	DefaultAckWaitStrictMessageOrder = time.Second * 30
 )
 
+// String returns the name of the mode.
+func (m SubscriptionMode) String() string {
+	switch m {
+	case MultipleSubscribersAllowed:
+		return "MultipleSubscribersAllowed"
+	case SingleSubscriberStrictMessageOrder:
+		return "SingleSubscriberStrictMessageOrder"
+	case OrderedConsumer:
+		return "OrderedConsumer"
+	}
+	return fmt.Sprintf("SubscriptionMode(%d)", int(m))
+}
+
+// ErrSubscriptionModeMismatch is matched by errors.Is for every ModeMismatchError.
+var ErrSubscriptionModeMismatch = errors.New("subscription mode mismatch")
+
+// ModeMismatchError is returned by NewSubscriber, if the consumer exists with another MaxAckPending than
+// requested, usually because it was created with another Mode. The MaxAckPending of the consumer can be
+// changed by deleting and recreating it, e.g. by Connection.MigrateConsumer.
+type ModeMismatchError struct {
+	// ConsumerName is the name of the existing consumer.
+	ConsumerName string
+
+	// Existing is the mode of the existing consumer, derived from its MaxAckPending.
+	Existing SubscriptionMode
+
+	// Requested is the Mode of the SubscriberArgs.
+	Requested SubscriptionMode
+
+	// ExistingMaxAckPending is the MaxAckPending of the existing consumer.
+	ExistingMaxAckPending int
+
+	// RequestedMaxAckPending is the effective MaxAckPending of the SubscriberArgs.
+	RequestedMaxAckPending int
+}
+
+func (e *ModeMismatchError) Error() string {
+	return fmt.Sprintf("%s: consumer %s exists with MaxAckPending %d of mode %s, but MaxAckPending %d of mode %s "+
+		"was requested", ErrSubscriptionModeMismatch, e.ConsumerName, e.ExistingMaxAckPending, e.Existing,
+		e.RequestedMaxAckPending, e.Requested)
+}
+
+// Is reports whether target is ErrSubscriptionModeMismatch.
+func (e *ModeMismatchError) Is(target error) bool {
+	return target == ErrSubscriptionModeMismatch
+}
+
+// modeOfMaxAckPending returns the mode, which creates consumers with the MaxAckPending by default.
+func modeOfMaxAckPending(maxAckPending int) SubscriptionMode {
+	if maxAckPending == 1 {
+		return SingleSubscriberStrictMessageOrder
+	}
+	return MultipleSubscribersAllowed
+}
+
+// defaultAckWait returns the default AckWait of the mode.
+func (m SubscriptionMode) defaultAckWait() time.Duration {
+	if m == SingleSubscriberStrictMessageOrder {
+		return DefaultAckWaitStrictMessageOrder
+	}
+	return DefaultAckWaitMultipleSubscribers
+}
+
+// defaultMaxAckPending returns the default MaxAckPending of the mode.
+func (m SubscriptionMode) defaultMaxAckPending() int {
+	if m == SingleSubscriberStrictMessageOrder {
+		return 1
+	}
+	return natsServer.JsDefaultMaxAckPending
+}
+
 // Config is a struct to hold the configuration of a NATS connection.
 type Config struct {
 	Password string
@@ -36,34 +135,99 @@ type Config struct {
 // Connection is the main entry point for the library. It is used to create Publishers and Subscribers.
 // It is also used to close the connection to the NATS server/ cluster.
 type Connection struct {
-	nats        bridge
-	logger      *slog.Logger
-	subscribers []*Subscriber
+	nats            bridge
+	logger          *slog.Logger
+	mu              sync.Mutex // guards subscribers and publishers
+	subscribers     []*Subscriber
+	publishers      []*Publisher
+	streamResolver  StreamResolver
+	allowedSubjects []string
+	natsOptions     []nats.Option
+	drainTimeout    time.Duration
+	metrics         Metrics
+	tracer          Tracer
 }
 
+// StreamResolver returns the name of the stream, which contains the given subject.
+type StreamResolver func(subject string) string
+
 // bridge is required to use a mock for the nats functions in unit tests
 type bridge interface {
 	// EnsureStreamExists checks if a *nats.StreamInfo for the given streamConfig can be fetched.
 	// If not it will be added.
 	EnsureStreamExists(streamConfig *nats.StreamConfig) error
 
-	// Subscribe creates a natsSubscription, that can fetch messages from a specified subject.
-	// The first token, separated by dots, of a subject will be interpreted as the streamName.
-	Subscribe(subject, consumerName string, mode SubscriptionMode) (*nats.Subscription, error)
+	// Subscribe creates a natsSubscription, that can fetch messages from the subject of args.
+	// If streamName is empty, the stream is looked up by the subject.
+	Subscribe(streamName string, args SubscriberArgs) (*nats.Subscription, error)
 
 	// Servers returns the list of NATS servers.
 	Servers() []string
 
-	// PublishMsg publishes a message with a context-dependent msgID to a subject.
-	PublishMsg(msg *nats.Msg, msgID string) error
+	// MaxPayload returns the maximum size of a message the server accepts, or 0 if it is not known yet.
+	MaxPayload() int64
+
+	// PublishMsg publishes a message with a context-dependent msgID to a subject. It waits for the ack of the
+	// server until ctx is done, or for the default timeout of the publish, if ctx has no deadline.
+	PublishMsg(ctx context.Context, msg *nats.Msg, msgID string) error
+
+	// PublishMsgAsync publishes a message like PublishMsg, but does not wait for the ack of the server.
+	PublishMsgAsync(msg *nats.Msg, msgID string) (nats.PubAckFuture, error)
+
+	// GetMsg returns the message with the given sequence from the stream.
+	GetMsg(streamName string, seq uint64) (*nats.RawStreamMsg, error)
+
+	// DeleteMsg deletes the message with the given sequence from the stream. If secureErase is set,
+	// the message is overwritten with random data.
+	DeleteMsg(streamName string, seq uint64, secureErase bool) error
+
+	// Ping returns ErrDisconnected, if the connection is not connected to a server, or ErrJetStreamUnavailable,
+	// if the account info of JetStream cannot be fetched until ctx is done.
+	Ping(ctx context.Context) error
+
+	// StreamInfo returns the config and state of the stream.
+	StreamInfo(streamName string) (*nats.StreamInfo, error)
+
+	// StreamSubjects returns the number of messages per subject of the stream matching the filter.
+	StreamSubjects(streamName, filter string) (map[string]uint64, error)
+
+	// PurgeStream removes the messages selected by the request from the stream.
+	PurgeStream(streamName string, req *nats.StreamPurgeRequest) error
+
+	// MigrateConsumer recreates the consumer with the given config, starting after its ack floor.
+	MigrateConsumer(streamName, consumerName string, cfg *nats.ConsumerConfig) error
+
+	// DrainConsumer ACKs all messages, which the consumer delivers until ctx is done, and returns their count.
+	DrainConsumer(ctx context.Context, streamName, consumerName string) (uint64, error)
+
+	// Watermark returns the stream sequence stored under the key of the KV bucket, or 0 if the key is missing.
+	Watermark(bucket, key string) (uint64, error)
+
+	// PutWatermark stores the stream sequence under the key of the KV bucket.
+	PutWatermark(bucket, key string, seq uint64) error
+
+	// KeyValue opens the bucket of cfg, or creates it with cfg, if it does not exist.
+	KeyValue(cfg *nats.KeyValueConfig) (nats.KeyValue, error)
+
+	// ObjectStore opens the bucket of cfg, or creates it with cfg, if it does not exist.
+	ObjectStore(cfg *nats.ObjectStoreConfig) (nats.ObjectStore, error)
+
+	// Request publishes msg by core NATS and returns the first reply, which arrives until ctx is done.
+	Request(ctx context.Context, msg *nats.Msg) (*nats.Msg, error)
+
+	// QueueSubscribe subscribes the handler to the subject by core NATS. Subscriptions of the same queue
+	// share the messages, an empty queue delivers every message to the handler.
+	QueueSubscribe(subject, queue string, handler nats.MsgHandler) (*nats.Subscription, error)
 
 	// Drain will put a Connection into a drain state. All subscriptions will
 	// immediately be put into a drain state. Upon completion, the publishers
 	// will be drained and can not publish any additional messages. Upon draining
 	// of the publishers, the Connection will be closed.
+	// Drain returns once the Connection was closed, or an error wrapping nats.ErrDrainTimeout,
+	// if it was not drained within the timeout.
 	//
 	// See notes for nats.Conn.Drain
-	Drain() error
+	Drain(timeout time.Duration) error
 }
 
 // Option is an optional configuration argument for the Connect() function.
@@ -77,7 +241,7 @@ func Connect(servers []string, options ...Option) (*Connection, error) {
 
 	conn.applyOptions(options...)
 	var err error
-	if conn.nats, err = newNATSBridge(servers, conn.logger); err != nil {
+	if conn.nats, err = newNATSBridge(servers, conn.logger, conn.natsOptions...); err != nil {
 		return nil, fmt.Errorf("NATS Connection could not be created: %w", err)
 	}
 	return conn, nil
@@ -96,6 +260,54 @@ type PublisherArgs struct {
 	// StreamName is the name of the stream like "PRODUCTS" or "ORDERS".
 	// If it does not exist, the stream will be created.
 	StreamName string
+
+	// StreamConfig contains the retention, limits, storage, deduplication and replicas of the stream, if it
+	// is created. See StreamConfig for the defaults.
+	StreamConfig StreamConfig
+
+	// Partitions defines the number of partitions used by Publisher.PartitionSubject to distribute
+	// messages by key. Default is 0, which means partitioning is not used.
+	Partitions int
+
+	// Encoding is the default format of the Data of published messages, which is sent in the
+	// ContentTypeHeader. It can be overridden per message by Msg.Encoding. Default is empty,
+	// which means no ContentTypeHeader is sent.
+	Encoding Encoding
+
+	// Cipher encrypts the Data of every published message. Default is nil, which means messages are
+	// published unencrypted. See Cipher for details.
+	Cipher Cipher
+
+	// Compression compresses the Data of every published message after it was encoded and before it is
+	// encrypted by the Cipher. It is sent in the ContentEncodingHeader, by which the Subscriber decompresses
+	// the Data. Default is CompressionNone.
+	Compression Compression
+
+	// AsyncPublish makes Publish return without waiting for the ack of the server, which increases the
+	// throughput at the cost of durability: Publish does not return an error, if the server fails to store
+	// the message. Such errors are logged and returned by Publisher.Flush. Publish blocks, if too many acks
+	// are pending. Default is false, which means Publish waits for the ack.
+	AsyncPublish bool
+
+	// MaxRetries is the number of times Publish retries a failed publish, e.g. while the stream leader is
+	// elected. Retries are safe, since the server deduplicates messages by MsgID. Default is 0, which means
+	// no retries.
+	MaxRetries int
+
+	// RetryBackoff defines the delay between the retries of Publish. Default is an ExponentialBackoff
+	// starting at 100ms up to 5 seconds.
+	RetryBackoff BackoffStrategy
+
+	// PublishTimeout is the deadline of a single Publish, including the wait for the ack and all retries, so
+	// that a failing message is given up after a known time. An earlier deadline of the context of
+	// PublishWithContext takes precedence. Default is 0, which means every attempt awaits the ack for 5 seconds
+	// and only MaxRetries bounds the retries.
+	PublishTimeout time.Duration
+
+	// Journal records the MsgIDs of published messages, so that messages acknowledged before are not published
+	// again, e.g. when a failed operation is retried after the duplication window of the stream.
+	// It cannot be combined with AsyncPublish. Default is nil, which means only the server deduplicates.
+	Journal PublishJournal
 }
 
 // SubscriberArgs contains the arguments for creating a new Subscriber.
@@ -106,35 +318,289 @@ type SubscriberArgs struct {
 	// name of the service.
 	ConsumerName string
 
+	// Ephemeral creates a consumer, which is named by the server and deleted once the Subscriber is
+	// unsubscribed or the Connection is closed. If the process exits without closing the Connection,
+	// the server deletes the consumer after the InactiveThreshold, or 5 seconds by default. Ephemeral consumers do not resume
+	// after a restart, so ConsumerName, QueueGroup and WatermarkBucket must be empty.
+	// Default is false, which means a durable consumer named ConsumerName is created.
+	Ephemeral bool
+
+	// QueueGroup makes the Subscriber a member of the named work queue, so that deployments and processes
+	// with the same QueueGroup compete for the messages and each message is handled by exactly one member.
+	// A redelivered message, e.g. after a NAK or an expired AckWait, may be handled by another member.
+	// The QueueGroup is the name of the shared consumer, so ConsumerName must be empty or equal.
+	// It requires the Mode MultipleSubscribersAllowed. Default is empty, which means the ConsumerName is used.
+	QueueGroup string
+
+	// Push creates a push consumer, whose messages are sent by the server as soon as they are available,
+	// instead of being pulled in batches of FetchBatchSize. With a QueueGroup, the server distributes the
+	// messages across the connected members of the group. Pull request limits, i.e. MaxRequestBatch,
+	// MaxRequestMaxBytes and a FetchBatchSize above 1, cannot be set. An existing pull consumer of the same
+	// name cannot be switched to push, see Connection.MigrateConsumer.
+	// Default is false, which means messages are pulled.
+	Push bool
+
 	// Subject defines which subjects of the stream should be subscribed.
 	// Examples:
 	//  "ORDERS.new" -> subscribe subject "new" of stream "ORDERS"
 	//  "ORDERS.>"   -> subscribe all subjects in any level of stream "ORDERS".
 	//  "ORDERS.*"   -> subscribe all direct subjects of stream "ORDERS", like "ORDERS.new", "ORDERS.processed",
 	//                  but not "ORDERS.new.error".
+	//  "ORDERS.*.created" -> subscribe the subjects "created" below any direct subject of stream "ORDERS", like
+	//                  "ORDERS.eu.created", but not "ORDERS.eu.deleted".
+	// The wildcards "*" and ">" must be whole tokens, and ">" must be the last token, see ValidateSubject.
 	Subject string
 
+	// StreamName is the name of the stream, which contains the Subject, for subjects not beginning with the
+	// stream name. Default is empty, which means the stream is resolved by the StreamResolver of the Connection,
+	// or looked up by the Subject.
+	StreamName string
+
 	// Mode defines the constraints of the subscription. Default is MultipleSubscribersAllowed.
 	// See SubscriptionMode for details.
 	Mode SubscriptionMode
+
+	// AckWait is the duration the server waits for the acknowledgement of a delivered message, before the message
+	// is redelivered. It should exceed the longest processing time of a message. Default depends on the Mode,
+	// see DefaultAckWaitMultipleSubscribers and DefaultAckWaitStrictMessageOrder. If the consumer already exists
+	// with another AckWait, NewSubscriber returns an error.
+	AckWait time.Duration
+	// AutoInProgress acknowledges a message as in progress every half of the AckWait, while its handler is
+	// running, so that handlers taking longer than the AckWait do not cause a redelivery. If the process
+	// crashes, the acknowledgements stop and the message is redelivered after the AckWait as usual.
+	// Default is false.
+	AutoInProgress bool
+
+	// MaxAckPending limits the number of messages of the consumer, which are delivered but not acknowledged
+	// yet, across all its Subscribers, e.g. 100 to bound the memory of MultipleSubscribersAllowed consumers.
+	// SingleSubscriberStrictMessageOrder requires 1. If the consumer already exists with another MaxAckPending,
+	// NewSubscriber returns an error. Default is 0, which means the default of the Mode: 1 for
+	// SingleSubscriberStrictMessageOrder, otherwise the server default of 1000.
+	MaxAckPending int
+
+	// MaxDeliver is the maximum number of deliveries of a message. A message, whose handler failed on the last
+	// delivery, is not redelivered anymore, so that it stops blocking a SingleSubscriberStrictMessageOrder
+	// consumer. If the consumer already exists with another MaxDeliver, including a limit when 0 is requested,
+	// NewSubscriber returns an error. Default is 0, which means unlimited deliveries.
+	MaxDeliver int
+
+	// InactiveThreshold lets the server delete the consumer, once no Subscriber fetched messages for this
+	// duration, e.g. consumers of a former deployment with other consumer names. It only applies when the
+	// consumer is created. Default is 0, which means durable consumers are kept until they are deleted.
+	InactiveThreshold time.Duration
+
+	// DeadLetterSubject is the subject, to which a message is republished, if its handler failed on the last
+	// delivery of MaxDeliver. The original Data and headers are kept and the OriginalSubjectHeader and
+	// DeliveryCountHeader are added. Afterwards the message is terminated. Requires MaxDeliver.
+	// Default is empty, which means failed messages are not republished.
+	DeadLetterSubject string
+
+	// DeliverPolicy defines the first message delivered to a new consumer, e.g. nats.DeliverNewPolicy to skip
+	// the messages already stored in the stream. It only applies when the consumer is created; if it already
+	// exists with another policy than the one set, NewSubscriber returns an error.
+	// Default is nats.DeliverAllPolicy.
+	DeliverPolicy nats.DeliverPolicy
+
+	// OptStartSeq is the stream sequence of the first message. Requires nats.DeliverByStartSequencePolicy.
+	OptStartSeq uint64
+
+	// OptStartTime is the time of the first message, e.g. an hour ago to replay the last hour.
+	// Requires nats.DeliverByStartTimePolicy. A durable consumer must be resubscribed with the same time,
+	// so relative times suit Ephemeral consumers.
+	OptStartTime time.Time
+
+	// Partitions defines the number of partitions the publisher distributes the messages to.
+	// If set, the Subscriber binds to the subjects of Partition only, e.g. the Subject "EVENTS.created"
+	// becomes "EVENTS.p3.created" for Partition 3. Default is 0, which means partitioning is not used.
+	Partitions int
+
+	// Partition is the partition in the range [0, Partitions) the Subscriber binds to.
+	// It is ignored if Partitions is not set.
+	Partition int
+
+	// PartitionedByServer binds the Subscriber to a partition of StreamConfig.Partitioning instead, whose
+	// subject token is the plain partition number, e.g. the Subject "EVENTS.created.*" becomes
+	// "EVENTS.3.created.*" for Partition 3. Default is false.
+	PartitionedByServer bool
+
+	// CircuitBreaker pauses fetching after consecutive handler errors. Default is nil, which means
+	// the Subscriber retries failing messages without pausing. See CircuitBreaker for details.
+	CircuitBreaker *CircuitBreaker
+
+	// CancelAckBehavior defines how a message is acknowledged, if its handler fails after the context of
+	// Subscriber.StartWithContext was cancelled. Default is NakOnCancel.
+	CancelAckBehavior CancelAckBehavior
+
+	// RedeliveryAlarm fires a callback, when the number of redelivered messages within a time window
+	// exceeds a threshold. Default is nil, which disables the alarm. See RedeliveryAlarm for details.
+	RedeliveryAlarm *RedeliveryAlarm
+
+	// MaxProcessingAge terminates redelivered messages, which were first delivered to the Subscriber longer ago
+	// than this age, so that a single message cannot stall the consumer forever. The age does not depend on when
+	// the message was stored in the stream, so old messages of a backlog are retried as well. The first delivery
+	// of a message is always passed to the handler. Default is 0, which means messages are retried without limit.
+	MaxProcessingAge time.Duration
+
+	// OnMaxProcessingAgeExceeded is called with every message terminated because of MaxProcessingAge. Optional.
+	OnMaxProcessingAgeExceeded func(msg Msg, age time.Duration)
+
+	// Schema validates the payload of every message before the handler is called. Invalid messages are
+	// terminated, since they will never become valid, and passed to OnInvalidMsg. Default is nil, which
+	// means messages are not validated.
+	Schema SchemaValidator
+
+	// OnInvalidMsg is called with every message terminated because it does not match the Schema or the
+	// handler returned ErrInvalidMsg. Optional.
+	OnInvalidMsg func(msg Msg, err error)
+
+	// MaxRequestBatch limits the number of messages a single pull request of any client of the consumer
+	// may fetch. Default is 0, which means unlimited.
+	MaxRequestBatch int
+
+	// FetchBatchSize is the maximum number of messages fetched by a single pull request, so that high-volume
+	// consumers need fewer round-trips to the server. The messages of a batch are handled one after another
+	// and acknowledged individually, so a failing message does not affect the others. It must not exceed
+	// MaxRequestBatch. The Mode SingleSubscriberStrictMessageOrder allows a single message in flight only,
+	// so it always fetches one message. Default is 1.
+	FetchBatchSize int
+
+	// FetchTimeout is the maximum time a single pull request waits for messages, before the next one is sent.
+	// Short timeouts send more requests on idle subjects, long ones keep fewer requests open. Cancelling the
+	// context of StartWithContext, Unsubscribe and Connection.Close abort a waiting request immediately, so
+	// the FetchTimeout does not delay the shutdown.
+	// Default is 5 seconds.
+	FetchTimeout time.Duration
+
+	// MaxRequestMaxBytes limits the total bytes a single pull request of any client of the consumer
+	// may fetch. The Subscriber cuts its batches of FetchBatchSize at this limit, which bounds the memory of
+	// a batch of large messages. A single message above the limit cannot be fetched.
+	// Default is 0, which means unlimited.
+	MaxRequestMaxBytes int
+
+	// Middlewares wrap the handler passed to Start. The first middleware is the outermost one.
+	// See Middleware for details.
+	Middlewares []Middleware
+
+	// ProgressCallback is called every ProgressInterval with the number of messages processed since the
+	// Subscriber was created, as long as the Subscriber runs. A count, which does not increase while messages
+	// are pending, indicates a stuck Subscriber. Optional.
+	ProgressCallback func(processed uint64)
+
+	// OnRedeliveryRatio is called every ProgressInterval with the ratio of redelivered to delivered messages
+	// within the RedeliveryRatioWindow, e.g. to record it as a gauge. A rising ratio indicates an unstable
+	// handler. The ratio is also available by Subscriber.Stats. Optional.
+	OnRedeliveryRatio func(ratio float64)
+
+	// RedeliveryRatioWindow is the duration over which the redelivery ratio is computed. Default is 5 minutes.
+	RedeliveryRatioWindow time.Duration
+
+	// ProgressInterval is the interval of the ProgressCallback and OnRedeliveryRatio. Default is 30 seconds.
+	ProgressInterval time.Duration
+
+	// OnSequenceGap is called, if messages of the consumer were skipped between the last ACKed or terminated
+	// message and a received message, e.g. because they exceeded the MaxDeliver of the consumer or were removed
+	// from the stream after the last message was received. Messages removed before are not detected.
+	// The first message after the Subscriber was created is the baseline; gaps before are not detected.
+	// It requires the Mode SingleSubscriberStrictMessageOrder. Optional.
+	OnSequenceGap func(gap SequenceGap)
+
+	// ReceiptSubject is the subject, to which a Receipt is published for every ACKed or terminated message,
+	// e.g. to reconcile the processed messages with the stream. The subject must belong to a stream and must
+	// not match the Subject of the Subscriber, otherwise receipts are received as messages again.
+	// Receipts are published after the message was acknowledged, so a failed publish is only logged.
+	// Default is empty, which means no receipts are published.
+	ReceiptSubject string
+
+	// NakBackoff defines the delay of the redelivery of a message, whose handler returned an error.
+	// The attempt is the number of deliveries of the message. A handler may override the delay of a single
+	// message by returning an error of RetryAfter. Default is nil, which means a fixed delay of 3 seconds.
+	NakBackoff BackoffStrategy
+
+	// CorrelationIDExtractor extracts the correlation ID of every message, e.g. by CorrelationIDFromHeader.
+	// It is passed to the handler in the context, see CorrelationIDFromContext, and added to the logs of the
+	// Subscriber and the LogMiddleware. Optional.
+	CorrelationIDExtractor CorrelationIDExtractor
+
+	// PendingAlarmThreshold is the number of pending messages of the consumer, from which OnPendingAlarm is
+	// called, e.g. to scale the Subscribers. Default is 0, which means the alarm is disabled.
+	PendingAlarmThreshold uint64
+
+	// OnPendingAlarm is called once the pending messages reach the PendingAlarmThreshold. It is called again
+	// only after they dropped below the threshold meanwhile.
+	OnPendingAlarm func(pending uint64)
+
+	// PendingAlarmInterval is the interval, in which the pending messages are checked. Default is 30 seconds.
+	PendingAlarmInterval time.Duration
+
+	// ReplaySpeed paces the handling of messages by the intervals of their original timestamps in the stream,
+	// e.g. to replay recorded traffic for load tests. 1 replays with the original timing, 2 twice as fast
+	// and 0.5 half as fast. The first handled message is the baseline. A message waits in flight, so the
+	// AckWait must exceed the longest pause. Default is 0, which means no pacing.
+	ReplaySpeed float64
+
+	// WatermarkBucket is the name of an existing KV bucket, which stores the stream sequence of the last
+	// processed message outside the consumer. If set and a watermark is stored, the consumer is recreated
+	// on subscribe to start right after the watermark, regardless of its own ack floor. While running, the
+	// ack floor of the consumer is written back as the watermark. Every Subscriber recreates the consumer,
+	// so start a single Subscriber per consumer only. Default is empty, which means no watermark.
+	WatermarkBucket string
+
+	// WatermarkKey is the key of the watermark in the WatermarkBucket. Default is the ConsumerName.
+	WatermarkKey string
+
+	// WatermarkInterval is the interval, in which the watermark is written back. Messages processed after
+	// the last write are delivered again after a resume. Default is 30 seconds.
+	WatermarkInterval time.Duration
+
+	// ByteBudget caps the payload bytes of messages handled concurrently. A Subscriber handles one message
+	// at a time, so share the ByteBudget among the Subscribers of a process to bound their total memory.
+	// Default is nil, which means no cap.
+	ByteBudget *ByteBudget
+
+	// Ciphers decrypt the Data of messages sealed by a Publisher. The Cipher is chosen by the KeyID in the
+	// CipherKeyHeader, so pass the Ciphers of old keys until all their messages were consumed.
+	// Messages without the CipherKeyHeader are passed unchanged. Default is nil.
+	Ciphers []Cipher
 }
 
-// Close closes the NATS Connection and drains all subscriptions.
+// Close closes the NATS Connection and drains all subscriptions. It waits until the handlers of the messages
+// in flight returned and their acknowledgements were sent, and then until the connection was closed.
+// If the connection is not drained within the drain timeout, see WithDrainTimeout, it is closed anyway
+// and Close returns an error wrapping nats.ErrDrainTimeout. Since it waits for the handlers, Close must not be
+// called from within a handler; cancel the context of Subscriber.StartWithContext or signal another go-routine
+// to close the Connection instead.
 func (c *Connection) Close() error {
-	for _, sub := range c.subscribers {
-		if err := sub.subscription.Drain(); err != nil {
-			return err
+	c.mu.Lock()
+	subscribers := slices.Clone(c.subscribers)
+	c.mu.Unlock()
+	for _, sub := range subscribers {
+		// Draining would wait for messages fetched after the go-routine of Start quit, which nobody reads
+		// anymore. Unsubscribing leaves them to be redelivered after the AckWait instead.
+		sub.quit()
+		if err := sub.subscription.Unsubscribe(); err != nil && !errors.Is(err, nats.ErrBadSubscription) &&
+			!errors.Is(err, nats.ErrConsumerNotFound) {
+			return fmt.Errorf("consumer %s could not be unsubscribed: %w", sub.consumerName, err)
 		}
-		sub.quitSignal <- true
-		close(sub.quitSignal)
 	}
-	if err := c.nats.Drain(); err != nil {
+	if err := c.nats.Drain(c.drainTimeoutOrDefault()); err != nil {
 		return fmt.Errorf("NATS Connection could not be closed: %w", err)
 	}
 	c.logger.Info("NATS Connection closed.")
 	return nil
 }
 
+func (c *Connection) removeSubscriber(sub *Subscriber) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.subscribers = slices.DeleteFunc(c.subscribers, func(s *Subscriber) bool { return s == sub })
+}
+
+func (c *Connection) removePublisher(pub *Publisher) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.publishers = slices.DeleteFunc(c.publishers, func(p *Publisher) bool { return p == pub })
+}
+
 // WithLogger sets the logger
 // This option can be passed in the Connect function.
 // Without this option, the default logger is a slog instance with level ERROR
@@ -144,6 +610,42 @@ func WithLogger(logger *slog.Logger) Option {
 	}
 }
 
+// WithStreamResolver sets a custom mapping of subjects to stream names.
+// This option can be passed in the Connect function.
+// Subscribers bind to the resolved stream and Publishers only accept subjects resolving to their stream.
+// Without this option, the subject of a Publisher must begin with its stream name and the stream
+// of a Subscriber is looked up by its subject.
+func WithStreamResolver(resolver StreamResolver) Option {
+	return func(c *Connection) {
+		c.streamResolver = resolver
+	}
+}
+
+// WithDrainTimeout sets the maximum duration of draining the connection on Close.
+// This option can be passed in the Connect function.
+// Without this option, the timeout is 30 seconds.
+func WithDrainTimeout(timeout time.Duration) Option {
+	return func(c *Connection) {
+		c.drainTimeout = timeout
+		withNATSOptions(nats.DrainTimeout(timeout))(c)
+	}
+}
+
+func (c *Connection) drainTimeoutOrDefault() time.Duration {
+	if c.drainTimeout <= 0 {
+		return defaultDrainTimeout
+	}
+	return c.drainTimeout
+}
+
+// WithConnectionName sets the name of the connection, which is shown by the monitoring of the server, e.g.
+// in "nats server report connections", to tell the connections of services apart.
+// This option can be passed in the Connect function.
+// Without this option, the connection has no name.
+func WithConnectionName(name string) Option {
+	return withNATSOptions(nats.Name(name))
+}
+
 // MustConnectToNATS to NATS Server. This function panics if the connection could not be established.
 // servers: List of NATS servers in the form of "nats://<user:password>@<host>:<port>"
 // logger: an optional slog.Logger instance
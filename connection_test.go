@@ -1,7 +1,10 @@
 package vnats
 
 import (
+	"errors"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 func TestConnection_NewPublisher(t *testing.T) {
@@ -54,3 +57,83 @@ func TestConnection_NewSubscriber(t *testing.T) {
 		}
 	}
 }
+
+func TestWithConnectionName(t *testing.T) {
+	if got := natsOptionsOf(t, WithConnectionName("order-service")).Name; got != "order-service" {
+		t.Errorf("Name = %q, want order-service", got)
+	}
+	if got := natsOptionsOf(t).Name; got != "" {
+		t.Errorf("Name without option = %q, want empty", got)
+	}
+}
+
+func TestConnection_Close(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	subject := integrationTestStreamName + ".close"
+	conn := makeIntegrationTestConn(t)
+	WithDrainTimeout(time.Second * 5)(conn)
+	publishStringMessages(t, conn, subject, []string{"in flight"})
+
+	sub, err := conn.NewSubscriber(SubscriberArgs{ConsumerName: "TestConnectionClose", Subject: subject})
+	if err != nil {
+		t.Fatal(err)
+	}
+	received := make(chan bool)
+	var finished atomic.Bool
+	if err := sub.Start(func(_ Msg) error {
+		close(received)
+		time.Sleep(time.Millisecond * 300)
+		finished.Store(true)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	<-received
+	if err := conn.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if !finished.Load() {
+		t.Error("Close() returned before the handler of the message in flight finished")
+	}
+	if acks := sub.Stats().Acks; acks != 1 {
+		t.Errorf("Got %d ACKs, expected the message in flight ACKed", acks)
+	}
+	if !conn.nats.(*natsBridge).connection.IsClosed() {
+		t.Error("Close() returned before the connection was closed")
+	}
+}
+
+func TestWithDrainTimeout(t *testing.T) {
+	conn := &Connection{}
+	if got := conn.drainTimeoutOrDefault(); got != defaultDrainTimeout {
+		t.Errorf("Drain timeout without option = %v, want %v", got, defaultDrainTimeout)
+	}
+	WithDrainTimeout(time.Second * 5)(conn)
+	if got := conn.drainTimeoutOrDefault(); got != time.Second*5 {
+		t.Errorf("Drain timeout = %v, want 5s", got)
+	}
+	if got := natsOptionsOf(t, WithDrainTimeout(time.Second*5)).DrainTimeout; got != time.Second*5 {
+		t.Errorf("DrainTimeout of the NATS connection = %v, want 5s", got)
+	}
+}
+
+func TestModeMismatchError(t *testing.T) {
+	var err error = &ModeMismatchError{
+		ConsumerName:           "orders",
+		Existing:               SingleSubscriberStrictMessageOrder,
+		Requested:              MultipleSubscribersAllowed,
+		ExistingMaxAckPending:  1,
+		RequestedMaxAckPending: 1000,
+	}
+	if !errors.Is(err, ErrSubscriptionModeMismatch) {
+		t.Errorf("errors.Is(%v, ErrSubscriptionModeMismatch) = false", err)
+	}
+	want := "subscription mode mismatch: consumer orders exists with MaxAckPending 1 of mode " +
+		"SingleSubscriberStrictMessageOrder, but MaxAckPending 1000 of mode MultipleSubscribersAllowed was requested"
+	if err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+}
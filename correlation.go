@@ -0,0 +1,55 @@
+package vnats
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+)
+
+// CorrelationIDExtractor returns the correlation ID of a message, or an empty string if it has none.
+type CorrelationIDExtractor func(msg Msg) string
+
+type correlationIDKey struct{}
+
+// CorrelationIDFromHeader returns a CorrelationIDExtractor, which reads the correlation ID from the header.
+func CorrelationIDFromHeader(header string) CorrelationIDExtractor {
+	return func(msg Msg) string {
+		return msg.Header.Get(header)
+	}
+}
+
+// CorrelationIDFromJSONField returns a CorrelationIDExtractor, which reads the correlation ID from the
+// string field of the JSON payload.
+func CorrelationIDFromJSONField(field string) CorrelationIDExtractor {
+	return func(msg Msg) string {
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(msg.Data, &fields); err != nil {
+			return ""
+		}
+		var id string
+		if err := json.Unmarshal(fields[field], &id); err != nil {
+			return ""
+		}
+		return id
+	}
+}
+
+// ContextWithCorrelationID returns a copy of ctx, which carries the correlation ID.
+func ContextWithCorrelationID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, correlationIDKey{}, id)
+}
+
+// CorrelationIDFromContext returns the correlation ID of the message, which is handled with ctx. It is set by
+// the Subscriber, if the SubscriberArgs contain a CorrelationIDExtractor.
+func CorrelationIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(correlationIDKey{}).(string)
+	return id
+}
+
+// correlationAttrs returns the log attributes of the correlation ID of ctx.
+func correlationAttrs(ctx context.Context) []any {
+	if id := CorrelationIDFromContext(ctx); id != "" {
+		return []any{slog.String("correlationID", id)}
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package vnats
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSubscriber_CorrelationIDExtractor(t *testing.T) {
+	tests := []struct {
+		name      string
+		extractor CorrelationIDExtractor
+		header    string
+		data      string
+		want      string
+	}{
+		{name: "From header", extractor: CorrelationIDFromHeader("Correlation-Id"), header: "abc-123", data: `{}`, want: "abc-123"},
+		{name: "From JSON field", extractor: CorrelationIDFromJSONField("orderId"), data: `{"orderId":"order-42"}`, want: "order-42"},
+		{name: "Missing JSON field", extractor: CorrelationIDFromJSONField("orderId"), data: `{"id":"42"}`, want: ""},
+		{name: "Invalid JSON", extractor: CorrelationIDFromJSONField("orderId"), data: `not json`, want: ""},
+		{name: "No extractor", extractor: nil, header: "abc-123", data: `{}`, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := "not called"
+			sub := makeTestSubscriber(SubscriberArgs{CorrelationIDExtractor: tt.extractor}, func(ctx context.Context, _ Msg) error {
+				got = CorrelationIDFromContext(ctx)
+				return nil
+			})
+
+			natsMsg := makeTestJSMsg(integrationTestStreamName+".correlation", []byte(tt.data), 1)
+			if tt.header != "" {
+				natsMsg.Header.Set("Correlation-Id", tt.header)
+			}
+			sub.handleMsg(context.Background(), natsMsg)
+
+			if got != tt.want {
+				t.Errorf("CorrelationIDFromContext() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package vnats
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/nats-io/nats.go"
+)
+
+const (
+	// OriginalSubjectHeader contains the subject of a message, before it was republished to the DeadLetterSubject.
+	OriginalSubjectHeader = "Vnats-Original-Subject"
+
+	// DeliveryCountHeader contains the number of deliveries of a message, before it was republished to the
+	// DeadLetterSubject.
+	DeliveryCountHeader = "Vnats-Delivery-Count"
+
+	// expectedHeaderPrefix is the prefix of the headers, which let the server check the stream before storing.
+	expectedHeaderPrefix = "Nats-Expected-"
+)
+
+// deadLetter republishes the message to the DeadLetterSubject and terminates it. If the republishing fails,
+// the message is NAKed, so that it stays in the stream, but it is not redelivered anymore.
+func (s *Subscriber) deadLetter(ctx context.Context, natsMsg *nats.Msg, msg Msg, meta *nats.MsgMetadata, handlerErr error) {
+	attrs := append(correlationAttrs(ctx), slog.String("msgID", msg.MsgID), slog.String("subject", msg.Subject),
+		slog.String("deadLetterSubject", s.deadLetterSubject), slog.String("error", handlerErr.Error()))
+	if err := s.publishDeadLetter(natsMsg, meta); err != nil {
+		s.logger.ErrorContext(ctx, "Message handle error on last delivery, could not be republished to dead letter subject",
+			append(attrs, slog.String("publishError", err.Error()))...)
+		s.nak(natsMsg, 0)
+		return
+	}
+	s.logger.WarnContext(ctx, "Message handle error on last delivery, republished to dead letter subject", attrs...)
+	s.term(natsMsg)
+}
+
+// publishDeadLetter publishes the original Data and headers, so that the message can be handled again after
+// the cause was fixed. The expectations and the msgID of the original publish are left out, because they do
+// not apply to the dead letter subject and would reject or deduplicate the dead letter.
+func (s *Subscriber) publishDeadLetter(natsMsg *nats.Msg, meta *nats.MsgMetadata) error {
+	header := make(nats.Header, len(natsMsg.Header)+2)
+	for key, values := range natsMsg.Header {
+		if key == nats.MsgIdHdr || strings.HasPrefix(key, expectedHeaderPrefix) {
+			continue
+		}
+		header[key] = values
+	}
+	header.Set(OriginalSubjectHeader, natsMsg.Subject)
+	header.Set(DeliveryCountHeader, strconv.FormatUint(meta.NumDelivered, 10))
+
+	msgID := fmt.Sprintf("%s-%s-%d-dead-letter", meta.Stream, meta.Consumer, meta.Sequence.Stream)
+	return s.conn.nats.PublishMsg(context.Background(), &nats.Msg{Subject: s.deadLetterSubject, Data: natsMsg.Data, Header: header}, msgID)
+}
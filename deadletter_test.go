@@ -0,0 +1,144 @@
+package vnats
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+func TestSubscriber_DeadLetterSubject(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	subject := integrationTestStreamName + ".deadLetter"
+	deadLetterSubject := integrationTestStreamName + ".deadLetters"
+	conn := makeIntegrationTestConn(t)
+	publishStringMessages(t, conn, subject, []string{"poison"})
+	sub, err := conn.NewSubscriber(SubscriberArgs{
+		ConsumerName:      "TestSubscriberDeadLetterSubject",
+		Subject:           subject,
+		MaxDeliver:        2,
+		NakBackoff:        LinearBackoff{Initial: time.Millisecond * 10},
+		DeadLetterSubject: deadLetterSubject,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sub.Start(func(_ Msg) error { return errors.New("cannot handle poison") }); err != nil {
+		t.Fatal(err)
+	}
+
+	deadLetterSub := createSubscriber(t, conn, "TestSubscriberDeadLetters", deadLetterSubject, MultipleSubscribersAllowed)
+	deadLetters := make(chan Msg, 1)
+	if err := deadLetterSub.Start(func(msg Msg) error {
+		deadLetters <- msg
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case msg := <-deadLetters:
+		if string(msg.Data) != "poison" || msg.MsgID == "msg-0" {
+			t.Errorf("Got dead letter with data %q and msgID %s, expected the original data with new msgID", msg.Data, msg.MsgID)
+		}
+		if got := msg.Header.Get(OriginalSubjectHeader); got != subject {
+			t.Errorf("Got original subject %q, expected %q", got, subject)
+		}
+		if got := msg.Header.Get(DeliveryCountHeader); got != "2" {
+			t.Errorf("Got delivery count %q, expected 2", got)
+		}
+	case <-time.After(time.Second * 5):
+		t.Fatal("Message was not republished to the dead letter subject")
+	}
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) && sub.Stats().Terms == 0 {
+		time.Sleep(time.Millisecond * 10)
+	}
+	if got := sub.Stats().Terms; got != 1 {
+		t.Errorf("Got %d terminated messages, expected 1", got)
+	}
+	if err := conn.Close(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSubscriber_DeadLetterSubject_ExpectLastSubjectSequence(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	subject := integrationTestStreamName + ".deadLetterExpected"
+	deadLetterSubject := integrationTestStreamName + ".deadLettersExpected"
+	conn := makeIntegrationTestConn(t)
+	pub, err := conn.NewPublisher(PublisherArgs{StreamName: integrationTestStreamName})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The dead letter subject already has a message, so the expectation of the original does not match it.
+	if err := pub.Publish(NewMsg(deadLetterSubject, "earlier-dead-letter", []byte("earlier"))); err != nil {
+		t.Fatal(err)
+	}
+	poison := NewMsg(subject, "poison", []byte("poison"))
+	expected := uint64(0)
+	poison.ExpectLastSubjectSequence = &expected
+	if err := pub.Publish(poison); err != nil {
+		t.Fatal(err)
+	}
+
+	sub, err := conn.NewSubscriber(SubscriberArgs{
+		ConsumerName:      "TestSubscriberDeadLetterExpected",
+		Subject:           subject,
+		MaxDeliver:        1,
+		DeadLetterSubject: deadLetterSubject,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sub.Start(func(_ Msg) error { return errors.New("cannot handle poison") }); err != nil {
+		t.Fatal(err)
+	}
+
+	deadLetterSub := createSubscriber(t, conn, "TestSubscriberDeadLettersExpected", deadLetterSubject, MultipleSubscribersAllowed)
+	deadLetters := make(chan Msg, 2)
+	if err := deadLetterSub.Start(func(msg Msg) error {
+		deadLetters <- msg
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	timeout := time.After(time.Second * 5)
+	for {
+		select {
+		case msg := <-deadLetters:
+			if string(msg.Data) != "poison" {
+				continue
+			}
+			if msg.MsgID == "poison" {
+				t.Errorf("Got dead letter with the original msgID %s, expected a new msgID", msg.MsgID)
+			}
+			if got := msg.Header.Get(nats.ExpectedLastSubjSeqHdr); got != "" {
+				t.Errorf("Got dead letter with expected last subject sequence %q, expected none", got)
+			}
+		case <-timeout:
+			t.Fatal("Message with expected last subject sequence was not republished to the dead letter subject")
+		}
+		break
+	}
+	if err := conn.Close(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestConnection_NewSubscriber_DeadLetterSubjectWithoutMaxDeliver(t *testing.T) {
+	conn := makeTestConnection(t, "ORDERS", 0, nil, "", nil)
+	if _, err := conn.NewSubscriber(SubscriberArgs{
+		ConsumerName:      "orders",
+		Subject:           "ORDERS.created",
+		DeadLetterSubject: "ORDERS.deadLetters",
+	}); err == nil {
+		t.Error("NewSubscriber() with DeadLetterSubject, but without MaxDeliver should fail")
+	}
+}
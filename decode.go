@@ -0,0 +1,64 @@
+package vnats
+
+import (
+	"errors"
+	"fmt"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// ErrInvalidMsg is returned by a handler for messages that will never be processable, like messages that
+// cannot be decoded. The Subscriber terminates such messages instead of NAKing them and passes them to the
+// OnInvalidMsg of the SubscriberArgs.
+var ErrInvalidMsg = errors.New("invalid message")
+
+// DecodeArgs contains the arguments for creating a MsgHandler with DecodeHandler.
+type DecodeArgs[T any] struct {
+	// Handler processes the decoded value of the message.
+	Handler func(msg Msg, value *T) error
+
+	// PostDecode is called after the Data was decoded and before the Handler is called, e.g. to apply
+	// defaults and validate required fields. If it returns an error, the message is invalid. Optional.
+	PostDecode func(value *T) error
+
+	// Encoding is the expected Encoding of the messages, which decodes messages without Encoding.
+	// DecodeHandler checks that *T is supported by EncProtobuf, so that a wrong handler fails before any
+	// message is received. Default is EncJSON.
+	Encoding Encoding
+}
+
+// DecodeHandler returns a MsgHandler that decodes the Data of each message into a new T according to the
+// Encoding of the message, which defaults to the Encoding of the args. Messages that cannot be decoded or are
+// rejected by PostDecode result in an error wrapping ErrInvalidMsg. If *T is not supported by the Encoding of
+// a message, e.g. no proto.Message for EncProtobuf, the error wraps ErrUnsupportedType instead, so that the
+// message is not terminated because of a wrong handler. DecodeHandler itself returns an error wrapping
+// ErrUnsupportedType, if the Encoding of the args is EncProtobuf and *T is no proto.Message.
+func DecodeHandler[T any](args DecodeArgs[T]) (MsgHandler, error) {
+	if args.Encoding == "" {
+		args.Encoding = EncJSON
+	}
+	if _, ok := any(new(T)).(proto.Message); !ok && args.Encoding == EncProtobuf {
+		return nil, fmt.Errorf("decode handler could not be created: %w: %T is no proto.Message",
+			ErrUnsupportedType, new(T))
+	}
+
+	return func(msg Msg) error {
+		encoding := msg.Encoding
+		if encoding == "" {
+			encoding = args.Encoding
+		}
+
+		value := new(T)
+		if err := encoding.Unmarshal(msg.Data, value); errors.Is(err, ErrUnsupportedType) {
+			return fmt.Errorf("message with msgID: %s could not be decoded: %w", msg.MsgID, err)
+		} else if err != nil {
+			return fmt.Errorf("%w: message with msgID: %s could not be decoded: %w", ErrInvalidMsg, msg.MsgID, err)
+		}
+		if args.PostDecode != nil {
+			if err := args.PostDecode(value); err != nil {
+				return fmt.Errorf("%w: message with msgID: %s: %w", ErrInvalidMsg, msg.MsgID, err)
+			}
+		}
+		return args.Handler(msg, value)
+	}, nil
+}
@@ -0,0 +1,109 @@
+package vnats
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+type decodeTestOrder struct {
+	ID       string `json:"id"`
+	Currency string `json:"currency"`
+}
+
+func TestDecodeHandler(t *testing.T) {
+	tests := []struct {
+		name         string
+		data         string
+		wantCurrency string
+		wantInvalid  bool
+	}{
+		{name: "Decoded with default", data: `{"id":"42"}`, wantCurrency: "EUR"},
+		{name: "Decoded without default", data: `{"id":"42","currency":"USD"}`, wantCurrency: "USD"},
+		{name: "Missing required field is invalid", data: `{"currency":"USD"}`, wantInvalid: true},
+		{name: "Invalid JSON is invalid", data: `not json`, wantInvalid: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got *decodeTestOrder
+			var invalidErr error
+			handler, err := DecodeHandler(DecodeArgs[decodeTestOrder]{
+				Handler: func(_ Msg, order *decodeTestOrder) error {
+					got = order
+					return nil
+				},
+				PostDecode: func(order *decodeTestOrder) error {
+					if order.ID == "" {
+						return fmt.Errorf("id is required")
+					}
+					if order.Currency == "" {
+						order.Currency = "EUR"
+					}
+					return nil
+				},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			sub := makeTestSubscriber(SubscriberArgs{
+				OnInvalidMsg: func(_ Msg, err error) { invalidErr = err },
+			}, func(_ context.Context, msg Msg) error {
+				return handler(msg)
+			})
+
+			sub.handleMsg(context.Background(), makeTestJSMsg(integrationTestStreamName+".decode", []byte(tt.data), 1))
+
+			if tt.wantInvalid {
+				if got != nil || !errors.Is(invalidErr, ErrInvalidMsg) {
+					t.Errorf("Message should be invalid, got value %v and error %v", got, invalidErr)
+				}
+				return
+			}
+			if invalidErr != nil {
+				t.Fatalf("Message should be valid: %v", invalidErr)
+			}
+			if got == nil || got.Currency != tt.wantCurrency {
+				t.Errorf("Got %v, want currency %s", got, tt.wantCurrency)
+			}
+		})
+	}
+}
+
+func TestDecodeHandler_UnsupportedType(t *testing.T) {
+	handler, err := DecodeHandler(DecodeArgs[decodeTestOrder]{
+		Handler: func(_ Msg, _ *decodeTestOrder) error { return nil },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = handler(Msg{MsgID: "msg-001", Data: []byte{0x0a, 0x02, 0x34, 0x32}, Encoding: EncProtobuf})
+	if !errors.Is(err, ErrUnsupportedType) || errors.Is(err, ErrInvalidMsg) {
+		t.Errorf("DecodeHandler() error = %v, want ErrUnsupportedType without ErrInvalidMsg", err)
+	}
+}
+
+func TestDecodeHandler_Encoding(t *testing.T) {
+	_, err := DecodeHandler(DecodeArgs[decodeTestOrder]{
+		Handler:  func(_ Msg, _ *decodeTestOrder) error { return nil },
+		Encoding: EncProtobuf,
+	})
+	if !errors.Is(err, ErrUnsupportedType) {
+		t.Errorf("DecodeHandler() error = %v, want %v", err, ErrUnsupportedType)
+	}
+
+	var got string
+	handler, err := DecodeHandler(DecodeArgs[wrapperspb.StringValue]{
+		Handler:  func(_ Msg, value *wrapperspb.StringValue) error { got = value.GetValue(); return nil },
+		Encoding: EncProtobuf,
+	})
+	if err != nil {
+		t.Fatalf("DecodeHandler() of proto.Message error = %v", err)
+	}
+	data, _ := EncProtobuf.Marshal(wrapperspb.String("hello"))
+	if err := handler(Msg{MsgID: "msg-001", Data: data}); err != nil || got != "hello" {
+		t.Errorf("handler() of message without Encoding = %q, %v, want it decoded by the Encoding of the args", got, err)
+	}
+}
@@ -8,8 +8,23 @@ import (
 
 const (
 	defaultStorageType       = nats.FileStorage
+	defaultReplicas          = 1
 	defaultDuplicationWindow = time.Minute * 30
-	defaultAckWait           = time.Second * 30
 	defaultNakDelay          = time.Second * 3
 	defaultMaxAge            = time.Hour * 24 * 30
+
+	defaultPublishTimeout        = time.Second * 5
+	defaultPublishAsyncTimeout   = time.Second * 5
+	defaultProgressInterval      = time.Second * 30
+	defaultPendingAlarmInterval  = time.Second * 30
+	defaultWatermarkInterval     = time.Second * 30
+	defaultDrainBatch            = 256
+	defaultRedeliveryRatioWindow = time.Minute * 5
+	defaultDrainFetchWait        = time.Millisecond * 500
+	defaultFetchWait             = time.Second * 5
+	defaultDrainTimeout          = time.Second * 30
+	defaultRequestTimeout        = time.Second * 5
+	defaultPingTimeout           = time.Second * 2
 )
+
+var defaultRetryBackoff = ExponentialBackoff{Initial: time.Millisecond * 100, Max: time.Second * 5}
@@ -0,0 +1,21 @@
+package vnats
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+)
+
+// DrainConsumer fast-forwards the consumer past its backlog by ACKing all pending messages without handling
+// them, e.g. for operational cleanup. It returns the number of drained messages, once no more messages are
+// delivered or ctx is done. The consumer must exist and its Subscribers should be stopped before, otherwise
+// they keep handling messages meanwhile.
+func (c *Connection) DrainConsumer(ctx context.Context, streamName, consumerName string) (uint64, error) {
+	drained, err := c.nats.DrainConsumer(ctx, streamName, consumerName)
+	if err != nil {
+		return drained, fmt.Errorf("consumer %s of stream %s could not be drained: %w", consumerName, streamName, err)
+	}
+	c.logger.Info("Drained consumer", slog.String("name", consumerName), slog.String("stream", streamName),
+		slog.Uint64("messages", drained))
+	return drained, nil
+}
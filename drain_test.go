@@ -0,0 +1,37 @@
+package vnats
+
+import (
+	"context"
+	"testing"
+)
+
+func TestConnection_DrainConsumer(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	subject := integrationTestStreamName + ".drainConsumer"
+	consumerName := "TestConnectionDrainConsumer"
+	conn := makeIntegrationTestConn(t)
+	sub := createSubscriber(t, conn, consumerName, subject, MultipleSubscribersAllowed)
+	publishManyMessages(t, conn, subject, 300)
+
+	drained, err := conn.DrainConsumer(context.Background(), integrationTestStreamName, consumerName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if drained != 300 {
+		t.Errorf("Got %d drained messages, expected 300", drained)
+	}
+
+	info, err := sub.subscription.ConsumerInfo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.NumPending != 0 || info.NumAckPending != 0 || info.AckFloor.Stream != 300 {
+		t.Errorf("Consumer was not drained: %d pending, %d ack pending, ack floor %d",
+			info.NumPending, info.NumAckPending, info.AckFloor.Stream)
+	}
+	if err := conn.Close(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,138 @@
+package vnats
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// ContentTypeHeader is the name of the header, which contains the Encoding of a message.
+const ContentTypeHeader = "Content-Type"
+
+// Encoding describes the format of the Data of a Msg as content type, like "application/json".
+// It is sent in the ContentTypeHeader, so that the Subscriber knows how to decode the Data.
+// Any content type can be used to label the Data, but only registered encodings can Marshal and Unmarshal,
+// see RegisterEncoding.
+type Encoding string
+
+// ErrUnsupportedType is returned by Marshal and Unmarshal, if the Encoding cannot handle the type of the value,
+// e.g. EncProtobuf with a value that is no proto.Message.
+var ErrUnsupportedType = errors.New("type not supported by encoding")
+
+const (
+	// EncJSON encodes values as JSON.
+	EncJSON Encoding = "application/json"
+
+	// EncProtobuf encodes values in the Protobuf wire format. The values must implement proto.Message.
+	EncProtobuf Encoding = "application/x-protobuf"
+
+	// EncRaw passes already serialized bytes unchanged. Marshal accepts a []byte and Unmarshal a *[]byte.
+	EncRaw Encoding = "application/octet-stream"
+)
+
+// Encoder marshals and unmarshals the Data of the messages of an Encoding. It must be safe for concurrent use.
+type Encoder interface {
+	// Marshal encodes v into the Data of a message. If the type of v cannot be encoded, the error should
+	// wrap ErrUnsupportedType.
+	Marshal(v any) ([]byte, error)
+
+	// Unmarshal decodes the Data of a message into v.
+	Unmarshal(data []byte, v any) error
+}
+
+var (
+	encodersMu sync.RWMutex
+	encoders   = map[Encoding]Encoder{
+		EncJSON:     jsonEncoder{},
+		EncProtobuf: protobufEncoder{},
+		EncRaw:      rawEncoder{},
+	}
+)
+
+// RegisterEncoding makes the Encoder available for the content type name, so that PublisherArgs.Encoding,
+// Msg.Encoding and the decoding of received messages can refer to it, e.g. for CBOR or Avro. Registering a
+// name again replaces its Encoder, also of the predefined encodings. It is usually called in an init function
+// and panics, if name is empty or enc is nil.
+func RegisterEncoding(name Encoding, enc Encoder) {
+	if name == "" || enc == nil {
+		panic("vnats: RegisterEncoding requires a name and an Encoder")
+	}
+	encodersMu.Lock()
+	defer encodersMu.Unlock()
+	encoders[name] = enc
+}
+
+func (e Encoding) encoder() (Encoder, bool) {
+	encodersMu.RLock()
+	defer encodersMu.RUnlock()
+	enc, ok := encoders[e]
+	return enc, ok
+}
+
+// Marshal encodes v into the Data of a message.
+func (e Encoding) Marshal(v any) ([]byte, error) {
+	enc, ok := e.encoder()
+	if !ok {
+		return nil, fmt.Errorf("encoding %q does not support marshalling", e)
+	}
+	return enc.Marshal(v)
+}
+
+// Unmarshal decodes the Data of a message into v.
+func (e Encoding) Unmarshal(data []byte, v any) error {
+	enc, ok := e.encoder()
+	if !ok {
+		return fmt.Errorf("encoding %q does not support unmarshalling", e)
+	}
+	return enc.Unmarshal(data, v)
+}
+
+type jsonEncoder struct{}
+
+func (jsonEncoder) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonEncoder) Unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
+
+type protobufEncoder struct{}
+
+func (protobufEncoder) Marshal(v any) ([]byte, error) {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("%w: %T is no proto.Message", ErrUnsupportedType, v)
+	}
+	return proto.Marshal(m)
+}
+
+func (protobufEncoder) Unmarshal(data []byte, v any) error {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return fmt.Errorf("%w: %T is no proto.Message", ErrUnsupportedType, v)
+	}
+	return proto.Unmarshal(data, m)
+}
+
+type rawEncoder struct{}
+
+func (rawEncoder) Marshal(v any) ([]byte, error) {
+	data, ok := v.([]byte)
+	if !ok {
+		return nil, fmt.Errorf("%w: %T is no []byte", ErrUnsupportedType, v)
+	}
+	return data, nil
+}
+
+func (rawEncoder) Unmarshal(data []byte, v any) error {
+	b, ok := v.(*[]byte)
+	if !ok {
+		return fmt.Errorf("%w: %T is no *[]byte", ErrUnsupportedType, v)
+	}
+	*b = data
+	return nil
+}
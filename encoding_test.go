@@ -0,0 +1,214 @@
+package vnats
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestEncoding_MarshalUnmarshal(t *testing.T) {
+	tests := []struct {
+		name     string
+		encoding Encoding
+		wantErr  bool
+	}{
+		{name: "JSON", encoding: EncJSON, wantErr: false},
+		{name: "Unsupported content type", encoding: "application/pdf", wantErr: true},
+		{name: "No encoding", encoding: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := tt.encoding.Marshal(testMessagePayload{Message: "hello"})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Marshal() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			var got testMessagePayload
+			if err := tt.encoding.Unmarshal(data, &got); err != nil {
+				t.Fatal(err)
+			}
+			if got.Message != "hello" {
+				t.Errorf("Unmarshal() got = %v, want hello", got.Message)
+			}
+		})
+	}
+}
+
+func TestMsg_Decode(t *testing.T) {
+	natsMsg := &nats.Msg{
+		Subject: "PRODUCTS.new",
+		Data:    []byte(`{"message":"hello"}`),
+		Header:  nats.Header{ContentTypeHeader: []string{string(EncJSON)}},
+	}
+	msg := makeMsg(natsMsg)
+	if msg.Encoding != EncJSON {
+		t.Fatalf("Encoding = %q, want %q", msg.Encoding, EncJSON)
+	}
+
+	var got testMessagePayload
+	if err := msg.Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Message != "hello" {
+		t.Errorf("Decode() got = %v, want hello", got.Message)
+	}
+
+	withoutEncoding := makeMsg(&nats.Msg{Data: []byte(`{"message":"hello"}`)})
+	if err := withoutEncoding.Decode(&got); err == nil {
+		t.Error("Decode() without encoding should fail")
+	}
+}
+
+func TestEncProtobuf_MarshalUnmarshal(t *testing.T) {
+	data, err := EncProtobuf.Marshal(wrapperspb.String("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got wrapperspb.StringValue
+	if err := EncProtobuf.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.GetValue() != "hello" {
+		t.Errorf("Unmarshal() got = %v, want hello", got.GetValue())
+	}
+
+	if _, err := EncProtobuf.Marshal(testMessagePayload{Message: "hello"}); !errors.Is(err, ErrUnsupportedType) {
+		t.Errorf("Marshal() of no proto.Message error = %v, want ErrUnsupportedType", err)
+	}
+	if err := EncProtobuf.Unmarshal(data, &testMessagePayload{}); !errors.Is(err, ErrUnsupportedType) {
+		t.Errorf("Unmarshal() into no proto.Message error = %v, want ErrUnsupportedType", err)
+	}
+}
+
+func TestSubscriber_Subscribe_Protobuf(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	subject := integrationTestStreamName + ".protobuf"
+	conn := makeIntegrationTestConn(t)
+	pub, err := conn.NewPublisher(PublisherArgs{StreamName: integrationTestStreamName, Encoding: EncProtobuf})
+	if err != nil {
+		t.Fatal(err)
+	}
+	publishMessages := []string{"hello", "world"}
+	for idx, message := range publishMessages {
+		data, err := EncProtobuf.Marshal(wrapperspb.String(message))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := pub.Publish(&Msg{Subject: subject, MsgID: fmt.Sprintf("proto-%d", idx), Data: data}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	sub := createSubscriber(t, conn, "TestSubscriberSubscribeProtobuf", subject, SingleSubscriberStrictMessageOrder)
+	received := make(chan string, len(publishMessages))
+	handler, err := DecodeHandler(DecodeArgs[wrapperspb.StringValue]{
+		Handler: func(_ Msg, value *wrapperspb.StringValue) error {
+			received <- value.GetValue()
+			return nil
+		},
+		Encoding: EncProtobuf,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sub.Start(handler); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for len(got) < len(publishMessages) {
+		select {
+		case message := <-received:
+			got = append(got, message)
+		case <-time.After(time.Second * 5):
+			t.Fatalf("Got messages %v, expected %v", got, publishMessages)
+		}
+	}
+	if !reflect.DeepEqual(got, publishMessages) {
+		t.Errorf("Got messages %v, expected %v", got, publishMessages)
+	}
+	if err := conn.Close(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestEncRaw_MarshalUnmarshal(t *testing.T) {
+	payload := []byte{0x00, 0xff, 0x42}
+	data, err := EncRaw.Marshal(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []byte
+	if err := EncRaw.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, payload) {
+		t.Errorf("Unmarshal() got = %v, want %v", got, payload)
+	}
+
+	if _, err := EncRaw.Marshal("hello"); !errors.Is(err, ErrUnsupportedType) {
+		t.Errorf("Marshal() of string error = %v, want ErrUnsupportedType", err)
+	}
+	if err := EncRaw.Unmarshal(data, &testMessagePayload{}); !errors.Is(err, ErrUnsupportedType) {
+		t.Errorf("Unmarshal() into struct error = %v, want ErrUnsupportedType", err)
+	}
+}
+
+// textEncoder encodes the Message of a testMessagePayload as plain text.
+type textEncoder struct{}
+
+func (textEncoder) Marshal(v any) ([]byte, error) {
+	payload, ok := v.(testMessagePayload)
+	if !ok {
+		return nil, fmt.Errorf("%w: %T", ErrUnsupportedType, v)
+	}
+	return []byte(payload.Message), nil
+}
+
+func (textEncoder) Unmarshal(data []byte, v any) error {
+	payload, ok := v.(*testMessagePayload)
+	if !ok {
+		return fmt.Errorf("%w: %T", ErrUnsupportedType, v)
+	}
+	payload.Message = string(data)
+	return nil
+}
+
+func TestRegisterEncoding(t *testing.T) {
+	const encText Encoding = "text/x-vnats-test"
+	RegisterEncoding(encText, textEncoder{})
+
+	data, err := encText.Marshal(testMessagePayload{Message: "hello"})
+	if err != nil || string(data) != "hello" {
+		t.Fatalf("Marshal() = %q, %v, want hello", data, err)
+	}
+	if _, err := encText.Marshal("hello"); !errors.Is(err, ErrUnsupportedType) {
+		t.Errorf("Marshal() of string error = %v, want ErrUnsupportedType", err)
+	}
+
+	msg := makeMsg(&nats.Msg{
+		Subject: "PRODUCTS.new",
+		Data:    data,
+		Header:  nats.Header{ContentTypeHeader: []string{string(encText)}},
+	})
+	var got testMessagePayload
+	if err := msg.Decode(&got); err != nil || got.Message != "hello" {
+		t.Errorf("Decode() got = %+v, %v, want hello", got, err)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("RegisterEncoding() without Encoder should panic")
+		}
+	}()
+	RegisterEncoding("text/x-vnats-missing", nil)
+}
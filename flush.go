@@ -0,0 +1,111 @@
+package vnats
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/nats-io/nats.go"
+)
+
+// compactAsyncAcksThreshold is the number of tracked acks, from which completed acks are removed on publish.
+const compactAsyncAcksThreshold = 1024
+
+// asyncAcks tracks the acks of the messages published async by a Publisher until they are flushed.
+type asyncAcks struct {
+	mu      sync.Mutex
+	futures []nats.PubAckFuture
+	errs    []error
+}
+
+func (a *asyncAcks) add(future nats.PubAckFuture) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.futures = append(a.futures, future)
+	if len(a.futures) >= compactAsyncAcksThreshold {
+		a.compact()
+	}
+}
+
+// compact removes completed acks and keeps their errors. The caller must hold the lock.
+func (a *asyncAcks) compact() {
+	pending := a.futures[:0]
+	for _, future := range a.futures {
+		select {
+		case <-future.Ok():
+		case err := <-future.Err():
+			a.errs = append(a.errs, ackError(future, err))
+		default:
+			pending = append(pending, future)
+		}
+	}
+	clear(a.futures[len(pending):])
+	a.futures = pending
+}
+
+// pending returns the number of tracked acks, which did not arrive yet.
+func (a *asyncAcks) pending() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.compact()
+	return len(a.futures)
+}
+
+// wait waits for all tracked acks and returns the errors of the failed publishes. If ctx is done before,
+// the remaining acks stay tracked.
+func (a *asyncAcks) wait(ctx context.Context) error {
+	a.mu.Lock()
+	futures, errs := a.futures, a.errs
+	a.futures, a.errs = nil, nil
+	a.mu.Unlock()
+
+	for i, future := range futures {
+		select {
+		case <-future.Ok():
+		case err := <-future.Err():
+			errs = append(errs, ackError(future, err))
+		case <-ctx.Done():
+			a.mu.Lock()
+			a.futures = append(a.futures, futures[i:]...)
+			a.mu.Unlock()
+			return errors.Join(append(errs, ctx.Err())...)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func ackError(future nats.PubAckFuture, err error) error {
+	msg := future.Msg()
+	return fmt.Errorf("message with msgID: %s @ %s could not be published: %w",
+		msg.Header.Get(nats.MsgIdHdr), msg.Subject, sequenceMismatch(err))
+}
+
+// Flush waits until the server acknowledged all messages, which were published by the Publisher with
+// AsyncPublish. It returns the errors of all failed async publishes since the last Flush. If ctx is done
+// before, the context error is returned as well and the remaining messages are waited for by the next Flush.
+func (p *Publisher) Flush(ctx context.Context) error {
+	return p.asyncAcks.wait(ctx)
+}
+
+// PendingAsync returns the number of messages published with AsyncPublish, whose acks did not arrive yet,
+// e.g. to slow down a producer before its memory grows.
+func (p *Publisher) PendingAsync() int {
+	return p.asyncAcks.pending()
+}
+
+// FlushAllPublishers flushes all Publishers of the Connection, e.g. before a coordinated cutover.
+// It returns the joined errors of all Publishers. See Publisher.Flush for details.
+func (c *Connection) FlushAllPublishers(ctx context.Context) error {
+	c.mu.Lock()
+	publishers := slices.Clone(c.publishers)
+	c.mu.Unlock()
+	var errs []error
+	for _, pub := range publishers {
+		if err := pub.Flush(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("publisher of stream %s could not be flushed: %w", pub.streamName, err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,132 @@
+package vnats
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+func TestConnection_FlushAllPublishers(t *testing.T) {
+	conn := makeTestConnection(t, "MESSAGES", 1, []byte("test message"), "msg-001", nil)
+	conn.nats.(*testBridge).failPublishes = 1
+	for i := 0; i < 2; i++ {
+		pub := &Publisher{conn: conn, logger: slog.Default(), streamName: "MESSAGES", async: true}
+		conn.publishers = append(conn.publishers, pub)
+		if err := pub.Publish(NewMsg("MESSAGES.Important", "msg-001", []byte("test message"))); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err := conn.FlushAllPublishers(context.Background())
+	if !errors.Is(err, nats.ErrNoResponders) {
+		t.Errorf("FlushAllPublishers() error = %v, want %v", err, nats.ErrNoResponders)
+	}
+	if err := conn.FlushAllPublishers(context.Background()); err != nil {
+		t.Errorf("Second FlushAllPublishers() error = %v, want nil", err)
+	}
+}
+
+// pendingAckFuture is a nats.PubAckFuture, which is never acknowledged.
+type pendingAckFuture struct{}
+
+func (pendingAckFuture) Ok() <-chan *nats.PubAck { return nil }
+func (pendingAckFuture) Err() <-chan error       { return nil }
+func (pendingAckFuture) Msg() *nats.Msg          { return &nats.Msg{} }
+
+func TestPublisher_Flush_ContextDone(t *testing.T) {
+	pub := &Publisher{}
+	pub.asyncAcks.add(pendingAckFuture{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+	defer cancel()
+	if err := pub.Flush(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Flush() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if len(pub.asyncAcks.futures) != 1 {
+		t.Errorf("Pending ack is not tracked anymore")
+	}
+}
+
+func TestPublisher_PendingAsync(t *testing.T) {
+	pub := &Publisher{}
+	pub.asyncAcks.add(pendingAckFuture{})
+	pub.asyncAcks.add(&testAckFuture{msg: &nats.Msg{Header: nats.Header{}}})
+	pub.asyncAcks.add(&testAckFuture{msg: &nats.Msg{Header: nats.Header{}}, err: nats.ErrNoResponders})
+
+	if got := pub.PendingAsync(); got != 1 {
+		t.Errorf("PendingAsync() = %d, want 1", got)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+	defer cancel()
+	if err := pub.Flush(ctx); !errors.Is(err, nats.ErrNoResponders) {
+		t.Errorf("Flush() error = %v, want the failed publish counted by PendingAsync %v", err, nats.ErrNoResponders)
+	}
+}
+
+func TestPublisher_Flush_Integration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	subject := integrationTestStreamName + ".flush"
+	conn := makeIntegrationTestConn(t)
+	pub, err := conn.NewPublisher(PublisherArgs{StreamName: integrationTestStreamName, AsyncPublish: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, data := range []string{"first", "second", "third"} {
+		if err := pub.Publish(NewMsg(subject, data, []byte(data))); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := conn.FlushAllPublishers(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	info, err := conn.nats.(*natsBridge).jetStreamContext.StreamInfo(integrationTestStreamName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.State.Msgs != 3 {
+		t.Errorf("Got %d messages after flush, expected 3", info.State.Msgs)
+	}
+	if err := conn.Close(); err != nil {
+		t.Error(err)
+	}
+}
+
+func BenchmarkPublisher_Publish(b *testing.B) {
+	if os.Getenv("NATS_SERVER_URL") == "" {
+		b.Skip("skipping integration benchmark")
+	}
+	subject := integrationTestStreamName + ".benchmarkPublish"
+	for _, async := range []bool{false, true} {
+		b.Run(fmt.Sprintf("async-%t", async), func(b *testing.B) {
+			conn := makeIntegrationTestConn(b)
+			pub, err := conn.NewPublisher(PublisherArgs{StreamName: integrationTestStreamName, AsyncPublish: async})
+			if err != nil {
+				b.Fatal(err)
+			}
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if err := pub.Publish(NewMsg(subject, fmt.Sprintf("msg-%d", i), []byte("hello"))); err != nil {
+					b.Fatal(err)
+				}
+			}
+			if err := pub.Flush(context.Background()); err != nil {
+				b.Fatal(err)
+			}
+			b.StopTimer()
+
+			if err := conn.Close(); err != nil {
+				b.Error(err)
+			}
+		})
+	}
+}
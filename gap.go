@@ -0,0 +1,75 @@
+package vnats
+
+import (
+	"sync"
+
+	"github.com/nats-io/nats.go"
+)
+
+// SequenceGap describes messages of the consumer, which were skipped between two messages.
+type SequenceGap struct {
+	// After is the stream sequence of the last message that was ACKed or terminated before the gap.
+	After uint64
+
+	// Before is the stream sequence of the message received after the gap.
+	Before uint64
+
+	// Missed is the number of messages of the consumer, which were skipped.
+	Missed uint64
+}
+
+// gapDetector detects skipped messages by the number of pending messages of the consumer, so that the
+// sequences of other subjects in the stream do not count as gaps. Every message of the filter subjects
+// between two messages reduces the pending count. A nil gapDetector is disabled.
+type gapDetector struct {
+	mu          sync.Mutex
+	onGap       func(gap SequenceGap)
+	settled     bool
+	lastSeq     uint64
+	lastPending uint64
+}
+
+func newGapDetector(onGap func(gap SequenceGap)) *gapDetector {
+	if onGap == nil {
+		return nil
+	}
+	return &gapDetector{onGap: onGap}
+}
+
+// observe checks a received message for a gap after the last settled message. Redeliveries are ignored.
+func (d *gapDetector) observe(meta *nats.MsgMetadata) {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	if !d.settled || meta.Sequence.Stream <= d.lastSeq || d.lastPending <= meta.NumPending+1 {
+		d.mu.Unlock()
+		return
+	}
+	gap := SequenceGap{
+		After:  d.lastSeq,
+		Before: meta.Sequence.Stream,
+		Missed: d.lastPending - meta.NumPending - 1,
+	}
+	d.mu.Unlock()
+
+	d.onGap(gap)
+}
+
+// settle records a message, which was ACKed or terminated.
+func (d *gapDetector) settle(natsMsg *nats.Msg) {
+	if d == nil {
+		return
+	}
+	meta, err := natsMsg.Metadata()
+	if err != nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if meta.Sequence.Stream > d.lastSeq {
+		d.settled = true
+		d.lastSeq = meta.Sequence.Stream
+		d.lastPending = meta.NumPending
+	}
+}
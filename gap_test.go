@@ -0,0 +1,92 @@
+package vnats
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/nats-io/nats.go"
+)
+
+func Test_gapDetector(t *testing.T) {
+	var gaps []SequenceGap
+	detector := newGapDetector(func(gap SequenceGap) { gaps = append(gaps, gap) })
+	meta := func(seq, pending uint64) *nats.MsgMetadata {
+		return &nats.MsgMetadata{Sequence: nats.SequencePair{Stream: seq}, NumPending: pending}
+	}
+	settle := func(seq, pending uint64) {
+		detector.settle(&nats.Msg{
+			Sub:   &nats.Subscription{},
+			Reply: fmt.Sprintf("$JS.ACK.%s.TestConsumer.1.%d.%d.0.%d", integrationTestStreamName, seq, seq, pending),
+		})
+	}
+
+	detector.observe(meta(3, 9)) // Baseline
+	settle(3, 9)
+	detector.observe(meta(7, 8)) // Other subjects in between
+	settle(7, 8)
+	detector.observe(meta(7, 8)) // Redelivery
+	detector.observe(meta(12, 5))
+
+	want := []SequenceGap{{After: 7, Before: 12, Missed: 2}}
+	if fmt.Sprint(gaps) != fmt.Sprint(want) {
+		t.Errorf("Got gaps %v, expected %v", gaps, want)
+	}
+}
+
+func TestSubscriber_OnSequenceGap(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	subject := integrationTestStreamName + ".sequenceGap"
+	conn := makeIntegrationTestConn(t)
+	pub, err := conn.NewPublisher(PublisherArgs{StreamName: integrationTestStreamName})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 5; i++ { // Interleave with another subject, so that the stream sequences of subject are 1, 3, 5, 7, 9
+		for _, s := range []string{subject, subject + "Other"} {
+			if err := pub.Publish(NewMsg(s, fmt.Sprintf("%s-%d", s, i), []byte("hello"))); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	var gaps []SequenceGap
+	sub, err := conn.NewSubscriber(SubscriberArgs{
+		ConsumerName:  "TestSubscriberOnSequenceGap",
+		Subject:       subject,
+		Mode:          SingleSubscriberStrictMessageOrder,
+		OnSequenceGap: func(gap SequenceGap) { gaps = append(gaps, gap) },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sub.handler = func(_ context.Context, _ Msg) error { return nil }
+	sub.processMessages(context.Background(), 1, defaultFetchWait)
+	sub.processMessages(context.Background(), 1, defaultFetchWait)
+	if err := conn.DeleteMsg(integrationTestStreamName, 5, false); err != nil {
+		t.Fatal(err)
+	}
+	sub.processMessages(context.Background(), 1, defaultFetchWait)
+	sub.processMessages(context.Background(), 1, defaultFetchWait)
+
+	want := []SequenceGap{{After: 3, Before: 7, Missed: 1}}
+	if fmt.Sprint(gaps) != fmt.Sprint(want) {
+		t.Errorf("Got gaps %v, expected %v", gaps, want)
+	}
+	if err := conn.Close(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestConnection_NewSubscriber_OnSequenceGapRequiresStrictOrder(t *testing.T) {
+	conn := &Connection{}
+	if _, err := conn.NewSubscriber(SubscriberArgs{
+		ConsumerName:  "TestGap",
+		Subject:       "TEST.gap",
+		OnSequenceGap: func(_ SequenceGap) {},
+	}); err == nil {
+		t.Error("NewSubscriber() should fail without SingleSubscriberStrictMessageOrder")
+	}
+}
@@ -0,0 +1,27 @@
+package vnats
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrDisconnected is wrapped by the error of Ping, if the Connection is not connected to a server, e.g. while
+// it reconnects or after it was closed.
+var ErrDisconnected = errors.New("NATS connection is not connected")
+
+// ErrJetStreamUnavailable is wrapped by the error of Ping, if the server is connected, but JetStream does not
+// answer, e.g. because it is disabled for the account or its meta leader is elected.
+var ErrJetStreamUnavailable = errors.New("JetStream is unavailable")
+
+// Ping checks that the Connection is usable, e.g. for the liveness probe of a service. It returns an error
+// wrapping ErrDisconnected, if the Connection is not connected to a server, or ErrJetStreamUnavailable, if
+// JetStream does not answer a request of the account info until ctx is done. If ctx has no deadline, Ping
+// waits at most 2 seconds.
+func (c *Connection) Ping(ctx context.Context) error {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, defaultPingTimeout)
+		defer cancel()
+	}
+	return c.nats.Ping(ctx)
+}
@@ -0,0 +1,30 @@
+package vnats
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestConnection_Ping(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	conn := makeIntegrationTestConn(t)
+	if err := conn.Ping(context.Background()); err != nil {
+		t.Errorf("Ping() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := conn.Ping(ctx); !errors.Is(err, ErrJetStreamUnavailable) {
+		t.Errorf("Ping() with cancelled context error = %v, want %v", err, ErrJetStreamUnavailable)
+	}
+
+	if err := conn.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := conn.Ping(context.Background()); !errors.Is(err, ErrDisconnected) {
+		t.Errorf("Ping() after Close error = %v, want %v", err, ErrDisconnected)
+	}
+}
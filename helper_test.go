@@ -1,6 +1,7 @@
 package vnats
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -22,9 +23,17 @@ type testBridge struct {
 	sequenceNumber uint64
 	wantData       []byte
 	wantMessageID  string
+	published      []*nats.Msg
+	publishedAsync int
+	failPublishes  int
+	maxPayload     int64
+	subscribed     []SubscriberArgs
+	boundStreams   []string
+	ensured        []*nats.StreamConfig
 }
 
-func (b *testBridge) EnsureStreamExists(_ *nats.StreamConfig) error {
+func (b *testBridge) EnsureStreamExists(streamConfig *nats.StreamConfig) error {
+	b.ensured = append(b.ensured, streamConfig)
 	return nil
 }
 
@@ -40,8 +49,17 @@ func (b *testBridge) Servers() []string {
 	return nil
 }
 
-func (b *testBridge) PublishMsg(msg *nats.Msg, msgID string) error {
+func (b *testBridge) MaxPayload() int64 {
+	return b.maxPayload
+}
+
+func (b *testBridge) PublishMsg(_ context.Context, msg *nats.Msg, msgID string) error {
+	if b.failPublishes > 0 {
+		b.failPublishes--
+		return nats.ErrNoResponders
+	}
 	b.Logf("%s", string(msg.Data))
+	b.published = append(b.published, msg)
 	if diff := cmp.Diff(msg.Data, b.wantData); diff != "" {
 		err := fmt.Errorf("wrong message found=%s (id=%s) want=%s (id=%s)", string(msg.Data), msgID, b.wantData, b.wantMessageID)
 		b.Fatal(err, diff)
@@ -52,11 +70,103 @@ func (b *testBridge) PublishMsg(msg *nats.Msg, msgID string) error {
 	return nil
 }
 
-func (b *testBridge) Subscribe(_, _ string, _ SubscriptionMode) (*nats.Subscription, error) {
+func (b *testBridge) PublishMsgAsync(msg *nats.Msg, msgID string) (nats.PubAckFuture, error) {
+	b.publishedAsync++
+	msg.Header.Set(nats.MsgIdHdr, msgID)
+	return &testAckFuture{msg: msg, err: b.PublishMsg(context.Background(), msg, msgID)}, nil
+}
+
+// testAckFuture is a completed nats.PubAckFuture, whose publish failed with err, if set.
+type testAckFuture struct {
+	msg *nats.Msg
+	err error
+}
+
+func (f *testAckFuture) Ok() <-chan *nats.PubAck {
+	ch := make(chan *nats.PubAck, 1)
+	if f.err == nil {
+		ch <- &nats.PubAck{}
+	}
+	return ch
+}
+
+func (f *testAckFuture) Err() <-chan error {
+	ch := make(chan error, 1)
+	if f.err != nil {
+		ch <- f.err
+	}
+	return ch
+}
+
+func (f *testAckFuture) Msg() *nats.Msg {
+	return f.msg
+}
+
+func (b *testBridge) GetMsg(_ string, _ uint64) (*nats.RawStreamMsg, error) {
+	return nil, nats.ErrMsgNotFound
+}
+
+func (b *testBridge) DeleteMsg(_ string, _ uint64, _ bool) error {
+	return nil
+}
+
+func (b *testBridge) Ping(_ context.Context) error {
+	return nil
+}
+
+func (b *testBridge) StreamInfo(streamName string) (*nats.StreamInfo, error) {
+	return &nats.StreamInfo{Config: nats.StreamConfig{Name: streamName}}, nil
+}
+
+func (b *testBridge) StreamSubjects(_, _ string) (map[string]uint64, error) {
+	return map[string]uint64{}, nil
+}
+
+func (b *testBridge) PurgeStream(_ string, _ *nats.StreamPurgeRequest) error {
+	return nil
+}
+
+func (b *testBridge) MigrateConsumer(_, _ string, _ *nats.ConsumerConfig) error {
+	return nil
+}
+
+func (b *testBridge) DrainConsumer(_ context.Context, _, _ string) (uint64, error) {
+	return 0, nil
+}
+
+func (b *testBridge) Watermark(_, _ string) (uint64, error) {
+	return 0, nil
+}
+
+func (b *testBridge) PutWatermark(_, _ string, _ uint64) error {
+	return nil
+}
+
+func (b *testBridge) Subscribe(streamName string, args SubscriberArgs) (*nats.Subscription, error) {
+	b.subscribed = append(b.subscribed, args)
+	b.boundStreams = append(b.boundStreams, streamName)
 	return nil, nil
 }
 
-func (b *testBridge) Drain() error {
+func (b *testBridge) KeyValue(_ *nats.KeyValueConfig) (nats.KeyValue, error) {
+	return nil, nats.ErrBucketNotFound
+}
+
+func (b *testBridge) ObjectStore(_ *nats.ObjectStoreConfig) (nats.ObjectStore, error) {
+	return nil, nats.ErrStreamNotFound
+}
+
+// Request echoes the data and header of msg as reply.
+func (b *testBridge) Request(_ context.Context, msg *nats.Msg) (*nats.Msg, error) {
+	b.published = append(b.published, msg)
+	return &nats.Msg{Subject: msg.Reply, Data: msg.Data, Header: msg.Header}, nil
+}
+
+func (b *testBridge) QueueSubscribe(_, _ string, _ nats.MsgHandler) (*nats.Subscription, error) {
+	return nil, nil
+}
+
+func (b *testBridge) Drain(_ time.Duration) error {
 	return nil
 }
 
@@ -78,6 +188,26 @@ func makeTestConnection(t *testing.T, streamName string, currentSequenceNumber u
 	}
 }
 
+// makeTestSubscriber creates a Subscriber without NATS subscription, whose handleMsg can be called with
+// messages of makeTestJSMsg.
+func makeTestSubscriber(args SubscriberArgs, handler ContextMsgHandler) *Subscriber {
+	sub := newSubscriber(&Connection{logger: slog.Default()}, nil, args)
+	sub.handler = handler
+	return sub
+}
+
+// makeTestJSMsg creates a message with the reply subject of a JetStream message, so that its metadata
+// can be parsed. Acknowledging the message fails, since its subscription has no connection.
+func makeTestJSMsg(subject string, data []byte, numDelivered uint64) *nats.Msg {
+	return &nats.Msg{
+		Sub:     &nats.Subscription{},
+		Subject: subject,
+		Reply:   fmt.Sprintf("$JS.ACK.%s.TestConsumer.%d.1.1.%d.0", integrationTestStreamName, numDelivered, time.Now().UnixNano()),
+		Data:    data,
+		Header:  nats.Header{},
+	}
+}
+
 func createStream(b *natsBridge, streamName string) error {
 	return b.EnsureStreamExists(&nats.StreamConfig{
 		Name:       streamName,
@@ -108,7 +238,7 @@ func deleteConsumer(c *Connection, b *natsBridge, streamName string) error {
 	return nil
 }
 
-func makeIntegrationTestConn(t *testing.T) *Connection {
+func makeIntegrationTestConn(t testing.TB) *Connection {
 	conn := &Connection{
 		logger: slog.Default(),
 	}
@@ -165,7 +295,7 @@ func cmpStringSlicesIgnoreOrder(expectedMessages, receivedMessages []string) err
 	return nil
 }
 
-func publishManyMessages(t *testing.T, conn *Connection, subject string, messageCount int) {
+func publishManyMessages(t testing.TB, conn *Connection, subject string, messageCount int) {
 	var messages []string
 	for i := 0; i < messageCount; i++ {
 		messages = append(messages, fmt.Sprintf("msg-%d", i))
@@ -174,7 +304,7 @@ func publishManyMessages(t *testing.T, conn *Connection, subject string, message
 	publishStringMessages(t, conn, subject, messages)
 }
 
-func publishStringMessages(t *testing.T, conn *Connection, subject string, publishMessages []string) {
+func publishStringMessages(t testing.TB, conn *Connection, subject string, publishMessages []string) {
 	pub, err := conn.NewPublisher(PublisherArgs{
 		StreamName: integrationTestStreamName,
 	})
@@ -192,6 +322,19 @@ func publishStringMessages(t *testing.T, conn *Connection, subject string, publi
 	}
 }
 
+// publishSubjectMessages publishes count messages, whose MsgIDs are unique within the subject.
+func publishSubjectMessages(t *testing.T, conn *Connection, subject string, count int) {
+	pub, err := conn.NewPublisher(PublisherArgs{StreamName: integrationTestStreamName})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < count; i++ {
+		if err := pub.Publish(NewMsg(subject, fmt.Sprintf("%s-%d", subject, i), []byte("hello"))); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
 func publishTestMessageStructMessages(t *testing.T, conn *Connection, subject string, publishMessages []string) {
 	pub, err := conn.NewPublisher(PublisherArgs{
 		StreamName: integrationTestStreamName,
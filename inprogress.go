@@ -0,0 +1,53 @@
+package vnats
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+// inProgressInterval returns the interval of the InProgress acknowledgements, which is half of the AckWait,
+// so that a lost acknowledgement does not cause a redelivery yet. It returns 0, if they are disabled.
+func inProgressInterval(args SubscriberArgs) time.Duration {
+	if !args.AutoInProgress {
+		return 0
+	}
+	ackWait := args.AckWait
+	if ackWait <= 0 {
+		ackWait = args.Mode.defaultAckWait()
+	}
+	return ackWait / 2
+}
+
+// keepInProgress acknowledges natsMsg as in progress every inProgressInterval, so that the AckWait is reset
+// while the handler is running. The returned function stops the acknowledgements and waits until no more
+// are sent, so that none follows the final acknowledgement.
+func (s *Subscriber) keepInProgress(natsMsg *nats.Msg) (stop func()) {
+	if s.inProgressInterval <= 0 {
+		return func() {}
+	}
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(s.inProgressInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if err := natsMsg.InProgress(); err != nil {
+					s.logger.Warn("natsMsg.InProgress() failed", slog.String("error", err.Error()))
+				}
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
@@ -0,0 +1,74 @@
+package vnats
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_inProgressInterval(t *testing.T) {
+	tests := []struct {
+		name string
+		args SubscriberArgs
+		want time.Duration
+	}{
+		{name: "Disabled", args: SubscriberArgs{AckWait: time.Minute}, want: 0},
+		{name: "Half of AckWait", args: SubscriberArgs{AckWait: time.Minute, AutoInProgress: true}, want: time.Second * 30},
+		{name: "Half of default AckWait", args: SubscriberArgs{AutoInProgress: true}, want: DefaultAckWaitMultipleSubscribers / 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := inProgressInterval(tt.args); got != tt.want {
+				t.Errorf("inProgressInterval() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSubscriber_AutoInProgress(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	subject := integrationTestStreamName + ".autoInProgress"
+	conn := makeIntegrationTestConn(t)
+	publishStringMessages(t, conn, subject, []string{"slow"})
+
+	args := SubscriberArgs{
+		ConsumerName:   "TestSubscriberAutoInProgress",
+		Subject:        subject,
+		AckWait:        time.Millisecond * 500,
+		AutoInProgress: true,
+	}
+	deliveries := make(chan uint64, 2)
+	handler := func(msg Msg) error {
+		deliveries <- msg.NumDelivered
+		time.Sleep(time.Millisecond * 1500) // three times the AckWait
+		return nil
+	}
+	// The second Subscriber of the consumer would receive a redelivery, while the first is still handling.
+	var subs []*Subscriber
+	for i := 0; i < 2; i++ {
+		sub, err := conn.NewSubscriber(args)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := sub.Start(handler); err != nil {
+			t.Fatal(err)
+		}
+		subs = append(subs, sub)
+	}
+
+	if got := <-deliveries; got != 1 {
+		t.Errorf("First delivery has NumDelivered %d, expected 1", got)
+	}
+	select {
+	case got := <-deliveries:
+		t.Errorf("Message was redelivered with NumDelivered %d while its handler was running", got)
+	case <-time.After(time.Second * 2):
+	}
+	if acks := subs[0].Stats().Acks + subs[1].Stats().Acks; acks != 1 {
+		t.Errorf("Got %d ACKs, expected 1", acks)
+	}
+	if err := conn.Close(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,45 @@
+package vnats
+
+import (
+	"fmt"
+	"log/slog"
+)
+
+// PublishJournal persists the MsgIDs of published messages, so that a Publisher does not publish a message
+// again, which was acknowledged by the server before, even after a restart of the process and beyond the
+// duplication window of the stream. Implementations must be safe for concurrent use, e.g. a table in the
+// database of the service.
+type PublishJournal interface {
+	// IsAcked reports whether the message with the msgID was acknowledged by the server.
+	IsAcked(msgID string) (bool, error)
+
+	// MarkInFlight records the message with the msgID before it is published. Messages, which are still in
+	// flight after a crash, may or may not have been stored by the server.
+	MarkInFlight(msgID string) error
+
+	// MarkAcked records that the message with the msgID was acknowledged by the server.
+	MarkAcked(msgID string) error
+}
+
+// publishJournaled publishes the message, unless the journal contains it as acknowledged.
+func (p *Publisher) publishJournaled(msg *Msg, publish func() error) error {
+	acked, err := p.journal.IsAcked(msg.MsgID)
+	if err != nil {
+		return fmt.Errorf("journal of message with msgID: %s could not be read: %w", msg.MsgID, err)
+	}
+	if acked {
+		p.logger.Debug("Message was published before, skip publish", slog.String("msgID", msg.MsgID))
+		return nil
+	}
+
+	if err := p.journal.MarkInFlight(msg.MsgID); err != nil {
+		return fmt.Errorf("message with msgID: %s could not be marked in flight: %w", msg.MsgID, err)
+	}
+	if err := publish(); err != nil {
+		return err
+	}
+	if err := p.journal.MarkAcked(msg.MsgID); err != nil {
+		return fmt.Errorf("message with msgID: %s was published, but could not be marked acked: %w", msg.MsgID, err)
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package vnats
+
+import (
+	"errors"
+	"log/slog"
+	"sync"
+	"testing"
+)
+
+// memoryJournal is a PublishJournal for tests, which maps MsgIDs to their acked state.
+type memoryJournal struct {
+	mu        sync.Mutex
+	msgs      map[string]bool
+	failAcked bool
+}
+
+func (j *memoryJournal) IsAcked(msgID string) (bool, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.msgs[msgID], nil
+}
+
+func (j *memoryJournal) MarkInFlight(msgID string) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.msgs[msgID] = false
+	return nil
+}
+
+func (j *memoryJournal) MarkAcked(msgID string) error {
+	if j.failAcked {
+		return errors.New("database is down")
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.msgs[msgID] = true
+	return nil
+}
+
+func Test_publisher_Publish_Journal(t *testing.T) {
+	tests := []struct {
+		name          string
+		journal       map[string]bool
+		failPublishes int
+		failAcked     bool
+		wantErr       bool
+		wantPublished int
+		wantAcked     bool
+	}{
+		{name: "New message", journal: map[string]bool{}, wantPublished: 1, wantAcked: true},
+		{name: "In flight message is published again", journal: map[string]bool{"msg-001": false}, wantPublished: 1, wantAcked: true},
+		{name: "Acked message is skipped", journal: map[string]bool{"msg-001": true}, wantPublished: 0, wantAcked: true},
+		{name: "Failed publish stays in flight", journal: map[string]bool{}, failPublishes: 1, wantErr: true, wantPublished: 0, wantAcked: false},
+		{name: "Failed journal", journal: map[string]bool{}, failAcked: true, wantErr: true, wantPublished: 1, wantAcked: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn := makeTestConnection(t, "MESSAGES", 1, []byte("test message"), "msg-001", nil)
+			conn.nats.(*testBridge).failPublishes = tt.failPublishes
+			journal := &memoryJournal{msgs: tt.journal, failAcked: tt.failAcked}
+			pub := &Publisher{conn: conn, logger: slog.Default(), streamName: "MESSAGES", journal: journal}
+
+			err := pub.Publish(NewMsg("MESSAGES.Important", "msg-001", []byte("test message")))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Publish() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := len(conn.nats.(*testBridge).published); got != tt.wantPublished {
+				t.Errorf("Published %d messages, want %d", got, tt.wantPublished)
+			}
+			if acked := journal.msgs["msg-001"]; acked != tt.wantAcked {
+				t.Errorf("Journal acked = %v, want %v", acked, tt.wantAcked)
+			}
+		})
+	}
+}
@@ -0,0 +1,174 @@
+package vnats
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+// KeyValueArgs contains the arguments for opening a KeyValue bucket.
+// By using a struct we are open for adding new arguments in the future
+// and the caller can omit arguments where the default value is OK.
+type KeyValueArgs struct {
+	// Bucket is the name of the bucket, which is created if it does not exist.
+	// The other arguments apply only when the bucket is created.
+	Bucket string
+
+	// TTL is the duration, after which a value expires. Default is 0, which means values do not expire.
+	TTL time.Duration
+
+	// History is the number of revisions kept per key, at most 64. Default is 1, which means only the
+	// latest value.
+	History uint8
+
+	// Storage defines where the values are stored. Default is nats.FileStorage.
+	Storage nats.StorageType
+
+	// Encoding is used to encode the values on Put and decode them on Get. Default is EncJSON.
+	Encoding Encoding
+}
+
+// KeyValue stores encoded values by key in a JetStream KV bucket, e.g. for configuration or small state.
+type KeyValue struct {
+	kv       nats.KeyValue
+	logger   *slog.Logger
+	bucket   string
+	encoding Encoding
+}
+
+// KeyValueEntry is a revision of a key received by KeyValue.Watch.
+type KeyValueEntry struct {
+	// Key of the entry.
+	Key string
+
+	// Revision is the stream sequence of the entry, which increases with every change of the bucket.
+	Revision uint64
+
+	// Deleted is set, if the key was deleted or purged. The entry has no value then.
+	Deleted bool
+
+	// Created is the time the revision was stored.
+	Created time.Time
+
+	value    []byte
+	encoding Encoding
+}
+
+// Decode decodes the value of the entry into v.
+func (e KeyValueEntry) Decode(v any) error {
+	return e.encoding.Unmarshal(e.value, v)
+}
+
+// KeyValue opens the bucket of args, or creates it if it does not exist.
+func (c *Connection) KeyValue(args KeyValueArgs) (*KeyValue, error) {
+	if args.Bucket == "" {
+		return nil, fmt.Errorf("bucket cannot be empty")
+	}
+	if args.History > nats.KeyValueMaxHistory {
+		return nil, fmt.Errorf("history %d of bucket %s exceeds %d", args.History, args.Bucket, nats.KeyValueMaxHistory)
+	}
+	if args.Encoding == "" {
+		args.Encoding = EncJSON
+	}
+
+	kv, err := c.nats.KeyValue(&nats.KeyValueConfig{
+		Bucket:   args.Bucket,
+		TTL:      args.TTL,
+		History:  args.History,
+		Storage:  args.Storage,
+		Replicas: len(c.nats.Servers()),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("bucket %s could not be opened: %w", args.Bucket, err)
+	}
+	return &KeyValue{kv: kv, logger: c.logger, bucket: args.Bucket, encoding: args.Encoding}, nil
+}
+
+// Get decodes the latest value of the key into v and returns its revision. If the key does not exist or was
+// deleted, the error wraps nats.ErrKeyNotFound.
+func (kv *KeyValue) Get(key string, v any) (uint64, error) {
+	entry, err := kv.kv.Get(key)
+	if err != nil {
+		return 0, fmt.Errorf("key %s of bucket %s could not be read: %w", key, kv.bucket, err)
+	}
+	if err := kv.encoding.Unmarshal(entry.Value(), v); err != nil {
+		return 0, fmt.Errorf("key %s of bucket %s could not be decoded: %w", key, kv.bucket, err)
+	}
+	return entry.Revision(), nil
+}
+
+// Put encodes v as the value of the key and returns the new revision.
+func (kv *KeyValue) Put(key string, v any) (uint64, error) {
+	data, err := kv.encoding.Marshal(v)
+	if err != nil {
+		return 0, fmt.Errorf("key %s of bucket %s could not be encoded: %w", key, kv.bucket, err)
+	}
+	revision, err := kv.kv.Put(key, data)
+	if err != nil {
+		return 0, fmt.Errorf("key %s of bucket %s could not be written: %w", key, kv.bucket, err)
+	}
+	return revision, nil
+}
+
+// Delete deletes the key. Its former revisions are kept according to the History of the bucket.
+func (kv *KeyValue) Delete(key string) error {
+	if err := kv.kv.Delete(key); err != nil {
+		return fmt.Errorf("key %s of bucket %s could not be deleted: %w", key, kv.bucket, err)
+	}
+	return nil
+}
+
+// Watch sends the latest entry of every key matching the keys, which may contain the wildcards "*" and ">",
+// and afterwards every change, until ctx is done. The returned channel is closed then.
+func (kv *KeyValue) Watch(ctx context.Context, keys string) (<-chan KeyValueEntry, error) {
+	watcher, err := kv.kv.Watch(keys)
+	if err != nil {
+		return nil, fmt.Errorf("keys %s of bucket %s could not be watched: %w", keys, kv.bucket, err)
+	}
+
+	entries := make(chan KeyValueEntry)
+	go func() {
+		defer close(entries)
+		defer func() {
+			if err := watcher.Stop(); err != nil && !errors.Is(err, nats.ErrConnectionClosed) {
+				kv.logger.Error("Watcher could not be stopped", slog.String("bucket", kv.bucket),
+					slog.String("error", err.Error()))
+			}
+		}()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case entry, ok := <-watcher.Updates():
+				if !ok {
+					return
+				}
+				if entry == nil { // marks that the latest entries were sent
+					continue
+				}
+				select {
+				case entries <- kv.makeEntry(entry):
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return entries, nil
+}
+
+func (kv *KeyValue) makeEntry(entry nats.KeyValueEntry) KeyValueEntry {
+	return KeyValueEntry{
+		Key:      entry.Key(),
+		Revision: entry.Revision(),
+		Deleted:  entry.Operation() != nats.KeyValuePut,
+		Created:  entry.Created(),
+		value:    entry.Value(),
+		encoding: kv.encoding,
+	}
+}
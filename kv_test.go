@@ -0,0 +1,82 @@
+package vnats
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+func TestConnection_KeyValue_InvalidArgs(t *testing.T) {
+	conn := makeTestConnection(t, "", 0, nil, "", nil)
+	if _, err := conn.KeyValue(KeyValueArgs{}); err == nil {
+		t.Error("KeyValue() without bucket should fail")
+	}
+	if _, err := conn.KeyValue(KeyValueArgs{Bucket: "config", History: nats.KeyValueMaxHistory + 1}); err == nil {
+		t.Error("KeyValue() with too much history should fail")
+	}
+}
+
+func TestKeyValue(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	const bucket = "TestKeyValue"
+	conn := makeIntegrationTestConn(t)
+	_ = conn.nats.(*natsBridge).jetStreamContext.DeleteKeyValue(bucket)
+
+	kv, err := conn.KeyValue(KeyValueArgs{Bucket: bucket, History: 5, TTL: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	status, err := kv.kv.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.History() != 5 || status.TTL() != time.Hour {
+		t.Errorf("Bucket has history %d and TTL %v, expected 5 and 1h", status.History(), status.TTL())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	entries, err := kv.Watch(ctx, "feature.>")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	revision, err := kv.Put("feature.checkout", testMessagePayload{Message: "enabled"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var value testMessagePayload
+	if got, err := kv.Get("feature.checkout", &value); err != nil || got != revision || value.Message != "enabled" {
+		t.Errorf("Get() = %d, %+v, %v, want %d, enabled", got, value, err, revision)
+	}
+	if err := kv.Delete("feature.checkout"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := kv.Get("feature.checkout", &value); !errors.Is(err, nats.ErrKeyNotFound) {
+		t.Errorf("Get() of deleted key error = %v, want %v", err, nats.ErrKeyNotFound)
+	}
+
+	put := <-entries
+	var watched testMessagePayload
+	if err := put.Decode(&watched); err != nil || put.Key != "feature.checkout" || put.Deleted || watched.Message != "enabled" {
+		t.Errorf("Got entry %+v with value %+v (error %v), expected the put", put, watched, err)
+	}
+	if deleted := <-entries; !deleted.Deleted {
+		t.Errorf("Got entry %+v, expected the deletion", deleted)
+	}
+
+	cancel()
+	for range entries { // the channel is closed once ctx is done
+	}
+	if err := conn.nats.(*natsBridge).jetStreamContext.DeleteKeyValue(bucket); err != nil {
+		t.Error(err)
+	}
+	if err := conn.Close(); err != nil {
+		t.Error(err)
+	}
+}
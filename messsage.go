@@ -1,12 +1,26 @@
 package vnats
 
 import (
+	"fmt"
+	"strconv"
+	"time"
+
 	"github.com/nats-io/nats.go"
 )
 
 // A Header represents the key-value pairs.
 type Header map[string][]string
 
+// Get returns the first value associated with the given key. It is case-sensitive.
+func (h Header) Get(key string) string {
+	return nats.Header(h).Get(key)
+}
+
+// Set sets the value of the given key, replacing any existing values. It is case-sensitive.
+func (h Header) Set(key, value string) {
+	nats.Header(h).Set(key, value)
+}
+
 // Msg contains the arguments publishing a new message.
 // By using a struct we are open for adding new arguments in the future
 // and the caller can omit arguments where the default value is OK.
@@ -22,14 +36,47 @@ type Msg struct {
 	// Semantically equal messages must lead to the same MsgID at any time.
 	// E.g. two messages with the same Data must have the same MsgID.
 	//
-	// The MsgID is used for deduplication.
+	// The MsgID is used for deduplication within the DuplicateWindow of the StreamConfig.
 	MsgID string
 
 	// Data represents the raw byte data to send. The data is sent as-is.
 	Data []byte
 
-	// Header represents the optional Header for the message.
+	// Header represents the optional Header for the message, e.g. for correlation IDs or tracing context.
+	// It is copied, so the Header of the caller is not modified by publishing. A ContentTypeHeader set by the
+	// caller is kept, unless Encoding is set.
 	Header Header
+
+	// Encoding represents the format of Data. It overrides the Encoding of the Publisher for this message
+	// and is sent in the ContentTypeHeader. The Subscriber sets it from the ContentTypeHeader.
+	Encoding Encoding
+
+	// DeliverAfter delays the handling of the message by the Subscribers for the given duration after publishing.
+	// The message is stored immediately with the DeliverAtHeader and NAKed with delay until the time arrived, so
+	// each deferral counts as delivery, which matters for the MaxDeliver of the consumer. Default is 0.
+	DeliverAfter time.Duration
+
+	// ExpectLastSubjectSequence lets the server store the message only, if the last message of the Subject in
+	// the stream has this sequence, or the Subject has no messages for 0. This enables optimistic concurrency,
+	// e.g. for the events of an aggregate. Otherwise, publishing fails with an error wrapping
+	// ErrSequenceMismatch. Default is nil, which means no expectation.
+	ExpectLastSubjectSequence *uint64
+
+	// ExpectLastSequence works like ExpectLastSubjectSequence, but for the last message of the whole stream.
+	ExpectLastSequence *uint64
+
+	// Stream is the name of the stream the message was received from. It is set by the Subscriber and GetMsg only.
+	Stream string
+
+	// Sequence is the sequence of the message in the stream. It is set by the Subscriber and GetMsg only.
+	Sequence uint64
+
+	// Timestamp is the time the message was stored in the stream. It is set by the Subscriber and GetMsg only.
+	Timestamp time.Time
+
+	// NumDelivered is the number of deliveries of the message to the consumer, including this one.
+	// It is set by the Subscriber only, e.g. to detect redeliveries.
+	NumDelivered uint64
 }
 
 // NewMsg constructs a new Msg with the given data.
@@ -41,21 +88,48 @@ func NewMsg(subject, id string, data []byte) *Msg {
 	}
 }
 
+// Decode decodes the Data of the message into v according to its Encoding.
+func (m *Msg) Decode(v any) error {
+	if m.Encoding == "" {
+		return fmt.Errorf("message with msgID: %s has no encoding", m.MsgID)
+	}
+	return m.Encoding.Unmarshal(m.Data, v)
+}
+
 func makeMsg(msg *nats.Msg) Msg {
 	return Msg{
-		Subject: msg.Subject,
-		Reply:   msg.Reply,
-		MsgID:   msg.Header.Get(nats.MsgIdHdr),
-		Data:    msg.Data,
-		Header:  Header(msg.Header),
+		Subject:  msg.Subject,
+		Reply:    msg.Reply,
+		MsgID:    msg.Header.Get(nats.MsgIdHdr),
+		Data:     msg.Data,
+		Header:   Header(msg.Header),
+		Encoding: Encoding(msg.Header.Get(ContentTypeHeader)),
 	}
 }
 
-func (m *Msg) toNATS() *nats.Msg {
+// toNATS converts the message. The header is copied, so that headers can be added without modifying the
+// Msg of the caller.
+func (m *Msg) toNATS(encoding Encoding) *nats.Msg {
+	header := make(nats.Header, len(m.Header)+1)
+	for key, values := range m.Header {
+		header[key] = values
+	}
+	if encoding != "" {
+		header.Set(ContentTypeHeader, string(encoding))
+	}
+	if m.DeliverAfter > 0 {
+		header.Set(DeliverAtHeader, time.Now().Add(m.DeliverAfter).UTC().Format(time.RFC3339Nano))
+	}
+	if m.ExpectLastSubjectSequence != nil {
+		header.Set(nats.ExpectedLastSubjSeqHdr, strconv.FormatUint(*m.ExpectLastSubjectSequence, 10))
+	}
+	if m.ExpectLastSequence != nil {
+		header.Set(nats.ExpectedLastSeqHdr, strconv.FormatUint(*m.ExpectLastSequence, 10))
+	}
 	return &nats.Msg{
 		Subject: m.Subject,
 		Reply:   m.Reply,
 		Data:    m.Data,
-		Header:  nats.Header(m.Header),
+		Header:  header,
 	}
 }
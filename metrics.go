@@ -0,0 +1,25 @@
+package vnats
+
+import "time"
+
+// Metrics observes the messages published and handled through a Connection, e.g. to export them to
+// Prometheus by the package vnatsprom. The methods are called synchronously in the publish and handle paths,
+// so they must be fast and safe for concurrent use.
+type Metrics interface {
+	// ObservePublish is called for every message sent to NATS, with the final error after all retries or
+	// nil if the message was published.
+	ObservePublish(subject string, err error)
+
+	// ObserveHandle is called for every message passed to the handler of a Subscriber of the consumer, with
+	// the duration and the error of the handler. Redeliveries have a NumDelivered above 1.
+	ObserveHandle(consumer string, msg Msg, duration time.Duration, err error)
+}
+
+// WithMetrics reports the published and handled messages of all Publishers and Subscribers to metrics.
+// This option can be passed in the Connect function.
+// Without this option, no metrics are collected.
+func WithMetrics(metrics Metrics) Option {
+	return func(c *Connection) {
+		c.metrics = metrics
+	}
+}
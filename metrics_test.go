@@ -0,0 +1,77 @@
+package vnats
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+type recordedPublish struct {
+	subject string
+	failed  bool
+}
+
+type recordedHandle struct {
+	consumer     string
+	numDelivered uint64
+	failed       bool
+}
+
+type testMetrics struct {
+	mu        sync.Mutex
+	published []recordedPublish
+	handled   []recordedHandle
+}
+
+func (m *testMetrics) ObservePublish(subject string, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.published = append(m.published, recordedPublish{subject: subject, failed: err != nil})
+}
+
+func (m *testMetrics) ObserveHandle(consumer string, msg Msg, _ time.Duration, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.handled = append(m.handled, recordedHandle{consumer: consumer, numDelivered: msg.NumDelivered, failed: err != nil})
+}
+
+func TestWithMetrics_Publish(t *testing.T) {
+	metrics := &testMetrics{}
+	conn := makeTestConnection(t, "MESSAGES", 1, []byte("test message"), "msg-001", nil)
+	WithMetrics(metrics)(conn)
+	pub := &Publisher{conn: conn, logger: conn.logger, streamName: "MESSAGES", backoff: defaultRetryBackoff}
+
+	if err := pub.Publish(NewMsg("MESSAGES.Important", "msg-001", []byte("test message"))); err != nil {
+		t.Fatal(err)
+	}
+	conn.nats.(*testBridge).failPublishes = 1
+	if err := pub.Publish(NewMsg("MESSAGES.Important", "msg-001", []byte("test message"))); err == nil {
+		t.Fatal("Publish() should fail")
+	}
+
+	want := []recordedPublish{{subject: "MESSAGES.Important"}, {subject: "MESSAGES.Important", failed: true}}
+	if len(metrics.published) != len(want) || metrics.published[0] != want[0] || metrics.published[1] != want[1] {
+		t.Errorf("Observed publishes %v, want %v", metrics.published, want)
+	}
+}
+
+func TestWithMetrics_Handle(t *testing.T) {
+	metrics := &testMetrics{}
+	sub := makeTestSubscriber(SubscriberArgs{ConsumerName: "TestConsumer"}, func(_ context.Context, msg Msg) error {
+		if msg.NumDelivered == 1 {
+			return errors.New("downstream unavailable")
+		}
+		return nil
+	})
+	WithMetrics(metrics)(sub.conn)
+
+	sub.handleMsg(context.Background(), makeTestJSMsg(integrationTestStreamName+".metrics", []byte("hello"), 1))
+	sub.handleMsg(context.Background(), makeTestJSMsg(integrationTestStreamName+".metrics", []byte("hello"), 2))
+
+	want := []recordedHandle{{consumer: "TestConsumer", numDelivered: 1, failed: true}, {consumer: "TestConsumer", numDelivered: 2}}
+	if len(metrics.handled) != len(want) || metrics.handled[0] != want[0] || metrics.handled[1] != want[1] {
+		t.Errorf("Observed handles %v, want %v", metrics.handled, want)
+	}
+}
@@ -0,0 +1,70 @@
+package vnats
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// Middleware wraps a handler to add cross-cutting behavior, like recovering panics, logging or metrics.
+type Middleware func(next ContextMsgHandler) ContextMsgHandler
+
+// chainMiddlewares wraps the handler with the middlewares. The first middleware is the outermost one,
+// so it is called first.
+func chainMiddlewares(handler ContextMsgHandler, middlewares []Middleware) ContextMsgHandler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i](handler)
+	}
+	return handler
+}
+
+// RecoverMiddleware recovers panics of the handler and returns them as error, so that the message is
+// NAKed instead of crashing the process.
+func RecoverMiddleware() Middleware {
+	return func(next ContextMsgHandler) ContextMsgHandler {
+		return func(ctx context.Context, msg Msg) (err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					err = fmt.Errorf("handler panicked: %v", r)
+				}
+			}()
+			return next(ctx, msg)
+		}
+	}
+}
+
+// LogMiddleware logs every handled message with its duration at debug level, and failed ones at error level.
+// The correlation ID of the message is logged, if the SubscriberArgs contain a CorrelationIDExtractor.
+func LogMiddleware(logger *slog.Logger) Middleware {
+	return func(next ContextMsgHandler) ContextMsgHandler {
+		return func(ctx context.Context, msg Msg) error {
+			start := time.Now()
+			err := next(ctx, msg)
+			attrs := append(correlationAttrs(ctx),
+				slog.String("msgID", msg.MsgID),
+				slog.String("subject", msg.Subject),
+				slog.Duration("duration", time.Since(start)),
+			)
+			if err != nil {
+				logger.ErrorContext(ctx, "Message handling failed", append(attrs, slog.String("error", err.Error()))...)
+			} else {
+				logger.DebugContext(ctx, "Message handled", attrs...)
+			}
+			return err
+		}
+	}
+}
+
+// MetricsMiddleware calls observe with the subject, duration and result of every handled message, so that
+// it can be recorded by the metrics library of your choice.
+func MetricsMiddleware(observe func(subject string, duration time.Duration, err error)) Middleware {
+	return func(next ContextMsgHandler) ContextMsgHandler {
+		return func(ctx context.Context, msg Msg) error {
+			start := time.Now()
+			err := next(ctx, msg)
+			observe(msg.Subject, time.Since(start), err)
+			return err
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package vnats
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_chainMiddlewares(t *testing.T) {
+	var calls []string
+	record := func(name string) Middleware {
+		return func(next ContextMsgHandler) ContextMsgHandler {
+			return func(ctx context.Context, msg Msg) error {
+				calls = append(calls, name)
+				return next(ctx, msg)
+			}
+		}
+	}
+	handler := chainMiddlewares(func(_ context.Context, _ Msg) error {
+		calls = append(calls, "handler")
+		return nil
+	}, []Middleware{record("first"), record("second")})
+
+	if err := handler(context.Background(), Msg{}); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"first", "second", "handler"}; !reflect.DeepEqual(calls, want) {
+		t.Errorf("calls = %v, want %v", calls, want)
+	}
+}
+
+func TestRecoverMiddleware(t *testing.T) {
+	handler := RecoverMiddleware()(func(_ context.Context, _ Msg) error {
+		panic("nil pointer")
+	})
+	if err := handler(context.Background(), Msg{}); err == nil {
+		t.Error("RecoverMiddleware() should return the panic as error")
+	}
+}
+
+func TestMetricsMiddleware(t *testing.T) {
+	errHandler := errors.New("handler failed")
+	var gotSubject string
+	var gotErr error
+	handler := MetricsMiddleware(func(subject string, _ time.Duration, err error) {
+		gotSubject, gotErr = subject, err
+	})(func(_ context.Context, _ Msg) error {
+		return errHandler
+	})
+
+	if err := handler(context.Background(), Msg{Subject: "PRODUCTS.new"}); !errors.Is(err, errHandler) {
+		t.Errorf("MetricsMiddleware() error = %v, want %v", err, errHandler)
+	}
+	if gotSubject != "PRODUCTS.new" || !errors.Is(gotErr, errHandler) {
+		t.Errorf("observe called with subject = %v, err = %v", gotSubject, gotErr)
+	}
+}
@@ -0,0 +1,38 @@
+package vnats
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+const (
+	// migrationConsumerSuffix is appended to the name of the temporary consumer, which validates the new config.
+	migrationConsumerSuffix = "-migration"
+
+	// migrationConsumerInactiveThreshold removes the temporary consumer, if it could not be deleted.
+	migrationConsumerInactiveThreshold = time.Minute
+)
+
+// MigrateConsumer changes the config of a consumer, even if immutable fields like the FilterSubject are
+// changed. The consumer is deleted and recreated with newCfg, which starts at the first message that was
+// not acknowledged yet, so that already processed messages are not delivered again.
+// The Durable, DeliverPolicy, OptStartSeq and OptStartTime of newCfg are overwritten.
+//
+// Before the consumer is deleted, newCfg is validated by creating a temporary consumer with it, so that an
+// invalid config leaves the consumer untouched. If recreating fails nevertheless, the consumer is restored with
+// its previous config at the same position and both errors are returned.
+//
+// Stop all Subscribers of the consumer before, otherwise their subscription breaks. Messages, which are ACKed
+// during the migration or were ACKed out of order above the ack floor, are delivered again; the latter is
+// logged as a warning.
+func (c *Connection) MigrateConsumer(streamName, consumerName string, newCfg *nats.ConsumerConfig) error {
+	if newCfg == nil {
+		return fmt.Errorf("consumer %s could not be migrated: config is missing", consumerName)
+	}
+	if err := c.nats.MigrateConsumer(streamName, consumerName, newCfg); err != nil {
+		return fmt.Errorf("consumer %s could not be migrated: %w", consumerName, err)
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package vnats
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+func TestConnection_MigrateConsumer(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	subject := integrationTestStreamName + ".migrateConsumer"
+	consumerName := "TestConnectionMigrateConsumer"
+	conn := makeIntegrationTestConn(t)
+	publishStringMessages(t, conn, subject, []string{"first", "second", "third"})
+	sub := createSubscriber(t, conn, consumerName, subject, MultipleSubscribersAllowed)
+	sub.handler = func(_ context.Context, _ Msg) error { return nil }
+	sub.processMessages(context.Background(), 2, defaultFetchWait)
+
+	js := conn.nats.(*natsBridge).jetStreamContext
+	deadline := time.Now().Add(time.Second * 5)
+	for time.Now().Before(deadline) {
+		if info, err := js.ConsumerInfo(integrationTestStreamName, consumerName); err == nil && info.AckFloor.Stream == 2 {
+			break
+		}
+		time.Sleep(time.Millisecond * 50)
+	}
+
+	if err := conn.MigrateConsumer(integrationTestStreamName, consumerName, &nats.ConsumerConfig{
+		AckPolicy:     nats.AckExplicitPolicy,
+		AckWait:       time.Minute,
+		FilterSubject: integrationTestStreamName + ".>",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := js.ConsumerInfo(integrationTestStreamName, consumerName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Config.AckWait != time.Minute || info.Config.FilterSubject != integrationTestStreamName+".>" {
+		t.Errorf("Consumer config was not migrated: %+v", info.Config)
+	}
+	if info.NumPending != 1 {
+		t.Errorf("Got %d pending messages after migration, expected 1", info.NumPending)
+	}
+	if err := conn.Close(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestConnection_MigrateConsumer_InvalidConfig(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	subject := integrationTestStreamName + ".migrateConsumerInvalid"
+	consumerName := "TestConnectionMigrateConsumerInvalid"
+	conn := makeIntegrationTestConn(t)
+	createSubscriber(t, conn, consumerName, subject, MultipleSubscribersAllowed)
+
+	// The server requires more deliveries than BackOff durations.
+	err := conn.MigrateConsumer(integrationTestStreamName, consumerName, &nats.ConsumerConfig{
+		AckPolicy:     nats.AckExplicitPolicy,
+		FilterSubject: integrationTestStreamName + ".>",
+		MaxDeliver:    1,
+		BackOff:       []time.Duration{time.Second, time.Second},
+	})
+	if err == nil {
+		t.Fatal("MigrateConsumer() with an invalid config should fail")
+	}
+
+	js := conn.nats.(*natsBridge).jetStreamContext
+	info, err := js.ConsumerInfo(integrationTestStreamName, consumerName)
+	if err != nil {
+		t.Fatalf("Consumer was lost by the failed migration: %v", err)
+	}
+	if info.Config.FilterSubject != subject {
+		t.Errorf("Got filter subject %s after the failed migration, want %s", info.Config.FilterSubject, subject)
+	}
+	if _, err := js.ConsumerInfo(integrationTestStreamName, consumerName+migrationConsumerSuffix); !errors.Is(err, nats.ErrConsumerNotFound) {
+		t.Errorf("Temporary consumer of the migration was not deleted: %v", err)
+	}
+	if err := conn.Close(); err != nil {
+		t.Error(err)
+	}
+}
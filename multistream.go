@@ -0,0 +1,172 @@
+package vnats
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/nats-io/nats.go"
+)
+
+// MultiStreamSubscriberArgs contains the arguments for creating a new MultiStreamSubscriber.
+// By using a struct we are open for adding new arguments in the future
+// and the caller can omit arguments where the default value is OK.
+type MultiStreamSubscriberArgs struct {
+	// ConsumerName contains the name of the consumer, which is created on each stream.
+	ConsumerName string
+
+	// Sources defines the subscribed subjects, usually of different streams.
+	Sources []MultiStreamSource
+
+	// Mode defines the constraints of the subscriptions. Default is MultipleSubscribersAllowed.
+	// See SubscriptionMode for details.
+	Mode SubscriptionMode
+}
+
+// MultiStreamSource is a subscribed subject of a MultiStreamSubscriber.
+type MultiStreamSource struct {
+	// Subject defines which subjects of a stream should be subscribed. See SubscriberArgs.Subject.
+	Subject string
+
+	// Weight is the maximum number of messages handled from this source per round, before the next source
+	// is served. It is the size of the batches fetched from the source. While a batch is handled, every other
+	// source may hold a fetched batch, which counts towards its AckWait. Default is 1.
+	Weight int
+}
+
+// MultiStreamSubscriber subscribes to consumers of multiple streams and delivers their messages to a single
+// MsgHandler. The sources are served round-robin according to their weight, so that a busy stream does not
+// starve the others. Every source is fetched concurrently, so idle sources do not delay the busy ones.
+// The source stream of each message is available in Msg.Stream.
+type MultiStreamSubscriber struct {
+	sources []multiStreamSource
+	started bool
+}
+
+type multiStreamSource struct {
+	sub    *Subscriber
+	weight int
+}
+
+// NewMultiStreamSubscriber creates a new MultiStreamSubscriber that subscribes to all sources.
+func (c *Connection) NewMultiStreamSubscriber(args MultiStreamSubscriberArgs) (*MultiStreamSubscriber, error) {
+	if len(args.Sources) == 0 {
+		return nil, fmt.Errorf("multi-stream subscriber needs at least one source")
+	}
+
+	m := &MultiStreamSubscriber{}
+	for _, source := range args.Sources {
+		sub, err := c.NewSubscriber(SubscriberArgs{
+			ConsumerName: args.ConsumerName,
+			Subject:      source.Subject,
+			Mode:         args.Mode,
+		})
+		if err != nil {
+			err = fmt.Errorf("multi-stream subscriber for %s could not be created: %w", source.Subject, err)
+			created := make([]*Subscriber, 0, len(m.sources))
+			for _, source := range m.sources {
+				created = append(created, source.sub)
+			}
+			return nil, errors.Join(err, unsubscribeAll(created))
+		}
+		weight := source.Weight
+		if weight < 1 {
+			weight = 1
+		}
+		m.sources = append(m.sources, multiStreamSource{sub: sub, weight: weight})
+	}
+	return m, nil
+}
+
+// Start starts a go-routine that handles the pulled messages of all sources.
+func (m *MultiStreamSubscriber) Start(handler MsgHandler) error {
+	return m.StartWithContext(context.Background(), func(_ context.Context, msg Msg) error {
+		return handler(msg)
+	})
+}
+
+// StartWithContext works like Start, but passes ctx to the handler. Once ctx is cancelled, pending fetches are
+// aborted and the go-routine returns without handling new messages, like Subscriber.StartWithContext.
+func (m *MultiStreamSubscriber) StartWithContext(ctx context.Context, handler ContextMsgHandler) error {
+	if m.started {
+		return fmt.Errorf("handler is already set, don't call Start() multiple times")
+	}
+	m.started = true
+
+	// All subscribers share the stopped channel, so that Connection.Close waits for the single go-routine.
+	stopped := make(chan struct{})
+	for _, source := range m.sources {
+		source.sub.handler = handler
+		source.sub.stopped = stopped
+	}
+
+	// Quitting any source stops all of them, like Connection.Close does. The handler keeps ctx, so that
+	// quitting does not cancel the message in flight.
+	fetchCtx, cancel := context.WithCancel(ctx)
+	batches := make(chan multiStreamBatch)
+	var fetchers sync.WaitGroup
+	for _, source := range m.sources {
+		fetchers.Add(1)
+		go func() {
+			defer fetchers.Done()
+			defer cancel()
+			source.fetch(fetchCtx, batches)
+		}()
+	}
+
+	go func() {
+		defer close(stopped)
+		defer fetchers.Wait()
+		defer cancel()
+		for {
+			select {
+			case <-fetchCtx.Done():
+				return
+			case batch := <-batches:
+				batch.sub.handleMessages(ctx, batch.natsMsgs)
+				close(batch.handled)
+			}
+		}
+	}()
+	return nil
+}
+
+// multiStreamBatch is a fetched batch of a source, which waits to be handled.
+type multiStreamBatch struct {
+	sub      *Subscriber
+	natsMsgs []*nats.Msg
+	handled  chan struct{}
+}
+
+// fetch passes batches of the source to the go-routine of StartWithContext until ctx is cancelled or the
+// Subscriber quits. The next batch is fetched after the previous one was handled. The go-routine receives
+// the batches of waiting sources in the order they were fetched, which serves them round-robin.
+func (s multiStreamSource) fetch(ctx context.Context, batches chan<- multiStreamBatch) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.sub.quitSignal:
+			s.sub.logger.Info("Received signal to quit multi-stream subscription go-routine.")
+			return
+		default:
+		}
+		natsMsgs := s.sub.fetchMessages(ctx, s.weight, s.sub.fetchWait)
+		if len(natsMsgs) == 0 {
+			continue
+		}
+
+		batch := multiStreamBatch{sub: s.sub, natsMsgs: natsMsgs, handled: make(chan struct{})}
+		select {
+		case batches <- batch:
+			<-batch.handled
+		case <-ctx.Done():
+			// Release the batch, instead of leaving it in flight until AckWait expires.
+			for _, natsMsg := range natsMsgs {
+				s.sub.nak(natsMsg, 0)
+			}
+			return
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package vnats
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+// ObjectStoreArgs contains the arguments for opening an ObjectStore bucket.
+// By using a struct we are open for adding new arguments in the future
+// and the caller can omit arguments where the default value is OK.
+type ObjectStoreArgs struct {
+	// Bucket is the name of the bucket, which is created if it does not exist.
+	// The other arguments apply only when the bucket is created.
+	Bucket string
+
+	// TTL is the duration, after which an object expires. Default is 0, which means objects do not expire.
+	TTL time.Duration
+
+	// Storage defines where the objects are stored. Default is nats.FileStorage.
+	Storage nats.StorageType
+}
+
+// ObjectStore stores objects of any size in a JetStream object store bucket, which splits them into chunks,
+// so that they are not limited by the maximum message size.
+type ObjectStore struct {
+	store  nats.ObjectStore
+	bucket string
+}
+
+// ObjectStore opens the bucket of args, or creates it if it does not exist.
+func (c *Connection) ObjectStore(args ObjectStoreArgs) (*ObjectStore, error) {
+	if args.Bucket == "" {
+		return nil, fmt.Errorf("bucket cannot be empty")
+	}
+
+	store, err := c.nats.ObjectStore(&nats.ObjectStoreConfig{
+		Bucket:   args.Bucket,
+		TTL:      args.TTL,
+		Storage:  args.Storage,
+		Replicas: len(c.nats.Servers()),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("object store %s could not be opened: %w", args.Bucket, err)
+	}
+	return &ObjectStore{store: store, bucket: args.Bucket}, nil
+}
+
+// Put stores the content of r as the object with the given name, replacing an existing object.
+func (o *ObjectStore) Put(name string, r io.Reader) error {
+	if _, err := o.store.Put(&nats.ObjectMeta{Name: name}, r); err != nil {
+		return fmt.Errorf("object %s could not be stored in %s: %w", name, o.bucket, err)
+	}
+	return nil
+}
+
+// Get returns a reader of the object with the given name, which must be closed by the caller. The content is
+// verified against its digest while reading. If the object does not exist, the error wraps
+// nats.ErrObjectNotFound.
+func (o *ObjectStore) Get(name string) (io.ReadCloser, error) {
+	result, err := o.store.Get(name)
+	if err != nil {
+		return nil, fmt.Errorf("object %s could not be read from %s: %w", name, o.bucket, err)
+	}
+	return result, nil
+}
+
+// Delete deletes the object with the given name.
+func (o *ObjectStore) Delete(name string) error {
+	if err := o.store.Delete(name); err != nil {
+		return fmt.Errorf("object %s could not be deleted from %s: %w", name, o.bucket, err)
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package vnats
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+func TestConnection_ObjectStore_InvalidArgs(t *testing.T) {
+	conn := makeTestConnection(t, "", 0, nil, "", nil)
+	if _, err := conn.ObjectStore(ObjectStoreArgs{}); err == nil {
+		t.Error("ObjectStore() without bucket should fail")
+	}
+}
+
+func TestObjectStore(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	const bucket = "TestObjectStore"
+	conn := makeIntegrationTestConn(t)
+	js := conn.nats.(*natsBridge).jetStreamContext
+	_ = js.DeleteObjectStore(bucket)
+
+	store, err := conn.ObjectStore(ObjectStoreArgs{Bucket: bucket, TTL: time.Hour, Storage: nats.MemoryStorage})
+	if err != nil {
+		t.Fatal(err)
+	}
+	status, err := store.store.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.TTL() != time.Hour || status.Storage() != nats.MemoryStorage {
+		t.Errorf("Object store has TTL %v and storage %v, expected 1h and memory", status.TTL(), status.Storage())
+	}
+
+	// Larger than the default chunk size of 128KB, so the object is split into several messages.
+	content := bytes.Repeat([]byte("0123456789"), 50_000)
+	if err := store.Put("report.csv", bytes.NewReader(content)); err != nil {
+		t.Fatal(err)
+	}
+	r, err := store.Get("report.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Close(); err != nil {
+		t.Error(err)
+	}
+	if !bytes.Equal(got, content) {
+		t.Errorf("Got %d bytes, expected the %d stored bytes", len(got), len(content))
+	}
+
+	if err := store.Delete("report.csv"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Get("report.csv"); !errors.Is(err, nats.ErrObjectNotFound) {
+		t.Errorf("Get() of deleted object error = %v, want %v", err, nats.ErrObjectNotFound)
+	}
+
+	if err := js.DeleteObjectStore(bucket); err != nil {
+		t.Error(err)
+	}
+	if err := conn.Close(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,45 @@
+package vnats
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/nats-io/nats.go"
+)
+
+// validateOrdered rejects the arguments, which require acknowledgements or a durable consumer.
+func validateOrdered(args SubscriberArgs) error {
+	switch {
+	case args.QueueGroup != "":
+		return fmt.Errorf("ordered consumer cannot be shared by queue group %s", args.QueueGroup)
+	case args.AckWait > 0, args.AutoInProgress, args.MaxAckPending > 0:
+		return fmt.Errorf("ordered consumer does not acknowledge, so AckWait, AutoInProgress and MaxAckPending cannot be set")
+	case args.MaxDeliver > 0, args.DeadLetterSubject != "":
+		return fmt.Errorf("ordered consumer does not redeliver, so MaxDeliver and DeadLetterSubject cannot be set")
+	case args.WatermarkBucket != "":
+		return fmt.Errorf("ordered consumer cannot resume from watermark bucket %s", args.WatermarkBucket)
+	case args.MaxRequestBatch > 0, args.MaxRequestMaxBytes > 0, args.FetchBatchSize > 1:
+		return fmt.Errorf("ordered consumer pushes messages, so pull request limits cannot be set")
+	}
+	return nil
+}
+
+// handle calls the handler with the message. Messages of an ordered consumer cannot be redelivered, so a
+// failed message is retried in place after the NAK delay, until the handler succeeds, returns ErrInvalidMsg,
+// or the Subscriber quits.
+func (s *Subscriber) handle(ctx context.Context, msg Msg) error {
+	err := s.handler(ctx, msg)
+	for attempt := 1; s.ordered && err != nil && !errors.Is(err, ErrInvalidMsg); attempt++ {
+		delay := s.nakDelay(&nats.MsgMetadata{NumDelivered: uint64(attempt)}, err)
+		s.logger.ErrorContext(ctx, "Message handle error, will be retried",
+			append(correlationAttrs(ctx), slog.Int("attempt", attempt), slog.Duration("delay", delay),
+				slog.String("error", err.Error()))...)
+		if !s.pause(ctx, delay) {
+			return err
+		}
+		err = s.handler(ctx, msg)
+	}
+	return err
+}
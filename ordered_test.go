@@ -0,0 +1,75 @@
+package vnats
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func Test_validateOrdered(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    SubscriberArgs
+		wantErr bool
+	}{
+		{name: "Defaults", args: SubscriberArgs{}, wantErr: false},
+		{name: "Single fetch", args: SubscriberArgs{FetchBatchSize: 1}, wantErr: false},
+		{name: "Queue group", args: SubscriberArgs{QueueGroup: "orders"}, wantErr: true},
+		{name: "AckWait", args: SubscriberArgs{AckWait: time.Minute}, wantErr: true},
+		{name: "AutoInProgress", args: SubscriberArgs{AutoInProgress: true}, wantErr: true},
+		{name: "MaxDeliver", args: SubscriberArgs{MaxDeliver: 3}, wantErr: true},
+		{name: "Dead letter", args: SubscriberArgs{DeadLetterSubject: "DLQ.orders"}, wantErr: true},
+		{name: "Watermark", args: SubscriberArgs{WatermarkBucket: "watermarks"}, wantErr: true},
+		{name: "Batch", args: SubscriberArgs{FetchBatchSize: 10}, wantErr: true},
+		{name: "MaxRequestBatch", args: SubscriberArgs{MaxRequestBatch: 10}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateOrdered(tt.args); (err != nil) != tt.wantErr {
+				t.Errorf("validateOrdered() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSubscriber_OrderedConsumer(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	subject := integrationTestStreamName + ".ordered"
+	conn := makeIntegrationTestConn(t)
+	publishManyMessages(t, conn, subject, 3)
+
+	sub, err := conn.NewSubscriber(SubscriberArgs{Subject: subject, Mode: OrderedConsumer})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var mu sync.Mutex
+	var received []string
+	failed := false
+	if err := sub.Start(func(msg Msg) error {
+		mu.Lock()
+		defer mu.Unlock()
+		received = append(received, string(msg.Data))
+		if string(msg.Data) == "msg-1" && !failed {
+			failed = true
+			return RetryAfter(time.Millisecond*100, errors.New("temporary failure"))
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(time.Millisecond * 800)
+	mu.Lock()
+	want := []string{"msg-0", "msg-1", "msg-1", "msg-2"}
+	if !reflect.DeepEqual(received, want) {
+		t.Errorf("Received %v, expected %v with the failed message retried in place", received, want)
+	}
+	mu.Unlock()
+	if err := conn.Close(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,97 @@
+package vnats
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+const (
+	defaultOutboxBatchSize    = 100
+	defaultOutboxPollInterval = time.Second
+)
+
+// OutboxSource provides the pending messages of a transactional outbox, e.g. rows of a database table
+// written in the same transaction as the business data.
+type OutboxSource interface {
+	// Fetch returns up to limit pending messages in the order they should be published.
+	// The MsgID of every message must be set and stable, so that a message published again, because
+	// MarkSent failed, is deduplicated by the server.
+	Fetch(ctx context.Context, limit int) ([]*Msg, error)
+
+	// MarkSent marks the message as sent. It is called only after the server acknowledged the message.
+	MarkSent(ctx context.Context, msg *Msg) error
+}
+
+// OutboxArgs contains the arguments for running an outbox with RunOutbox.
+// By using a struct we are open for adding new arguments in the future
+// and the caller can omit arguments where the default value is OK.
+type OutboxArgs struct {
+	// StreamName is the name of the stream the messages are published to. See PublisherArgs.StreamName.
+	StreamName string
+
+	// BatchSize is the maximum number of messages fetched from the OutboxSource at once. Default is 100.
+	BatchSize int
+
+	// PollInterval is the time to wait, when the outbox is empty or an error occurred. Default is 1s.
+	PollInterval time.Duration
+}
+
+// RunOutbox publishes the pending messages of the source until ctx is cancelled. A message is marked as
+// sent only after it was acknowledged by the server. If a message could not be published, the rest of the
+// batch is skipped to keep the order and retried after the PollInterval. RunOutbox returns ctx.Err() once
+// ctx is cancelled, or an error if the Publisher could not be created.
+func (c *Connection) RunOutbox(ctx context.Context, source OutboxSource, args OutboxArgs) error {
+	pub, err := c.NewPublisher(PublisherArgs{StreamName: args.StreamName})
+	if err != nil {
+		return fmt.Errorf("outbox could not be started: %w", err)
+	}
+	defer c.removePublisher(pub)
+	if args.BatchSize < 1 {
+		args.BatchSize = defaultOutboxBatchSize
+	}
+	if args.PollInterval <= 0 {
+		args.PollInterval = defaultOutboxPollInterval
+	}
+
+	for {
+		sent, err := c.publishOutboxBatch(ctx, pub, source, args.BatchSize)
+		if err != nil {
+			c.logger.Error("Outbox batch failed, will be retried", slog.String("error", err.Error()))
+		}
+		// A full batch indicates more pending messages, so continue without waiting.
+		if err == nil && sent == args.BatchSize {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(args.PollInterval):
+		}
+	}
+}
+
+func (c *Connection) publishOutboxBatch(ctx context.Context, pub *Publisher, source OutboxSource, limit int) (int, error) {
+	msgs, err := source.Fetch(ctx, limit)
+	if err != nil {
+		return 0, fmt.Errorf("outbox messages could not be fetched: %w", err)
+	}
+
+	for idx, msg := range msgs {
+		if msg.MsgID == "" {
+			return idx, fmt.Errorf("outbox message @ %s has no msgID", msg.Subject)
+		}
+		if err := pub.PublishWithContext(ctx, msg); err != nil {
+			return idx, err
+		}
+		if err := source.MarkSent(ctx, msg); err != nil {
+			return idx, fmt.Errorf("outbox message with msgID: %s could not be marked as sent: %w", msg.MsgID, err)
+		}
+	}
+	return len(msgs), nil
+}
@@ -0,0 +1,109 @@
+package vnats
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+)
+
+type memoryOutbox struct {
+	mu      sync.Mutex
+	pending []*Msg
+	sent    []string
+	failed  bool
+}
+
+func (o *memoryOutbox) Fetch(_ context.Context, limit int) ([]*Msg, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if len(o.pending) < limit {
+		limit = len(o.pending)
+	}
+	return append([]*Msg(nil), o.pending[:limit]...), nil
+}
+
+func (o *memoryOutbox) MarkSent(_ context.Context, msg *Msg) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	// Fail once to verify the message is published again, but deduplicated.
+	if !o.failed {
+		o.failed = true
+		return fmt.Errorf("database is down")
+	}
+	o.pending = o.pending[1:]
+	o.sent = append(o.sent, msg.MsgID)
+	return nil
+}
+
+func TestConnection_RunOutbox(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	subject := integrationTestStreamName + ".outbox"
+	conn := makeIntegrationTestConn(t)
+
+	outbox := &memoryOutbox{}
+	for i := 0; i < 5; i++ {
+		outbox.pending = append(outbox.pending, NewMsg(subject, fmt.Sprintf("outbox-%d", i), []byte(fmt.Sprintf("msg-%d", i))))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	defer cancel()
+	err := conn.RunOutbox(ctx, outbox, OutboxArgs{
+		StreamName:   integrationTestStreamName,
+		BatchSize:    2,
+		PollInterval: time.Millisecond * 50,
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("RunOutbox() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if len(outbox.sent) != 5 || len(outbox.pending) != 0 {
+		t.Errorf("Not all outbox messages were sent: sent %v, pending %d", outbox.sent, len(outbox.pending))
+	}
+
+	sub := createSubscriber(t, conn, "TestConnectionRunOutbox", subject, SingleSubscriberStrictMessageOrder)
+	expected := []string{"msg-0", "msg-1", "msg-2", "msg-3", "msg-4"}
+	received, err := retrieveStringMessages(sub, expected)
+	if err != nil {
+		t.Error(err)
+	}
+	if fmt.Sprint(received) != fmt.Sprint(expected) {
+		t.Errorf("Got %v, expected %v", received, expected)
+	}
+	if err := conn.Close(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestConnection_publishOutboxBatch_Cancelled(t *testing.T) {
+	conn := makeTestConnection(t, "MESSAGES", 1, []byte("test message"), "msg-001", nil)
+	pub := &Publisher{conn: conn, logger: slog.Default(), streamName: "MESSAGES"}
+	outbox := &memoryOutbox{pending: []*Msg{NewMsg("MESSAGES.outbox", "outbox-0", []byte("msg-0"))}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	sent, err := conn.publishOutboxBatch(ctx, pub, outbox, 1)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("publishOutboxBatch() error = %v, want %v", err, context.Canceled)
+	}
+	if sent != 0 || len(conn.nats.(*testBridge).published) != 0 {
+		t.Errorf("Got %d sent and %d published messages after cancel, want 0", sent, len(conn.nats.(*testBridge).published))
+	}
+}
+
+func TestConnection_RunOutbox_RemovesPublisher(t *testing.T) {
+	conn := makeTestConnection(t, "MESSAGES", 1, []byte("test message"), "msg-001", nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := conn.RunOutbox(ctx, &memoryOutbox{}, OutboxArgs{StreamName: "MESSAGES"}); !errors.Is(err, context.Canceled) {
+		t.Errorf("RunOutbox() error = %v, want %v", err, context.Canceled)
+	}
+	if len(conn.publishers) != 0 {
+		t.Errorf("Got %d publishers after RunOutbox returned, want 0", len(conn.publishers))
+	}
+}
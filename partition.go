@@ -0,0 +1,172 @@
+package vnats
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/nats-io/nats.go"
+)
+
+// PartitionFor returns the partition in the range [0, partitions) the given key belongs to.
+// The mapping is deterministic and uses jump consistent hashing, so that increasing the number
+// of partitions only moves a minimal amount of keys to other partitions.
+func PartitionFor(key string, partitions int) int {
+	if partitions <= 1 {
+		return 0
+	}
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(key))
+	return jumpHash(h.Sum64(), partitions)
+}
+
+// PartitionToken returns the subject token for the given partition, like "p3".
+func PartitionToken(partition int) string {
+	return "p" + strconv.Itoa(partition)
+}
+
+// ServerPartitionFor returns the partition in the range [0, partitions) the server maps the given key to,
+// if the stream is configured with SubjectPartitioning. Unlike PartitionFor, it uses the hashing of the server.
+func ServerPartitionFor(key string, partitions int) int {
+	if partitions <= 1 {
+		return 0
+	}
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+	return int(h.Sum32() % uint32(partitions))
+}
+
+// partitionSubject inserts the partition token after the first token (the stream name) of the subject.
+// Example: "EVENTS.created" in partition 3 -> "EVENTS.p3.created"
+func partitionSubject(subject string, partition int) string {
+	return insertPartitionToken(subject, PartitionToken(partition))
+}
+
+// insertPartitionToken inserts the token after the first token (the stream name) of the subject.
+func insertPartitionToken(subject, token string) string {
+	streamName, rest, found := strings.Cut(subject, ".")
+	if !found {
+		return streamName + "." + token
+	}
+	return streamName + "." + token + "." + rest
+}
+
+// SubjectPartitioning lets the server store each message of Subject in one of the Partitions by the token at
+// KeyWildcard. The server inserts the partition number after the stream name, e.g. with Subject "ORDERS.*.*"
+// and KeyWildcard 2, a message published to "ORDERS.created.customer-42" is stored as
+// "ORDERS.3.created.customer-42". Bind Subscribers with SubscriberArgs.PartitionedByServer, and compute the
+// partition of a key with ServerPartitionFor. Messages not matching Subject are stored unchanged.
+// It requires nats-server 2.10 or newer.
+type SubjectPartitioning struct {
+	// Subject is the subject pattern of the stream, whose messages are partitioned, e.g. "ORDERS.*.*".
+	// It must begin with the stream name and use "*" wildcards only.
+	Subject string
+
+	// KeyWildcard is the position of the "*" wildcard in Subject, starting at 1, whose token is the key.
+	KeyWildcard int
+
+	// Partitions is the number of partitions, at least 1.
+	Partitions int
+}
+
+func (p *SubjectPartitioning) validate(streamName string) error {
+	if err := ValidateSubject(p.Subject); err != nil {
+		return fmt.Errorf("partitioning is invalid: %w", err)
+	}
+	tokens := strings.Split(p.Subject, ".")
+	if tokens[0] != streamName || len(tokens) < 2 {
+		return fmt.Errorf("partitioning subject %s must be within the stream %s", p.Subject, streamName)
+	}
+	if slices.Contains(tokens, ">") {
+		return fmt.Errorf("partitioning subject %s must not contain \">\"", p.Subject)
+	}
+	if wildcards := countTokens(tokens, "*"); p.KeyWildcard < 1 || p.KeyWildcard > wildcards {
+		return fmt.Errorf("partitioning key wildcard %d is out of range [1, %d]", p.KeyWildcard, wildcards)
+	}
+	if p.Partitions < 1 {
+		return fmt.Errorf("partitioning partitions must be at least 1")
+	}
+	return nil
+}
+
+func countTokens(tokens []string, token string) int {
+	count := 0
+	for _, t := range tokens {
+		if t == token {
+			count++
+		}
+	}
+	return count
+}
+
+// subjectTransform maps Subject to the subject with the partition number inserted after the stream name.
+// Example: "ORDERS.*.*" with KeyWildcard 2 and 4 Partitions ->
+// "ORDERS.{{partition(4,2)}}.{{wildcard(1)}}.{{wildcard(2)}}"
+func (p *SubjectPartitioning) subjectTransform(streamName string) *nats.SubjectTransformConfig {
+	tokens := strings.Split(p.Subject, ".")[1:]
+	wildcard := 0
+	for i, token := range tokens {
+		if token == "*" {
+			wildcard++
+			tokens[i] = fmt.Sprintf("{{wildcard(%d)}}", wildcard)
+		}
+	}
+	destination := fmt.Sprintf("%s.{{partition(%d,%d)}}.%s", streamName, p.Partitions, p.KeyWildcard,
+		strings.Join(tokens, "."))
+	return &nats.SubjectTransformConfig{Source: p.Subject, Destination: destination}
+}
+
+// NewPartitionSubscribers creates one Subscriber per partition of args.Partitions, each bound to the subjects
+// of its partition. The consumer of each Subscriber is named after args.ConsumerName and the partition token,
+// e.g. "orders-p3", so that the partitions are processed independently and in parallel, while messages with
+// the same key keep their order. args.Partition is ignored.
+func (c *Connection) NewPartitionSubscribers(args SubscriberArgs) ([]*Subscriber, error) {
+	if args.Partitions < 1 {
+		return nil, fmt.Errorf("partition subscribers could not be created: partitions must be at least 1")
+	}
+
+	subs := make([]*Subscriber, 0, args.Partitions)
+	consumerName := args.ConsumerName
+	for partition := 0; partition < args.Partitions; partition++ {
+		args.Partition = partition
+		args.ConsumerName = partitionConsumerName(consumerName, partition)
+		sub, err := c.NewSubscriber(args)
+		if err != nil {
+			err = fmt.Errorf("subscriber of partition %d could not be created: %w", partition, err)
+			return nil, errors.Join(err, unsubscribeAll(subs))
+		}
+		subs = append(subs, sub)
+	}
+	return subs, nil
+}
+
+// partitionConsumerName appends the partition token to the consumer name.
+// Example: "orders" in partition 3 -> "orders-p3"
+func partitionConsumerName(consumerName string, partition int) string {
+	return consumerName + "-" + PartitionToken(partition)
+}
+
+func validatePartition(partition, partitions int) error {
+	if partitions < 1 {
+		return fmt.Errorf("partitions must be at least 1")
+	}
+	if partition < 0 || partition >= partitions {
+		return fmt.Errorf("partition %d is out of range [0, %d)", partition, partitions)
+	}
+	return nil
+}
+
+// jumpHash is the jump consistent hash algorithm by Lamping and Veach.
+// See https://arxiv.org/abs/1406.2294
+func jumpHash(key uint64, buckets int) int {
+	var b, j int64 = -1, 0
+	for j < int64(buckets) {
+		b = j
+		key = key*2862933555777941757 + 1
+		j = int64(float64(b+1) * (float64(int64(1)<<31) / float64((key>>33)+1)))
+	}
+	return int(b)
+}
@@ -0,0 +1,228 @@
+package vnats
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+func TestPartitionFor(t *testing.T) {
+	tests := []struct {
+		name       string
+		partitions int
+	}{
+		{name: "No partitions", partitions: 0},
+		{name: "Single partition", partitions: 1},
+		{name: "Multiple partitions", partitions: 8},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i := 0; i < 100; i++ {
+				key := fmt.Sprintf("key-%d", i)
+				got := PartitionFor(key, tt.partitions)
+				if got < 0 || (tt.partitions > 0 && got >= tt.partitions) {
+					t.Errorf("PartitionFor(%s, %d) = %d, out of range", key, tt.partitions, got)
+				}
+				if again := PartitionFor(key, tt.partitions); again != got {
+					t.Errorf("PartitionFor(%s, %d) is not deterministic: %d != %d", key, tt.partitions, got, again)
+				}
+			}
+		})
+	}
+}
+
+func Test_partitionSubject(t *testing.T) {
+	tests := []struct {
+		subject   string
+		partition int
+		want      string
+	}{
+		{subject: "EVENTS.created", partition: 3, want: "EVENTS.p3.created"},
+		{subject: "EVENTS.>", partition: 0, want: "EVENTS.p0.>"},
+		{subject: "EVENTS", partition: 1, want: "EVENTS.p1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.subject, func(t *testing.T) {
+			if got := partitionSubject(tt.subject, tt.partition); got != tt.want {
+				t.Errorf("partitionSubject() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPublisher_PartitionSubject(t *testing.T) {
+	pub := &Publisher{conn: &Connection{}, streamName: "EVENTS", partitions: 4}
+
+	got, err := pub.PartitionSubject("customer-42", "EVENTS.created")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := partitionSubject("EVENTS.created", PartitionFor("customer-42", 4))
+	if got != want {
+		t.Errorf("PartitionSubject() = %v, want %v", got, want)
+	}
+
+	if _, err := (&Publisher{conn: &Connection{}, streamName: "EVENTS"}).PartitionSubject("customer-42", "EVENTS.created"); err == nil {
+		t.Errorf("PartitionSubject() without partitions should fail")
+	}
+}
+
+func TestConnection_NewPartitionSubscribers(t *testing.T) {
+	conn := makeTestConnection(t, "EVENTS", 0, nil, "", nil)
+	subs, err := conn.NewPartitionSubscribers(SubscriberArgs{
+		ConsumerName: "orders",
+		Subject:      "EVENTS.created",
+		Partitions:   3,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(subs) != 3 {
+		t.Fatalf("Got %d subscribers, expected 3", len(subs))
+	}
+
+	subscribed := conn.nats.(*testBridge).subscribed
+	for i, args := range subscribed {
+		if want := fmt.Sprintf("orders-p%d", i); args.ConsumerName != want || subs[i].consumerName != want {
+			t.Errorf("Subscriber %d has consumer %s, want %s", i, args.ConsumerName, want)
+		}
+		if want := fmt.Sprintf("EVENTS.p%d.created", i); args.Subject != want {
+			t.Errorf("Subscriber %d binds subject %s, want %s", i, args.Subject, want)
+		}
+	}
+
+	if _, err := conn.NewPartitionSubscribers(SubscriberArgs{ConsumerName: "orders", Subject: "EVENTS.created"}); err == nil {
+		t.Errorf("NewPartitionSubscribers() without partitions should fail")
+	}
+}
+
+func TestSubjectPartitioning_subjectTransform(t *testing.T) {
+	partitioning := &SubjectPartitioning{Subject: "ORDERS.*.*", KeyWildcard: 2, Partitions: 4}
+	if err := partitioning.validate("ORDERS"); err != nil {
+		t.Fatal(err)
+	}
+	got := partitioning.subjectTransform("ORDERS")
+	want := "ORDERS.{{partition(4,2)}}.{{wildcard(1)}}.{{wildcard(2)}}"
+	if got.Source != "ORDERS.*.*" || got.Destination != want {
+		t.Errorf("subjectTransform() = %+v, want destination %s", got, want)
+	}
+}
+
+func TestSubjectPartitioning_validate(t *testing.T) {
+	tests := []struct {
+		name         string
+		partitioning SubjectPartitioning
+	}{
+		{name: "Other stream", partitioning: SubjectPartitioning{Subject: "EVENTS.*", KeyWildcard: 1, Partitions: 2}},
+		{name: "Full wildcard", partitioning: SubjectPartitioning{Subject: "ORDERS.*.>", KeyWildcard: 1, Partitions: 2}},
+		{name: "Key wildcard out of range", partitioning: SubjectPartitioning{Subject: "ORDERS.*", KeyWildcard: 2, Partitions: 2}},
+		{name: "No partitions", partitioning: SubjectPartitioning{Subject: "ORDERS.*", KeyWildcard: 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.partitioning.validate("ORDERS"); err == nil {
+				t.Errorf("validate() of %+v should fail", tt.partitioning)
+			}
+		})
+	}
+}
+
+func TestConnection_NewPartitionSubscribers_PartitionedByServer(t *testing.T) {
+	conn := makeTestConnection(t, "EVENTS", 0, nil, "", nil)
+	if _, err := conn.NewPartitionSubscribers(SubscriberArgs{
+		ConsumerName:        "orders",
+		Subject:             "EVENTS.created.*",
+		Partitions:          2,
+		PartitionedByServer: true,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	for i, args := range conn.nats.(*testBridge).subscribed {
+		if want := fmt.Sprintf("EVENTS.%d.created.*", i); args.Subject != want {
+			t.Errorf("Subscriber %d binds subject %s, want %s", i, args.Subject, want)
+		}
+	}
+}
+
+func TestConnection_NewPartitionSubscribers_ServerPartitioning(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	streamName := "PartitionTests"
+	conn := makeIntegrationTestConn(t)
+	nb := conn.nats.(*natsBridge)
+	if err := deleteStream(nb, streamName); err != nil && !errors.Is(err, nats.ErrStreamNotFound) {
+		t.Fatal(err)
+	}
+	pub, err := conn.NewPublisher(PublisherArgs{
+		StreamName:   streamName,
+		StreamConfig: StreamConfig{Partitioning: &SubjectPartitioning{Subject: streamName + ".*.*", KeyWildcard: 2, Partitions: 4}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := pub.Publish(NewMsg(streamName+".created.customer-42", "msg-001", []byte("hello"))); err != nil {
+		t.Fatal(err)
+	}
+
+	subs, err := conn.NewPartitionSubscribers(SubscriberArgs{
+		ConsumerName:        "TestServerPartitioning",
+		Subject:             streamName + ".created.*",
+		Partitions:          4,
+		PartitionedByServer: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	received := make(chan int, 4)
+	for partition, sub := range subs {
+		if err := sub.Start(func(_ Msg) error {
+			received <- partition
+			return nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	select {
+	case got := <-received:
+		if want := ServerPartitionFor("customer-42", 4); got != want {
+			t.Errorf("Message was received in partition %d, want %d", got, want)
+		}
+	case <-time.After(time.Second * 5):
+		t.Fatal("Message was not received in any partition")
+	}
+	if err := conn.Close(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestConnection_NewPartitionSubscribers_Cleanup(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	subject := integrationTestStreamName + ".partitionCleanup"
+	conn := makeIntegrationTestConn(t)
+	args := SubscriberArgs{ConsumerName: "TestPartitionCleanup", Subject: subject, Partitions: 2, MaxDeliver: 5}
+
+	// The consumer of partition 1 exists with another MaxDeliver, so that its Subscriber cannot be created.
+	existing := args
+	existing.Partition = 1
+	existing.ConsumerName = partitionConsumerName(args.ConsumerName, 1)
+	if _, err := conn.NewSubscriber(existing); err != nil {
+		t.Fatal(err)
+	}
+	args.MaxDeliver = 3
+	if _, err := conn.NewPartitionSubscribers(args); err == nil {
+		t.Fatal("NewPartitionSubscribers() with drifted consumer should fail")
+	}
+	if len(conn.subscribers) != 1 {
+		t.Errorf("Got %d subscribers, want only the existing one", len(conn.subscribers))
+	}
+	if err := conn.Close(); err != nil {
+		t.Error(err)
+	}
+}
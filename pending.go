@@ -0,0 +1,75 @@
+package vnats
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// Backlog contains the message counts of a consumer, which are not processed yet.
+type Backlog struct {
+	// Pending is the number of messages of the stream, which were not delivered to the consumer yet.
+	Pending uint64
+
+	// AckPending is the number of messages, which were delivered, but are not acknowledged yet.
+	AckPending int
+
+	// Redelivered is the number of messages pending acknowledgement, which were delivered more than once.
+	Redelivered int
+}
+
+// Backlog fetches the backlog of the consumer from the server, e.g. to export it as a gauge. The counts apply
+// to all Subscribers of the consumer.
+func (s *Subscriber) Backlog() (Backlog, error) {
+	info, err := s.subscription.ConsumerInfo()
+	if err != nil {
+		return Backlog{}, fmt.Errorf("consumer info of %s could not be fetched: %w", s.consumerName, err)
+	}
+	return Backlog{Pending: info.NumPending, AckPending: info.NumAckPending, Redelivered: info.NumRedelivered}, nil
+}
+
+// pendingAlarm fires once the pending messages reach the threshold, and again only after they dropped below.
+type pendingAlarm struct {
+	threshold uint64
+	onAlarm   func(pending uint64)
+	raised    bool
+}
+
+func (a *pendingAlarm) observe(pending uint64) {
+	if pending < a.threshold {
+		a.raised = false
+		return
+	}
+	if !a.raised {
+		a.raised = true
+		a.onAlarm(pending)
+	}
+}
+
+// watchPending checks the pending messages of the consumer periodically until the Subscriber quits.
+func (s *Subscriber) watchPending(ctx context.Context) {
+	interval := s.pendingAlarmInterval
+	if interval <= 0 {
+		interval = defaultPendingAlarmInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.quitSignal:
+			return
+		case <-ticker.C:
+			info, err := s.subscription.ConsumerInfo()
+			if err != nil {
+				s.logger.Error("Consumer info could not be fetched for pending alarm",
+					slog.String("name", s.consumerName), slog.String("error", err.Error()))
+				continue
+			}
+			s.pendingAlarm.observe(info.NumPending)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package vnats
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_pendingAlarm(t *testing.T) {
+	var alarms []uint64
+	alarm := &pendingAlarm{threshold: 100, onAlarm: func(pending uint64) { alarms = append(alarms, pending) }}
+	for _, pending := range []uint64{10, 100, 150, 99, 120, 130} {
+		alarm.observe(pending)
+	}
+
+	if want := []uint64{100, 120}; !reflect.DeepEqual(alarms, want) {
+		t.Errorf("Got alarms %v, want %v", alarms, want)
+	}
+}
+
+func TestSubscriber_OnPendingAlarm(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	subject := integrationTestStreamName + ".pendingAlarm"
+	conn := makeIntegrationTestConn(t)
+	publishManyMessages(t, conn, subject, 5)
+
+	alarms := make(chan uint64, 1)
+	sub, err := conn.NewSubscriber(SubscriberArgs{
+		ConsumerName:          "TestSubscriberOnPendingAlarm",
+		Subject:               subject,
+		PendingAlarmThreshold: 3,
+		OnPendingAlarm:        func(pending uint64) { alarms <- pending },
+		PendingAlarmInterval:  time.Millisecond * 10,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	go sub.watchPending(context.Background())
+
+	select {
+	case pending := <-alarms:
+		if pending != 5 {
+			t.Errorf("Got alarm with %d pending messages, expected 5", pending)
+		}
+	case <-time.After(time.Second):
+		t.Error("Pending alarm was not raised")
+	}
+	if err := conn.Close(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSubscriber_Backlog(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	subject := integrationTestStreamName + ".backlog"
+	conn := makeIntegrationTestConn(t)
+	publishManyMessages(t, conn, subject, 5)
+
+	sub, err := conn.NewSubscriber(SubscriberArgs{ConsumerName: "TestSubscriberBacklog", Subject: subject})
+	if err != nil {
+		t.Fatal(err)
+	}
+	msgs, err := sub.subscription.Fetch(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := msgs[0].AckSync(); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := sub.Backlog()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (Backlog{Pending: 3, AckPending: 1}); got != want {
+		t.Errorf("Backlog() = %+v, want %+v", got, want)
+	}
+	if err := conn.Close(); err != nil {
+		t.Error(err)
+	}
+}
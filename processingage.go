@@ -0,0 +1,90 @@
+package vnats
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+// firstDeliveries records when the Subscriber received each unsettled message for the first time, since
+// JetStream only provides the time a message was stored in the stream. Messages first received by another
+// Subscriber or before a restart are recorded at their first delivery to this Subscriber.
+// Messages settled elsewhere, e.g. by another Subscriber of the consumer or after the last delivery, are never
+// settled here, so entries expire, once no further delivery can be expected. A nil firstDeliveries is disabled.
+type firstDeliveries struct {
+	mu      sync.Mutex
+	entries map[uint64]time.Time
+	ttl     time.Duration
+	now     func() time.Time
+}
+
+// newFirstDeliveries keeps each entry for the maxProcessingAge and the AckWait of every possible delivery.
+// Without MaxDeliver the next redelivery after the maxProcessingAge is terminated, so one AckWait is enough.
+func newFirstDeliveries(maxProcessingAge, ackWait time.Duration, maxDeliver int) *firstDeliveries {
+	if maxProcessingAge <= 0 {
+		return nil
+	}
+	return &firstDeliveries{
+		entries: map[uint64]time.Time{},
+		ttl:     maxProcessingAge + ackWait*time.Duration(max(maxDeliver, 1)),
+		now:     time.Now,
+	}
+}
+
+// observe records the delivery of the message and returns the time elapsed since its first delivery.
+func (f *firstDeliveries) observe(sequence uint64) time.Duration {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	now := f.now()
+	f.pruneExpired(now)
+	first, ok := f.entries[sequence]
+	if !ok {
+		f.entries[sequence] = now
+		return 0
+	}
+	return now.Sub(first)
+}
+
+func (f *firstDeliveries) pruneExpired(now time.Time) {
+	for seq, first := range f.entries {
+		if now.Sub(first) > f.ttl {
+			delete(f.entries, seq)
+		}
+	}
+}
+
+// settle forgets the message, which was ACKed or terminated.
+func (f *firstDeliveries) settle(natsMsg *nats.Msg) {
+	if f == nil {
+		return
+	}
+	meta, err := natsMsg.Metadata()
+	if err != nil {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.entries, meta.Sequence.Stream)
+}
+
+// exceedsMaxProcessingAge terminates a redelivered message, if its first delivery is longer ago than the
+// MaxProcessingAge.
+func (s *Subscriber) exceedsMaxProcessingAge(natsMsg *nats.Msg, msg Msg, meta *nats.MsgMetadata) bool {
+	if s.firstDeliveries == nil {
+		return false
+	}
+	age := s.firstDeliveries.observe(meta.Sequence.Stream)
+	if meta.NumDelivered < 2 || age <= s.maxProcessingAge {
+		return false
+	}
+
+	s.logger.Warn("Message exceeded max processing age, will be terminated",
+		slog.String("msgID", msg.MsgID), slog.String("subject", msg.Subject), slog.Duration("age", age))
+	s.term(natsMsg)
+	if s.onMaxProcessingAge != nil {
+		s.onMaxProcessingAge(msg, age)
+	}
+	return true
+}
@@ -0,0 +1,67 @@
+package vnats
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestSubscriber_MaxProcessingAge_FirstDelivery(t *testing.T) {
+	now := time.Now()
+	var terminated []Msg
+	handled := 0
+	sub := makeTestSubscriber(SubscriberArgs{
+		MaxProcessingAge:           time.Minute,
+		OnMaxProcessingAgeExceeded: func(msg Msg, _ time.Duration) { terminated = append(terminated, msg) },
+	}, func(_ context.Context, _ Msg) error {
+		handled++
+		return errors.New("REST-Endpoint is down, retry later")
+	})
+	sub.firstDeliveries.now = func() time.Time { return now }
+
+	// The message was stored in the stream long before it is consumed.
+	storedAt := now.Add(-time.Hour)
+	deliver := func(numDelivered uint64) {
+		natsMsg := makeTestJSMsg(integrationTestStreamName+".age", []byte("hello"), numDelivered)
+		natsMsg.Reply = fmt.Sprintf("$JS.ACK.%s.TestConsumer.%d.7.7.%d.0", integrationTestStreamName, numDelivered,
+			storedAt.UnixNano())
+		sub.handleMsg(context.Background(), natsMsg)
+	}
+
+	deliver(1)
+	now = now.Add(time.Second)
+	deliver(2)
+	if handled != 2 || len(terminated) != 0 {
+		t.Fatalf("Handled %d times and terminated %d times within the max age, want 2 and 0", handled, len(terminated))
+	}
+
+	now = now.Add(time.Minute)
+	deliver(3)
+	if handled != 2 || len(terminated) != 1 {
+		t.Errorf("Handled %d times and terminated %d times after the max age, want 2 and 1", handled, len(terminated))
+	}
+}
+
+func Test_firstDeliveries_expire(t *testing.T) {
+	now := time.Now()
+	deliveries := newFirstDeliveries(time.Minute, time.Second*30, 3)
+	deliveries.now = func() time.Time { return now }
+
+	deliveries.observe(1) // settled by another Subscriber, never seen again
+	now = now.Add(time.Minute)
+	deliveries.observe(2)
+
+	now = now.Add(time.Second * 90)
+	if age := deliveries.observe(2); age != time.Second*90 || len(deliveries.entries) != 2 {
+		t.Errorf("observe() within expiry = %v with %d entries, want %v with 2 entries", age, len(deliveries.entries),
+			time.Second*90)
+	}
+
+	now = now.Add(time.Second)
+	deliveries.observe(2)
+	if _, ok := deliveries.entries[1]; ok || len(deliveries.entries) != 1 {
+		t.Errorf("Got entries %v after expiry, want only sequence 2", deliveries.entries)
+	}
+}
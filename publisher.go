@@ -1,67 +1,307 @@
 package vnats
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/nats-io/nats.go"
 )
 
+// ErrSequenceMismatch is wrapped by the error of publishing a message, whose ExpectLastSubjectSequence or
+// ExpectLastSequence does not match the stream anymore. Reload the state and publish again.
+var ErrSequenceMismatch = errors.New("last sequence does not match expected sequence")
+
+// ErrMessageTooLarge is wrapped by the error of publishing a message, whose encoded headers and Data exceed the max
+// payload of the server. Enable PublisherArgs.Compression or store the Data in the ObjectStore and publish a reference instead.
+var ErrMessageTooLarge = errors.New("message exceeds max payload")
+
 // NewPublisher creates a new Publisher that publishes to a NATS stream.
 func (c *Connection) NewPublisher(args PublisherArgs) (*Publisher, error) {
 	if err := validateStreamName(args.StreamName); err != nil {
 		return nil, err
 	}
-	if err := c.nats.EnsureStreamExists(&nats.StreamConfig{
-		Name:       args.StreamName,
-		Subjects:   []string{args.StreamName + ".>"},
-		Storage:    defaultStorageType,
-		Replicas:   len(c.nats.Servers()),
-		Duplicates: defaultDuplicationWindow,
-		MaxAge:     time.Hour * 24 * 30,
-	}); err != nil {
+	for _, subject := range args.StreamConfig.Subjects {
+		if err := ValidateSubject(subject); err != nil {
+			return nil, fmt.Errorf("publisher could not be created: %w", err)
+		}
+	}
+	if args.StreamConfig.Partitioning != nil {
+		if err := args.StreamConfig.Partitioning.validate(args.StreamName); err != nil {
+			return nil, fmt.Errorf("publisher could not be created: %w", err)
+		}
+	}
+	if args.Compression != CompressionNone && args.Compression != CompressionGzip {
+		return nil, fmt.Errorf("publisher could not be created: compression %q is not supported", args.Compression)
+	}
+	if args.Journal != nil && args.AsyncPublish {
+		return nil, fmt.Errorf("publisher could not be created: Journal cannot be combined with AsyncPublish")
+	}
+	if err := c.nats.EnsureStreamExists(args.StreamConfig.natsConfig(args.StreamName)); err != nil {
 		return nil, fmt.Errorf("publisher could not be created: %w", err)
 	}
 
 	p := &Publisher{
-		conn:       c,
-		logger:     c.logger,
-		streamName: args.StreamName,
+		conn:        c,
+		logger:      c.logger,
+		streamName:  args.StreamName,
+		subjects:    args.StreamConfig.Subjects,
+		partitions:  args.Partitions,
+		encoding:    args.Encoding,
+		cipher:      args.Cipher,
+		compression: args.Compression,
+		async:       args.AsyncPublish,
+		maxRetries:  args.MaxRetries,
+		backoff:     args.RetryBackoff,
+		timeout:     args.PublishTimeout,
+		wait:        waitContext,
+		journal:     args.Journal,
+	}
+	if p.backoff == nil {
+		p.backoff = defaultRetryBackoff
 	}
+	c.mu.Lock()
+	c.publishers = append(c.publishers, p)
+	c.mu.Unlock()
 	return p, nil
 }
 
 // Publisher is a NATS publisher that publishes to a NATS stream.
 type Publisher struct {
-	conn       *Connection
-	streamName string
-	logger     *slog.Logger
+	conn        *Connection
+	streamName  string
+	subjects    []string
+	partitions  int
+	encoding    Encoding
+	cipher      Cipher
+	compression Compression
+	async       bool
+	maxRetries  int
+	backoff     BackoffStrategy
+	timeout     time.Duration
+	wait        func(ctx context.Context, d time.Duration) error
+	asyncAcks   asyncAcks
+	journal     PublishJournal
+	logger      *slog.Logger
 }
 
 // Publish publishes the message (data) to the given subject.
 func (p *Publisher) Publish(msg *Msg) error {
-	if err := validateSubject(msg.Subject, p.streamName); err != nil {
+	return p.PublishWithContext(context.Background(), msg)
+}
+
+// PublishWithContext works like Publish, but stops waiting for the ack of the server and retrying, once ctx
+// is done. The returned error wraps ctx.Err() then. Whether a cancelled message was stored is unknown, so
+// publish it again with the same MsgID to be sure. The PublishTimeout shortens the deadline of ctx. Without any
+// deadline, the ack is awaited for 5 seconds.
+func (p *Publisher) PublishWithContext(ctx context.Context, msg *Msg) error {
+	if err := p.validateSubject(msg.Subject); err != nil {
 		return err
 	}
+	if err := p.conn.checkSubjectAllowed(msg.Subject); err != nil {
+		return fmt.Errorf("message with msgID: %s could not be published: %w", msg.MsgID, err)
+	}
+
+	encoding := msg.Encoding
+	if encoding == "" && msg.Header.Get(ContentTypeHeader) == "" {
+		encoding = p.encoding
+	}
+
+	natsMsg := msg.toNATS(encoding)
+	if p.compression != CompressionNone {
+		compressed, err := p.compression.compress(natsMsg.Data)
+		if err != nil {
+			return fmt.Errorf("message with msgID: %s could not be compressed: %w", msg.MsgID, err)
+		}
+		natsMsg.Data = compressed
+		natsMsg.Header.Set(ContentEncodingHeader, string(p.compression))
+	}
+	if p.cipher != nil {
+		sealed, err := p.cipher.Seal(natsMsg.Data)
+		if err != nil {
+			return fmt.Errorf("message with msgID: %s could not be encrypted: %w", msg.MsgID, err)
+		}
+		natsMsg.Data = sealed
+		natsMsg.Header.Set(CipherKeyHeader, p.cipher.KeyID())
+	}
+	if p.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.timeout)
+		defer cancel()
+	}
 
-	err := p.conn.nats.PublishMsg(msg.toNATS(), msg.MsgID)
-	if err != nil {
-		return fmt.Errorf("message with msgID: %s @ %s could not be published: %w", msg.MsgID, msg.Subject, err)
+	publish := func() error {
+		end := func(error) {}
+		if p.conn.tracer != nil {
+			traced := *msg
+			traced.Header = Header(natsMsg.Header) // the trace context is injected into the sent copy
+			end = p.conn.tracer.StartPublish(ctx, traced)
+		}
+		err := p.checkPayloadSize(natsMsg, msg.MsgID)
+		if err == nil {
+			err = p.publishWithRetries(ctx, natsMsg, msg.MsgID)
+		}
+		end(err)
+		if p.conn.metrics != nil {
+			p.conn.metrics.ObservePublish(msg.Subject, err)
+		}
+		if err != nil {
+			return fmt.Errorf("message with msgID: %s @ %s could not be published: %w", msg.MsgID, msg.Subject, err)
+		}
+		return nil
+	}
+	if p.journal != nil {
+		return p.publishJournaled(msg, publish)
+	}
+	return publish()
+}
+
+// checkPayloadSize returns ErrMessageTooLarge, if the headers and Data of natsMsg exceed the max payload of the
+// server, like the server would reject it. The msgID header is only set while publishing, so it is counted separately.
+func (p *Publisher) checkPayloadSize(natsMsg *nats.Msg, msgID string) error {
+	maxPayload := p.conn.nats.MaxPayload()
+	if maxPayload <= 0 {
+		return nil
+	}
+	size := headerSize(natsMsg.Header) + len(natsMsg.Data)
+	if msgID != "" && natsMsg.Header.Get(nats.MsgIdHdr) == "" {
+		size += len(nats.MsgIdHdr) + len(": \r\n") + len(msgID)
+		if len(natsMsg.Header) == 0 {
+			size += len("NATS/1.0\r\n\r\n")
+		}
+	}
+	if int64(size) > maxPayload {
+		return fmt.Errorf("%w: %d bytes exceed %d bytes", ErrMessageTooLarge, size, maxPayload)
 	}
 	return nil
 }
 
-func validateSubject(subject, streamName string) error {
+// headerSize returns the length of the header as encoded on the wire: "NATS/1.0\r\n", a "Key: Value\r\n" line for
+// each value and a closing "\r\n". A message without header has no header section at all.
+func headerSize(header nats.Header) int {
+	if len(header) == 0 {
+		return 0
+	}
+	size := len("NATS/1.0\r\n\r\n")
+	for key, values := range header {
+		for _, value := range values {
+			size += len(key) + len(": \r\n") + len(value)
+		}
+	}
+	return size
+}
+
+func (p *Publisher) publishWithRetries(ctx context.Context, natsMsg *nats.Msg, msgID string) error {
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 {
+			delay := p.backoff.Next(attempt)
+			p.logger.Warn("Retry publish", slog.String("msgID", msgID), slog.Int("attempt", attempt),
+				slog.Duration("delay", delay))
+			if err := p.wait(ctx, delay); err != nil {
+				return err
+			}
+		}
+
+		err := ctx.Err()
+		if err != nil {
+			return err
+		}
+		if p.async {
+			var future nats.PubAckFuture
+			if future, err = p.conn.nats.PublishMsgAsync(natsMsg, msgID); err == nil {
+				p.asyncAcks.add(future)
+			}
+		} else {
+			err = p.conn.nats.PublishMsg(ctx, natsMsg, msgID)
+		}
+		if err = sequenceMismatch(err); errors.Is(err, ErrSequenceMismatch) {
+			return err // retrying cannot resolve the conflict
+		}
+		if err == nil || attempt >= p.maxRetries || ctx.Err() != nil {
+			return err
+		}
+	}
+}
+
+// sequenceMismatch wraps ErrSequenceMismatch around err, if the server rejected the expected last sequence.
+func sequenceMismatch(err error) error {
+	var apiErr *nats.APIError
+	if errors.As(err, &apiErr) && apiErr.ErrorCode == nats.JSErrCodeStreamWrongLastSequence {
+		return fmt.Errorf("%w: %w", ErrSequenceMismatch, err)
+	}
+	return err
+}
+
+// waitContext blocks for the given duration. It returns ctx.Err(), if ctx is done meanwhile.
+func waitContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// PartitionSubject returns the subject in the partition of the given key. The partition token is
+// inserted after the stream name, e.g. "EVENTS.created" becomes "EVENTS.p3.created".
+// Messages with the same key always end up in the same partition.
+func (p *Publisher) PartitionSubject(key, subject string) (string, error) {
+	if p.partitions < 1 {
+		return "", fmt.Errorf("publisher is not configured with partitions")
+	}
+	if err := p.validateSubject(subject); err != nil {
+		return "", err
+	}
+	return partitionSubject(subject, PartitionFor(key, p.partitions)), nil
+}
+
+// validateSubject checks that the subject belongs to the stream of the Publisher.
+func (p *Publisher) validateSubject(subject string) error {
+	if p.conn.streamResolver == nil {
+		return validateSubject(subject, p.streamName, p.subjects)
+	}
+	if err := ValidateSubject(subject); err != nil {
+		return err
+	}
+	if err := validatePublishSubject(subject); err != nil {
+		return err
+	}
+	if streamName := p.conn.streamResolver(subject); streamName != p.streamName {
+		return fmt.Errorf("subject %s belongs to stream %q, not to stream %q of publisher", subject, streamName, p.streamName)
+	}
+	return nil
+}
+
+// validateSubject checks that the subject begins with the stream name or matches one of the additional subjects of
+// the stream.
+func validateSubject(subject, streamName string, subjects []string) error {
 	if err := validateStreamName(streamName); err != nil {
 		return err
 	}
-	if subject == "" {
-		return fmt.Errorf("subject cannot be empty")
+	if err := ValidateSubject(subject); err != nil {
+		return err
+	}
+	if !strings.HasPrefix(subject, streamName+".") && !slices.ContainsFunc(subjects, func(pattern string) bool {
+		return subjectIsSubset(subject, pattern)
+	}) {
+		return fmt.Errorf("subject needs to begin with `STREAM_NAME.` or match StreamConfig.Subjects")
 	}
-	if !strings.HasPrefix(subject, streamName+".") {
-		return fmt.Errorf("subject needs to begin with `STREAM_NAME.`")
+	return validatePublishSubject(subject)
+}
+
+// validatePublishSubject rejects wildcards, since messages can only be published to concrete subjects.
+func validatePublishSubject(subject string) error {
+	for _, token := range strings.Split(subject, ".") {
+		if token == "*" || token == ">" {
+			return fmt.Errorf("%w: cannot publish to wildcard subject %s", nats.ErrBadSubject, subject)
+		}
 	}
 	return nil
 }
@@ -73,5 +313,8 @@ func validateStreamName(streamName string) error {
 	if strings.ContainsAny(streamName, "*.>") {
 		return fmt.Errorf("streamName cannot contain any of chars: *.>")
 	}
+	if strings.IndexFunc(streamName, invalidSubjectRune) >= 0 {
+		return fmt.Errorf("streamName %q contains invalid characters", streamName)
+	}
 	return nil
 }
@@ -1,8 +1,15 @@
 package vnats
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"log/slog"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
 )
 
 type testMessagePayload struct {
@@ -87,6 +94,28 @@ func Test_publisher_Publish(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "Publish to wildcard subject",
+
+			args: args{
+				data:       []byte("test message"),
+				streamName: "MESSAGES",
+				subject:    "MESSAGES.*.Important",
+				msgID:      "msg-001",
+			},
+			wantErr: true,
+		},
+		{
+			name: "Publish to subject with whitespace",
+
+			args: args{
+				data:       []byte("test message"),
+				streamName: "MESSAGES",
+				subject:    "MESSAGES.Very Important",
+				msgID:      "msg-001",
+			},
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -174,6 +203,15 @@ func Test_makePublisher(t *testing.T) {
 			want:    nil,
 			wantErr: true,
 		},
+		{
+			name: "StreamName contains whitespace",
+			args: args{
+				conn:       connectionEmptySubscriptions,
+				streamName: "PRODUCTS ",
+			},
+			want:    nil,
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -196,3 +234,222 @@ func Test_makePublisher(t *testing.T) {
 		})
 	}
 }
+
+func Test_publisher_Publish_StreamResolver(t *testing.T) {
+	resolver := func(subject string) string {
+		if strings.HasPrefix(subject, "orders.") {
+			return "ORDERS"
+		}
+		return ""
+	}
+	tests := []struct {
+		name    string
+		subject string
+		wantErr bool
+	}{
+		{name: "Subject resolving to stream of publisher", subject: "orders.created", wantErr: false},
+		{name: "Subject resolving to other stream", subject: "products.created", wantErr: true},
+		{name: "Subject with stream name prefix, but other stream", subject: "ORDERS.created", wantErr: true},
+		{name: "Empty subject", subject: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn := makeTestConnection(t, "ORDERS", 1, []byte("test message"), "msg-001", nil)
+			conn.streamResolver = resolver
+			pub := &Publisher{
+				conn:       conn,
+				logger:     slog.Default(),
+				streamName: "ORDERS",
+			}
+			err := pub.Publish(NewMsg(tt.subject, "msg-001", []byte("test message")))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Publisher.Publish() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_publisher_Publish_Encoding(t *testing.T) {
+	tests := []struct {
+		name      string
+		encoding  Encoding
+		override  Encoding
+		callerHdr string
+		wantInHdr string
+	}{
+		{name: "No encoding", encoding: "", override: "", wantInHdr: ""},
+		{name: "Publisher encoding", encoding: EncJSON, override: "", wantInHdr: string(EncJSON)},
+		{name: "Message overrides publisher encoding", encoding: EncJSON, override: "application/pdf", wantInHdr: "application/pdf"},
+		{name: "Message encoding without publisher encoding", encoding: "", override: EncJSON, wantInHdr: string(EncJSON)},
+		{name: "Header of caller overrides publisher encoding", encoding: EncJSON, callerHdr: "text/csv", wantInHdr: "text/csv"},
+		{name: "Message encoding overrides header of caller", encoding: EncJSON, override: EncJSON, callerHdr: "text/csv", wantInHdr: string(EncJSON)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn := makeTestConnection(t, "MESSAGES", 1, []byte("test message"), "msg-001", nil)
+			pub := &Publisher{
+				conn:       conn,
+				logger:     slog.Default(),
+				streamName: "MESSAGES",
+				encoding:   tt.encoding,
+			}
+			header := Header{"Trace-Id": []string{"42"}}
+			if tt.callerHdr != "" {
+				header.Set(ContentTypeHeader, tt.callerHdr)
+			}
+			if err := pub.Publish(&Msg{
+				Subject:  "MESSAGES.Important",
+				MsgID:    "msg-001",
+				Data:     []byte("test message"),
+				Header:   header,
+				Encoding: tt.override,
+			}); err != nil {
+				t.Fatal(err)
+			}
+
+			published := conn.nats.(*testBridge).published[0]
+			if got := published.Header.Get(ContentTypeHeader); got != tt.wantInHdr {
+				t.Errorf("Content-Type = %q, want %q", got, tt.wantInHdr)
+			}
+			if published.Header.Get("Trace-Id") != "42" {
+				t.Errorf("Header of message was not published: %v", published.Header)
+			}
+			if _, ok := header[ContentTypeHeader]; ok && tt.callerHdr == "" {
+				t.Errorf("Header of caller was modified: %v", header)
+			}
+		})
+	}
+}
+
+func Test_publisher_Publish_Async(t *testing.T) {
+	for _, async := range []bool{false, true} {
+		t.Run(fmt.Sprintf("async=%v", async), func(t *testing.T) {
+			conn := makeTestConnection(t, "MESSAGES", 1, []byte("test message"), "msg-001", nil)
+			pub := &Publisher{conn: conn, logger: slog.Default(), streamName: "MESSAGES", async: async}
+
+			if err := pub.Publish(NewMsg("MESSAGES.Important", "msg-001", []byte("test message"))); err != nil {
+				t.Fatal(err)
+			}
+
+			if got := conn.nats.(*testBridge).publishedAsync == 1; got != async {
+				t.Errorf("Published async = %v, want %v", got, async)
+			}
+		})
+	}
+}
+
+func TestPublisher_PublishWithContext(t *testing.T) {
+	conn := makeTestConnection(t, "MESSAGES", 1, []byte("test message"), "msg-001", nil)
+	conn.nats.(*testBridge).failPublishes = 1
+	pub := &Publisher{
+		conn:       conn,
+		logger:     conn.logger,
+		streamName: "MESSAGES",
+		maxRetries: 3,
+		backoff:    LinearBackoff{Initial: time.Hour},
+		wait:       waitContext,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(time.Millisecond*20, cancel)
+	start := time.Now()
+	err := pub.PublishWithContext(ctx, NewMsg("MESSAGES.Important", "msg-001", []byte("test message")))
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("PublishWithContext() error = %v, want context.Canceled", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("PublishWithContext() returned after %v, expected to return promptly after cancellation", elapsed)
+	}
+
+	if err := pub.PublishWithContext(ctx, NewMsg("MESSAGES.Important", "msg-001", []byte("test message"))); !errors.Is(err, context.Canceled) {
+		t.Errorf("PublishWithContext() with cancelled context error = %v, want context.Canceled", err)
+	}
+	if published := len(conn.nats.(*testBridge).published); published != 0 {
+		t.Errorf("Got %d published messages, expected none", published)
+	}
+}
+
+func TestPublisher_Publish_MaxPayload(t *testing.T) {
+	conn := makeTestConnection(t, "MESSAGES", 1, []byte("test message"), "msg-001", nil)
+	// "NATS/1.0\r\n" + "Nats-Msg-Id: msg-001\r\n" + "\r\n" + "test message"
+	conn.nats.(*testBridge).maxPayload = 46
+	pub := &Publisher{conn: conn, logger: slog.Default(), streamName: "MESSAGES"}
+
+	if err := pub.Publish(NewMsg("MESSAGES.Important", "msg-001", []byte("test message"))); err != nil {
+		t.Errorf("Publish() at max payload error = %v", err)
+	}
+	err := pub.Publish(NewMsg("MESSAGES.Important", "msg-002", []byte("test message!")))
+	if !errors.Is(err, ErrMessageTooLarge) {
+		t.Fatalf("Publish() above max payload error = %v, want %v", err, ErrMessageTooLarge)
+	}
+	if !strings.Contains(err.Error(), "47 bytes exceed 46 bytes") {
+		t.Errorf("Publish() error = %q, want actual and max size", err)
+	}
+
+	msg := NewMsg("MESSAGES.Important", "msg-003", []byte("test"))
+	msg.Header = Header{"X-Trace": []string{"abc"}}
+	if err := pub.Publish(msg); !errors.Is(err, ErrMessageTooLarge) {
+		t.Errorf("Publish() with header above max payload error = %v, want %v", err, ErrMessageTooLarge)
+	}
+	if published := len(conn.nats.(*testBridge).published); published != 1 {
+		t.Errorf("Got %d published messages, want 1", published)
+	}
+}
+
+func Test_headerSize(t *testing.T) {
+	header := nats.Header{"Content-Type": []string{"application/json"}, "X-Tags": []string{"a", "bc"}}
+	want := len("NATS/1.0\r\nContent-Type: application/json\r\nX-Tags: a\r\nX-Tags: bc\r\n\r\n")
+	if got := headerSize(header); got != want {
+		t.Errorf("headerSize() = %d, want %d", got, want)
+	}
+	if got := headerSize(nil); got != 0 {
+		t.Errorf("headerSize(nil) = %d, want 0", got)
+	}
+}
+
+func TestPublisher_Publish_ExpectLastSubjectSequence(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	subject := integrationTestStreamName + ".expectLastSubjectSequence"
+	conn := makeIntegrationTestConn(t)
+	pub, err := conn.NewPublisher(PublisherArgs{StreamName: integrationTestStreamName, MaxRetries: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	publish := func(msgID string, expected uint64) error {
+		msg := NewMsg(subject, msgID, []byte(msgID))
+		msg.ExpectLastSubjectSequence = &expected
+		return pub.Publish(msg)
+	}
+
+	if err := publish("created", 0); err != nil {
+		t.Fatalf("Publish() of first message error = %v", err)
+	}
+	if err := publish("created-concurrently", 0); !errors.Is(err, ErrSequenceMismatch) {
+		t.Errorf("Publish() with outdated sequence error = %v, want %v", err, ErrSequenceMismatch)
+	}
+	if err := publish("updated", 1); err != nil {
+		t.Errorf("Publish() with current sequence error = %v", err)
+	}
+	if err := conn.Close(); err != nil {
+		t.Error(err)
+	}
+}
+
+func Test_publishContext(t *testing.T) {
+	ctx, cancel := publishContext(context.Background())
+	defer cancel()
+	if deadline, ok := ctx.Deadline(); !ok || time.Until(deadline) > defaultPublishTimeout {
+		t.Errorf("Deadline without deadline of caller = %v, want default publish timeout", deadline)
+	}
+
+	callerCtx, callerCancel := context.WithTimeout(context.Background(), time.Minute)
+	defer callerCancel()
+	ctx, cancel = publishContext(callerCtx)
+	defer cancel()
+	want, _ := callerCtx.Deadline()
+	if deadline, _ := ctx.Deadline(); !deadline.Equal(want) {
+		t.Errorf("Deadline = %v, want longer deadline of caller %v", deadline, want)
+	}
+}
@@ -0,0 +1,43 @@
+package vnats
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/nats-io/nats.go"
+)
+
+// PurgeStreamArgs selects the messages removed by PurgeStream.
+// By using a struct we are open for adding new arguments in the future
+// and the caller can omit arguments where the default value is OK.
+type PurgeStreamArgs struct {
+	// Sequence purges all messages before, but not including, this stream sequence.
+	// It cannot be combined with Keep. Default is 0, which means all messages.
+	Sequence uint64
+
+	// Keep is the number of the latest messages, which are kept. It cannot be combined with Sequence.
+	// Default is 0, which means no messages are kept.
+	Keep uint64
+
+	// Subject limits the purge to messages with this subject, like "ORDERS.created" or "ORDERS.*.created".
+	// Sequence and Keep apply per subject then. Default is empty, which means all subjects.
+	Subject string
+}
+
+// PurgeStream removes the messages selected by args from the stream. With empty args all messages are removed.
+func (c *Connection) PurgeStream(streamName string, args PurgeStreamArgs) error {
+	if args.Sequence > 0 && args.Keep > 0 {
+		return fmt.Errorf("stream %s could not be purged: Sequence and Keep cannot be combined", streamName)
+	}
+
+	if err := c.nats.PurgeStream(streamName, &nats.StreamPurgeRequest{
+		Sequence: args.Sequence,
+		Keep:     args.Keep,
+		Subject:  args.Subject,
+	}); err != nil {
+		return fmt.Errorf("stream %s could not be purged: %w", streamName, err)
+	}
+	c.logger.Info("Purged stream", slog.String("name", streamName), slog.Uint64("sequence", args.Sequence),
+		slog.Uint64("keep", args.Keep), slog.String("subject", args.Subject))
+	return nil
+}
@@ -0,0 +1,76 @@
+package vnats
+
+import (
+	"testing"
+)
+
+func TestConnection_PurgeStream(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	tests := []struct {
+		name         string
+		args         PurgeStreamArgs
+		wantMsgs     uint64
+		wantFirstSeq uint64
+		wantErr      bool
+	}{
+		{name: "Purge all", args: PurgeStreamArgs{}, wantMsgs: 0, wantFirstSeq: 7},
+		{name: "Purge up to sequence", args: PurgeStreamArgs{Sequence: 3}, wantMsgs: 4, wantFirstSeq: 3},
+		{name: "Keep last messages", args: PurgeStreamArgs{Keep: 2}, wantMsgs: 2, wantFirstSeq: 5},
+		{name: "Purge subject", args: PurgeStreamArgs{Subject: integrationTestStreamName + ".purge.b"}, wantMsgs: 4, wantFirstSeq: 1},
+		{name: "Sequence and Keep", args: PurgeStreamArgs{Sequence: 3, Keep: 2}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn := makeIntegrationTestConn(t)
+			publishStringMessages(t, conn, integrationTestStreamName+".purge.a", []string{"1", "2", "3", "4"})
+			publishSubjectMessages(t, conn, integrationTestStreamName+".purge.b", 2)
+
+			err := conn.PurgeStream(integrationTestStreamName, tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("PurgeStream() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr {
+				info, err := conn.nats.(*natsBridge).jetStreamContext.StreamInfo(integrationTestStreamName)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if info.State.Msgs != tt.wantMsgs || info.State.FirstSeq != tt.wantFirstSeq {
+					t.Errorf("Got %d messages starting at %d, expected %d starting at %d",
+						info.State.Msgs, info.State.FirstSeq, tt.wantMsgs, tt.wantFirstSeq)
+				}
+			}
+			if err := conn.Close(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestConnection_PurgeStream_KeepsConsumers(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	subject := integrationTestStreamName + ".purgeConsumer"
+	conn := makeIntegrationTestConn(t)
+	publishStringMessages(t, conn, subject, []string{"1", "2", "3"})
+	sub, err := conn.NewSubscriber(SubscriberArgs{ConsumerName: "TestConnectionPurgeStreamKeepsConsumers", Subject: subject})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := conn.PurgeStream(integrationTestStreamName, PurgeStreamArgs{}); err != nil {
+		t.Fatal(err)
+	}
+	backlog, err := sub.Backlog()
+	if err != nil {
+		t.Fatalf("Consumer was not kept: %v", err)
+	}
+	if backlog.Pending != 0 {
+		t.Errorf("Consumer has %d pending messages after purge, expected 0", backlog.Pending)
+	}
+	if err := conn.Close(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,33 @@
+package vnats
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/nats-io/nats.go"
+)
+
+// validatePush rejects the arguments, which apply only to pull requests.
+func validatePush(args SubscriberArgs) error {
+	if args.MaxRequestBatch > 0 || args.MaxRequestMaxBytes > 0 || args.FetchBatchSize > 1 {
+		return fmt.Errorf("push consumer receives messages one by one, so pull request limits cannot be set")
+	}
+	return nil
+}
+
+// fetch returns the next batch of messages. The messages of a push consumer are received one by one.
+// The server enforces the MaxRequestMaxBytes of the consumer only for pull requests, which set their max bytes.
+func (s *Subscriber) fetch(ctx context.Context, batch int) ([]*nats.Msg, error) {
+	if !s.push {
+		opts := []nats.PullOpt{nats.Context(ctx)}
+		if s.fetchBytes > 0 {
+			opts = append(opts, nats.PullMaxBytes(s.fetchBytes))
+		}
+		return s.subscription.Fetch(batch, opts...)
+	}
+	natsMsg, err := s.subscription.NextMsgWithContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return []*nats.Msg{natsMsg}, nil
+}
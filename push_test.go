@@ -0,0 +1,102 @@
+package vnats
+
+import (
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+func Test_validatePush(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    SubscriberArgs
+		wantErr bool
+	}{
+		{name: "Defaults", args: SubscriberArgs{}, wantErr: false},
+		{name: "Single fetch", args: SubscriberArgs{FetchBatchSize: 1}, wantErr: false},
+		{name: "Batch", args: SubscriberArgs{FetchBatchSize: 10}, wantErr: true},
+		{name: "MaxRequestBatch", args: SubscriberArgs{MaxRequestBatch: 10}, wantErr: true},
+		{name: "MaxRequestMaxBytes", args: SubscriberArgs{MaxRequestMaxBytes: 1024}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validatePush(tt.args); (err != nil) != tt.wantErr {
+				t.Errorf("validatePush() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSubscriber_PushQueueGroup(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	subject := integrationTestStreamName + ".pushQueueGroup"
+	const messageCount = 60
+	const memberCount = 3
+	conn := makeIntegrationTestConn(t)
+
+	var mu sync.Mutex
+	handledBy := map[string][]int{}
+	total := 0
+	for member := 0; member < memberCount; member++ {
+		// Every member has its own connection like a separate process.
+		memberConn, err := Connect([]string{os.Getenv("NATS_SERVER_URL")})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer memberConn.Close()
+
+		sub, err := memberConn.NewSubscriber(SubscriberArgs{QueueGroup: "TestSubscriberPushQueueGroup", Subject: subject, Push: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := sub.Start(func(msg Msg) error {
+			mu.Lock()
+			defer mu.Unlock()
+			handledBy[string(msg.Data)] = append(handledBy[string(msg.Data)], member)
+			total++
+			return nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Publish after all members joined, so that the server pushes the messages to the whole group.
+	publishManyMessages(t, conn, subject, messageCount)
+
+	deadline := time.Now().Add(time.Second * 10)
+	for time.Now().Before(deadline) {
+		mu.Lock()
+		done := total >= messageCount
+		mu.Unlock()
+		if done {
+			break
+		}
+		time.Sleep(time.Millisecond * 50)
+	}
+	time.Sleep(time.Millisecond * 200) // Duplicate deliveries would arrive meanwhile
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(handledBy) != messageCount || total != messageCount {
+		t.Errorf("Got %d distinct of %d handled messages, expected %d", len(handledBy), total, messageCount)
+	}
+	perMember := make([]int, memberCount)
+	for data, members := range handledBy {
+		if len(members) != 1 {
+			t.Errorf("Message %s was handled by members %v, expected exactly one", data, members)
+		}
+		for _, member := range members {
+			perMember[member]++
+		}
+	}
+	for member, count := range perMember {
+		if count == 0 {
+			t.Errorf("Member %d handled no message, load was not spread: %v", member, perMember)
+		}
+	}
+	if err := conn.Close(); err != nil {
+		t.Error(err)
+	}
+}
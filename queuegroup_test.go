@@ -0,0 +1,90 @@
+package vnats
+
+import (
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSubscriber_QueueGroup(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	subject := integrationTestStreamName + ".queueGroup"
+	const messageCount = 30
+	conn := makeIntegrationTestConn(t)
+	publishManyMessages(t, conn, subject, messageCount)
+
+	var mu sync.Mutex
+	handledBy := map[string][]int{}
+	total := 0
+	for member := 0; member < 3; member++ {
+		// Every member has its own connection like a separate process.
+		memberConn, err := Connect([]string{os.Getenv("NATS_SERVER_URL")})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer memberConn.Close()
+
+		sub, err := memberConn.NewSubscriber(SubscriberArgs{QueueGroup: "TestSubscriberQueueGroup", Subject: subject})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := sub.Start(func(msg Msg) error {
+			mu.Lock()
+			defer mu.Unlock()
+			handledBy[string(msg.Data)] = append(handledBy[string(msg.Data)], member)
+			total++
+			return nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	deadline := time.Now().Add(time.Second * 10)
+	for time.Now().Before(deadline) {
+		mu.Lock()
+		done := total >= messageCount
+		mu.Unlock()
+		if done {
+			break
+		}
+		time.Sleep(time.Millisecond * 50)
+	}
+	time.Sleep(time.Millisecond * 200) // Duplicate deliveries would arrive meanwhile
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(handledBy) != messageCount || total != messageCount {
+		t.Errorf("Got %d distinct of %d handled messages, expected %d", len(handledBy), total, messageCount)
+	}
+	for data, members := range handledBy {
+		if len(members) != 1 {
+			t.Errorf("Message %s was handled by members %v, expected exactly one", data, members)
+		}
+	}
+	if err := conn.Close(); err != nil {
+		t.Error(err)
+	}
+}
+
+func Test_validateQueueGroup(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    SubscriberArgs
+		wantErr bool
+	}{
+		{name: "Queue group", args: SubscriberArgs{QueueGroup: "billing"}, wantErr: false},
+		{name: "Equal consumer name", args: SubscriberArgs{QueueGroup: "billing", ConsumerName: "billing"}, wantErr: false},
+		{name: "Different consumer name", args: SubscriberArgs{QueueGroup: "billing", ConsumerName: "shipping"}, wantErr: true},
+		{name: "Strict message order", args: SubscriberArgs{QueueGroup: "billing", Mode: SingleSubscriberStrictMessageOrder}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateQueueGroup(tt.args); (err != nil) != tt.wantErr {
+				t.Errorf("validateQueueGroup() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package vnats
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+// ReceiptOutcome describes how the processing of a message ended.
+type ReceiptOutcome string
+
+const (
+	// ReceiptAcked is the outcome of messages, which were processed successfully.
+	ReceiptAcked ReceiptOutcome = "acked"
+
+	// ReceiptTerminated is the outcome of messages, which were terminated without being processed, e.g.
+	// because they were invalid.
+	ReceiptTerminated ReceiptOutcome = "terminated"
+)
+
+// Receipt is published as JSON to the ReceiptSubject of the SubscriberArgs for every processed message.
+type Receipt struct {
+	MsgID       string         `json:"msgId"`
+	Consumer    string         `json:"consumer"`
+	Stream      string         `json:"stream"`
+	Sequence    uint64         `json:"sequence"`
+	Subject     string         `json:"subject"`
+	ProcessedAt time.Time      `json:"processedAt"`
+	Outcome     ReceiptOutcome `json:"outcome"`
+}
+
+// publishReceipt publishes the receipt of the message, if a ReceiptSubject is set. Errors are logged only,
+// since the message is already acknowledged.
+func (s *Subscriber) publishReceipt(natsMsg *nats.Msg, outcome ReceiptOutcome) {
+	if s.receiptSubject == "" {
+		return
+	}
+	if err := s.doPublishReceipt(natsMsg, outcome); err != nil {
+		s.logger.Error("Receipt could not be published", slog.String("subject", s.receiptSubject),
+			slog.String("error", err.Error()))
+	}
+}
+
+func (s *Subscriber) doPublishReceipt(natsMsg *nats.Msg, outcome ReceiptOutcome) error {
+	meta, err := natsMsg.Metadata()
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(Receipt{
+		MsgID:       natsMsg.Header.Get(nats.MsgIdHdr),
+		Consumer:    meta.Consumer,
+		Stream:      meta.Stream,
+		Sequence:    meta.Sequence.Stream,
+		Subject:     natsMsg.Subject,
+		ProcessedAt: time.Now().UTC(),
+		Outcome:     outcome,
+	})
+	if err != nil {
+		return err
+	}
+
+	receipt := &Msg{Subject: s.receiptSubject, Data: data}
+	msgID := fmt.Sprintf("%s-%s-%d-%s", meta.Stream, meta.Consumer, meta.Sequence.Stream, outcome)
+	return s.conn.nats.PublishMsg(context.Background(), receipt.toNATS(EncJSON), msgID)
+}
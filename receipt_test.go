@@ -0,0 +1,45 @@
+package vnats
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+func TestSubscriber_ReceiptSubject(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	subject := integrationTestStreamName + ".receipt.orders"
+	receiptSubject := integrationTestStreamName + ".receipt.receipts"
+	conn := makeIntegrationTestConn(t)
+	publishStringMessages(t, conn, subject, []string{"hello"})
+	sub, err := conn.NewSubscriber(SubscriberArgs{
+		ConsumerName:   "TestSubscriberReceiptSubject",
+		Subject:        subject,
+		ReceiptSubject: receiptSubject,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sub.handler = func(_ context.Context, _ Msg) error { return nil }
+	sub.processMessages(context.Background(), 1, defaultFetchWait)
+
+	msg, err := conn.GetMsg(integrationTestStreamName, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var receipt Receipt
+	if err := msg.Decode(&receipt); err != nil {
+		t.Fatal(err)
+	}
+	if msg.Subject != receiptSubject || receipt.MsgID != "msg-0" || receipt.Consumer != "TestSubscriberReceiptSubject" ||
+		receipt.Stream != integrationTestStreamName || receipt.Sequence != 1 || receipt.Subject != subject ||
+		receipt.Outcome != ReceiptAcked || receipt.ProcessedAt.IsZero() {
+		data, _ := json.Marshal(receipt)
+		t.Errorf("Unexpected receipt on %s: %s", msg.Subject, data)
+	}
+	if err := conn.Close(); err != nil {
+		t.Error(err)
+	}
+}
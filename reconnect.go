@@ -0,0 +1,36 @@
+package vnats
+
+import (
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+// WithMaxReconnects sets the number of attempts to reconnect after the connection was lost, before it is
+// closed. A negative number means unlimited attempts.
+// This option can be passed in the Connect function.
+// Without this option, the NATS default of 60 attempts is used.
+func WithMaxReconnects(attempts int) Option {
+	return withNATSOptions(nats.MaxReconnects(attempts))
+}
+
+// WithReconnectWait sets the duration to wait between the attempts to reconnect to the same server.
+// This option can be passed in the Connect function.
+// Without this option, the NATS default of 2 seconds is used.
+func WithReconnectWait(wait time.Duration) Option {
+	return withNATSOptions(nats.ReconnectWait(wait))
+}
+
+// WithConnectTimeout sets the timeout of dialing a server, when connecting and reconnecting.
+// This option can be passed in the Connect function.
+// Without this option, the NATS default of 2 seconds is used.
+func WithConnectTimeout(timeout time.Duration) Option {
+	return withNATSOptions(nats.Timeout(timeout))
+}
+
+// WithPingInterval sets the interval of the pings to the server, which detect a stale connection.
+// This option can be passed in the Connect function.
+// Without this option, the NATS default of 2 minutes is used.
+func WithPingInterval(interval time.Duration) Option {
+	return withNATSOptions(nats.PingInterval(interval))
+}
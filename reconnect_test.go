@@ -0,0 +1,35 @@
+package vnats
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+func TestReconnectOptions(t *testing.T) {
+	defaults := natsOptionsOf(t)
+	if defaults.MaxReconnect != nats.DefaultMaxReconnect || defaults.ReconnectWait != nats.DefaultReconnectWait ||
+		defaults.Timeout != nats.DefaultTimeout || defaults.PingInterval != nats.DefaultPingInterval {
+		t.Error("Without options the NATS defaults are not kept")
+	}
+
+	got := natsOptionsOf(t,
+		WithMaxReconnects(-1),
+		WithReconnectWait(time.Millisecond*500),
+		WithConnectTimeout(time.Second*10),
+		WithPingInterval(time.Second*20),
+	)
+	if got.MaxReconnect != -1 {
+		t.Errorf("MaxReconnect = %d, want -1", got.MaxReconnect)
+	}
+	if got.ReconnectWait != time.Millisecond*500 {
+		t.Errorf("ReconnectWait = %v, want 500ms", got.ReconnectWait)
+	}
+	if got.Timeout != time.Second*10 {
+		t.Errorf("Timeout = %v, want 10s", got.Timeout)
+	}
+	if got.PingInterval != time.Second*20 {
+		t.Errorf("PingInterval = %v, want 20s", got.PingInterval)
+	}
+}
@@ -0,0 +1,135 @@
+package vnats
+
+import (
+	"sync"
+	"time"
+)
+
+// RedeliveryAlarm configures the Subscriber to call OnAlarm, when many messages are redelivered in a
+// short time, e.g. because of a downstream outage.
+type RedeliveryAlarm struct {
+	// Threshold is the number of redelivered messages within the Window, which fires the alarm.
+	Threshold int
+
+	// Window is the duration over which the redelivered messages are counted.
+	Window time.Duration
+
+	// OnAlarm is called with the number of redelivered messages in the window, when the Threshold is reached.
+	// It is called again only after the number dropped below the Threshold in the meantime.
+	OnAlarm func(redelivered int, window time.Duration)
+}
+
+type redeliveryMonitor struct {
+	config      RedeliveryAlarm
+	mu          sync.Mutex
+	redelivered []time.Time
+	alarmed     bool
+	now         func() time.Time
+}
+
+func newRedeliveryMonitor(config *RedeliveryAlarm) *redeliveryMonitor {
+	if config == nil || config.Threshold < 1 || config.Window <= 0 || config.OnAlarm == nil {
+		return nil
+	}
+	return &redeliveryMonitor{
+		config: *config,
+		now:    time.Now,
+	}
+}
+
+// observe records a delivered message with the given delivery count.
+func (m *redeliveryMonitor) observe(numDelivered uint64) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	now := m.now()
+	m.expire(now)
+	if numDelivered > 1 {
+		m.redelivered = append(m.redelivered, now)
+	}
+
+	count := len(m.redelivered)
+	fire := false
+	if count >= m.config.Threshold {
+		fire = !m.alarmed
+		m.alarmed = true
+	} else {
+		m.alarmed = false
+	}
+	m.mu.Unlock()
+
+	if fire {
+		m.config.OnAlarm(count, m.config.Window)
+	}
+}
+
+func (m *redeliveryMonitor) expire(now time.Time) {
+	cutoff := now.Add(-m.config.Window)
+	idx := 0
+	for idx < len(m.redelivered) && !m.redelivered[idx].After(cutoff) {
+		idx++
+	}
+	m.redelivered = m.redelivered[idx:]
+}
+
+const redeliveryRatioBuckets = 10
+
+// redeliveryRatio counts delivered and redelivered messages in buckets, which together cover the window.
+type redeliveryRatio struct {
+	bucketWidth time.Duration
+	mu          sync.Mutex
+	buckets     [redeliveryRatioBuckets]deliveryBucket
+	now         func() time.Time
+}
+
+type deliveryBucket struct {
+	start       int64
+	delivered   uint64
+	redelivered uint64
+}
+
+func newRedeliveryRatio(window time.Duration) *redeliveryRatio {
+	if window <= 0 {
+		window = defaultRedeliveryRatioWindow
+	}
+	return &redeliveryRatio{
+		bucketWidth: window / redeliveryRatioBuckets,
+		now:         time.Now,
+	}
+}
+
+// observe records a delivered message with the given delivery count.
+func (r *redeliveryRatio) observe(numDelivered uint64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	start := r.now().UnixNano() / int64(r.bucketWidth)
+	bucket := &r.buckets[start%redeliveryRatioBuckets]
+	if bucket.start != start {
+		*bucket = deliveryBucket{start: start}
+	}
+	bucket.delivered++
+	if numDelivered > 1 {
+		bucket.redelivered++
+	}
+}
+
+// ratio returns the ratio of redelivered to delivered messages within the window, or 0 without deliveries.
+func (r *redeliveryRatio) ratio() float64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	oldest := r.now().UnixNano()/int64(r.bucketWidth) - redeliveryRatioBuckets
+	var delivered, redelivered uint64
+	for _, bucket := range r.buckets {
+		if bucket.start > oldest {
+			delivered += bucket.delivered
+			redelivered += bucket.redelivered
+		}
+	}
+	if delivered == 0 {
+		return 0
+	}
+	return float64(redelivered) / float64(delivered)
+}
@@ -0,0 +1,80 @@
+package vnats
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func Test_redeliveryMonitor(t *testing.T) {
+	var alarms []int
+	now := time.Now()
+
+	m := newRedeliveryMonitor(&RedeliveryAlarm{
+		Threshold: 3,
+		Window:    time.Minute,
+		OnAlarm:   func(redelivered int, _ time.Duration) { alarms = append(alarms, redelivered) },
+	})
+	m.now = func() time.Time { return now }
+
+	m.observe(1)
+	m.observe(2)
+	m.observe(3)
+	if len(alarms) != 0 {
+		t.Fatalf("alarm fired before threshold was reached: %v", alarms)
+	}
+
+	m.observe(2)
+	m.observe(5)
+	if len(alarms) != 1 || alarms[0] != 3 {
+		t.Fatalf("alarm should fire once when threshold is reached: %v", alarms)
+	}
+
+	now = now.Add(time.Minute)
+	m.observe(1)
+	m.observe(2)
+	m.observe(2)
+	m.observe(2)
+	if len(alarms) != 2 || alarms[1] != 3 {
+		t.Errorf("alarm should fire again after the window expired: %v", alarms)
+	}
+}
+
+func Test_redeliveryRatio(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	ratio := newRedeliveryRatio(time.Minute)
+	ratio.now = func() time.Time { return now }
+
+	if got := ratio.ratio(); got != 0 {
+		t.Errorf("ratio() without deliveries = %v, want 0", got)
+	}
+	for _, numDelivered := range []uint64{1, 1, 2, 3} {
+		ratio.observe(numDelivered)
+	}
+	if got := ratio.ratio(); got != 0.5 {
+		t.Errorf("ratio() = %v, want 0.5", got)
+	}
+
+	now = now.Add(time.Second * 30)
+	for _, numDelivered := range []uint64{1, 1, 1, 1} {
+		ratio.observe(numDelivered)
+	}
+	if got := ratio.ratio(); got != 0.25 {
+		t.Errorf("ratio() = %v, want 0.25", got)
+	}
+
+	now = now.Add(time.Second * 40)
+	if got := ratio.ratio(); got != 0 {
+		t.Errorf("ratio() after the redeliveries left the window = %v, want 0", got)
+	}
+}
+
+func TestSubscriber_Stats_RedeliveryRatio(t *testing.T) {
+	sub := makeTestSubscriber(SubscriberArgs{}, func(_ context.Context, _ Msg) error { return nil })
+	for _, numDelivered := range []uint64{1, 2, 1, 1} {
+		sub.handleMsg(context.Background(), makeTestJSMsg(integrationTestStreamName+".ratio", []byte("hello"), numDelivered))
+	}
+	if got := sub.Stats().RedeliveryRatio; got != 0.25 {
+		t.Errorf("Stats().RedeliveryRatio = %v, want 0.25", got)
+	}
+}
@@ -0,0 +1,64 @@
+package vnats
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// replayPacer delays messages, so that they are handled with the intervals of their original timestamps,
+// scaled by the speed. A nil replayPacer does not delay.
+type replayPacer struct {
+	speed float64
+	now   func() time.Time
+
+	mu         sync.Mutex
+	started    bool
+	startWall  time.Time
+	startStamp time.Time
+}
+
+func newReplayPacer(speed float64) *replayPacer {
+	if speed <= 0 {
+		return nil
+	}
+	return &replayPacer{speed: speed, now: time.Now}
+}
+
+// delay returns the duration, until the message with the timestamp is due. The first message is due immediately.
+func (p *replayPacer) delay(timestamp time.Time) time.Duration {
+	if p == nil {
+		return 0
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.now()
+	if !p.started {
+		p.started = true
+		p.startWall, p.startStamp = now, timestamp
+		return 0
+	}
+	due := p.startWall.Add(time.Duration(float64(timestamp.Sub(p.startStamp)) / p.speed))
+	return due.Sub(now)
+}
+
+// waitReplay blocks until the message with the timestamp is due. It returns false, if ctx was cancelled or
+// the quit signal was received meanwhile.
+func (s *Subscriber) waitReplay(ctx context.Context, timestamp time.Time) bool {
+	d := s.replay.delay(timestamp)
+	if d <= 0 {
+		return true
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	case <-s.quitSignal:
+		return false
+	}
+}
@@ -0,0 +1,52 @@
+package vnats
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func Test_replayPacer_delay(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		speed float64
+		want  []time.Duration
+	}{
+		{name: "Original speed", speed: 1, want: []time.Duration{0, time.Second * 9, time.Second * 28}},
+		{name: "Twice as fast", speed: 2, want: []time.Duration{0, time.Second * 4, time.Second * 13}},
+		{name: "Half as fast", speed: 0.5, want: []time.Duration{0, time.Second * 19, time.Second * 58}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pacer := newReplayPacer(tt.speed)
+			wall := start.Add(time.Hour)
+			pacer.now = func() time.Time { return wall }
+
+			// Messages were stored 10s and 30s after the first one, and each handling takes 1s.
+			for i, offset := range []time.Duration{0, time.Second * 10, time.Second * 30} {
+				if got := pacer.delay(start.Add(offset)); got != tt.want[i] {
+					t.Errorf("delay() of message %d = %v, want %v", i, got, tt.want[i])
+				}
+				wall = wall.Add(time.Second)
+			}
+		})
+	}
+
+	if got := newReplayPacer(0).delay(start); got != 0 {
+		t.Errorf("delay() without ReplaySpeed = %v, want 0", got)
+	}
+}
+
+func TestSubscriber_waitReplay_Cancelled(t *testing.T) {
+	sub := makeTestSubscriber(SubscriberArgs{ReplaySpeed: 1}, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	now := time.Now()
+	if !sub.waitReplay(ctx, now) {
+		t.Fatal("First message should not wait")
+	}
+	cancel()
+	if sub.waitReplay(ctx, now.Add(time.Hour)) {
+		t.Error("waitReplay() should return false after ctx was cancelled")
+	}
+}
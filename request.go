@@ -0,0 +1,146 @@
+package vnats
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/nats-io/nats.go"
+)
+
+// ReplyErrorHeader is the name of the header, which contains the error of a RequestHandler in the reply.
+const ReplyErrorHeader = "Vnats-Reply-Error"
+
+// ErrRequestFailed is wrapped by the error of Request, if the RequestHandler of the Responder returned an error.
+var ErrRequestFailed = errors.New("request failed")
+
+// Request publishes the request encoded as EncJSON to the subject and waits for the reply, which is decoded
+// into reply according to its Encoding. If ctx has no deadline, Request waits at most defaultRequestTimeout.
+// A nil reply discards the data of the reply.
+// Requests are sent by core NATS instead of JetStream, so they are not stored in a stream and fail with
+// nats.ErrNoResponders, if no Responder listens on the subject.
+func (c *Connection) Request(ctx context.Context, subject string, request, reply any) error {
+	if err := c.checkSubjectAllowed(subject); err != nil {
+		return err
+	}
+	data, err := EncJSON.Marshal(request)
+	if err != nil {
+		return fmt.Errorf("request to %s could not be encoded: %w", subject, err)
+	}
+
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, defaultRequestTimeout)
+		defer cancel()
+	}
+
+	msg := Msg{Subject: subject, Data: data}
+	natsReply, err := c.nats.Request(ctx, msg.toNATS(EncJSON))
+	if err != nil {
+		return fmt.Errorf("request to %s failed: %w", subject, err)
+	}
+
+	if replyErr := natsReply.Header.Get(ReplyErrorHeader); replyErr != "" {
+		return fmt.Errorf("%w: %s: %s", ErrRequestFailed, subject, replyErr)
+	}
+	if reply == nil {
+		return nil
+	}
+	encoding := Encoding(natsReply.Header.Get(ContentTypeHeader))
+	if encoding == "" {
+		encoding = EncJSON
+	}
+	if err := encoding.Unmarshal(natsReply.Data, reply); err != nil {
+		return fmt.Errorf("reply of %s could not be decoded: %w", subject, err)
+	}
+	return nil
+}
+
+// RequestHandler is the type of function to answer a request. The returned reply is encoded with the Encoding
+// of the request, or EncJSON if the request has none. A returned error is sent to the requester, whose
+// Request returns an error wrapping ErrRequestFailed.
+type RequestHandler func(ctx context.Context, msg Msg) (any, error)
+
+// ResponderArgs contains the arguments for creating a Responder.
+type ResponderArgs struct {
+	// Subject of the requests, which may contain the wildcards "*" and ">".
+	Subject string
+
+	// QueueGroup shares the requests between all Responders of the same group, so each request is answered
+	// once. Default is no group, so every Responder answers every request.
+	QueueGroup string
+}
+
+// Responder answers the requests sent to its subject by Connection.Request.
+type Responder struct {
+	subscription *nats.Subscription
+	logger       *slog.Logger
+	subject      string
+	handler      RequestHandler
+}
+
+// NewResponder subscribes the handler to the requests of args.Subject. The handler is called for one request
+// after another, until Unsubscribe is called or the Connection is closed.
+func (c *Connection) NewResponder(args ResponderArgs, handler RequestHandler) (*Responder, error) {
+	if args.Subject == "" {
+		return nil, fmt.Errorf("subject cannot be empty")
+	}
+	if handler == nil {
+		return nil, fmt.Errorf("handler cannot be nil")
+	}
+	if err := c.checkSubjectAllowed(args.Subject); err != nil {
+		return nil, err
+	}
+
+	responder := &Responder{
+		logger:  c.logger,
+		subject: args.Subject,
+		handler: handler,
+	}
+	subscription, err := c.nats.QueueSubscribe(args.Subject, args.QueueGroup, responder.respond)
+	if err != nil {
+		return nil, fmt.Errorf("responder for %s could not be subscribed: %w", args.Subject, err)
+	}
+	responder.subscription = subscription
+	return responder, nil
+}
+
+// Unsubscribe stops the Responder. Requests, which are handled already, are still answered.
+func (r *Responder) Unsubscribe() error {
+	if err := r.subscription.Unsubscribe(); err != nil {
+		return fmt.Errorf("responder for %s could not be unsubscribed: %w", r.subject, err)
+	}
+	return nil
+}
+
+// respond calls the handler with the request and publishes its result to the reply subject of the request.
+func (r *Responder) respond(natsMsg *nats.Msg) {
+	if natsMsg.Reply == "" {
+		r.logger.Warn("Request without reply subject is ignored", slog.String("subject", natsMsg.Subject))
+		return
+	}
+
+	msg := makeMsg(natsMsg)
+	encoding := msg.Encoding
+	if encoding == "" {
+		encoding = EncJSON
+	}
+
+	reply := &nats.Msg{Header: nats.Header{}}
+	value, err := r.handler(context.Background(), msg)
+	if err == nil {
+		reply.Data, err = encoding.Marshal(value)
+	}
+	if err != nil {
+		reply.Data = nil
+		reply.Header.Set(ReplyErrorHeader, err.Error())
+	} else {
+		reply.Header.Set(ContentTypeHeader, string(encoding))
+	}
+
+	if err := natsMsg.RespondMsg(reply); err != nil {
+		r.logger.Error("Reply could not be sent", slog.String("subject", natsMsg.Subject),
+			slog.String("error", err.Error()))
+	}
+}
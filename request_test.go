@@ -0,0 +1,94 @@
+package vnats
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+type testRequest struct {
+	Name string `json:"name"`
+}
+
+func TestConnection_Request(t *testing.T) {
+	conn := makeTestConnection(t, "", 0, nil, "", nil)
+
+	var reply testRequest
+	if err := conn.Request(context.Background(), "GREETINGS.hello", testRequest{Name: "test"}, &reply); err != nil {
+		t.Fatal(err)
+	}
+	if reply.Name != "test" {
+		t.Errorf("Got reply %+v, expected the echoed request", reply)
+	}
+
+	published := conn.nats.(*testBridge).published
+	if len(published) != 1 || published[0].Header.Get(ContentTypeHeader) != string(EncJSON) {
+		t.Errorf("Request should be sent with content type %s", EncJSON)
+	}
+}
+
+func TestConnection_Request_SubjectNotAllowed(t *testing.T) {
+	conn := makeTestConnection(t, "", 0, nil, "", nil)
+	conn.allowedSubjects = []string{"ORDERS.>"}
+
+	err := conn.Request(context.Background(), "GREETINGS.hello", testRequest{}, nil)
+	if !errors.Is(err, ErrSubjectNotAllowed) {
+		t.Errorf("Request() error = %v, want %v", err, ErrSubjectNotAllowed)
+	}
+}
+
+func TestConnection_RequestReply(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	conn := makeIntegrationTestConn(t)
+	subject := "RequestReplyTests.greet"
+
+	responder, err := conn.NewResponder(ResponderArgs{Subject: subject, QueueGroup: "greeters"},
+		func(_ context.Context, msg Msg) (any, error) {
+			var request testRequest
+			if err := msg.Encoding.Unmarshal(msg.Data, &request); err != nil {
+				return nil, err
+			}
+			if request.Name == "" {
+				return nil, errors.New("name is missing")
+			}
+			return testRequest{Name: "Hello " + request.Name}, nil
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	// The requester uses its own connection like a separate process.
+	requesterConn, err := Connect([]string{os.Getenv("NATS_SERVER_URL")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer requesterConn.Close()
+
+	var reply testRequest
+	if err := requesterConn.Request(ctx, subject, testRequest{Name: "vnats"}, &reply); err != nil {
+		t.Fatal(err)
+	}
+	if reply.Name != "Hello vnats" {
+		t.Errorf("Got reply %q, expected %q", reply.Name, "Hello vnats")
+	}
+
+	if err := requesterConn.Request(ctx, subject, testRequest{}, &reply); !errors.Is(err, ErrRequestFailed) {
+		t.Errorf("Request() error = %v, want %v", err, ErrRequestFailed)
+	}
+
+	if err := responder.Unsubscribe(); err != nil {
+		t.Fatal(err)
+	}
+	if err := requesterConn.Request(ctx, subject+".unknown", testRequest{Name: "vnats"}, nil); !errors.Is(err, nats.ErrNoResponders) {
+		t.Errorf("Request() without responder error = %v, want %v", err, nats.ErrNoResponders)
+	}
+}
@@ -0,0 +1,51 @@
+package vnats
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+// startupRetry retries the creation of streams and consumers, which fails transiently when many clients
+// start at the same time and race for the JetStream API. The jitter spreads the retries of the clients.
+type startupRetry struct {
+	attempts int
+	backoff  BackoffStrategy
+	sleep    func(d time.Duration)
+}
+
+var defaultStartupRetry = startupRetry{
+	attempts: 5,
+	backoff:  FullJitterBackoff{Backoff: ExponentialBackoff{Initial: time.Millisecond * 200, Max: time.Second * 3}},
+	sleep:    time.Sleep,
+}
+
+// do calls fn until it succeeds, fails permanently or the attempts are exhausted.
+func (r startupRetry) do(logger *slog.Logger, operation string, fn func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= r.attempts || !isTransientErr(err) {
+			return err
+		}
+
+		delay := r.backoff.Next(attempt)
+		logger.Warn("Transient error, will retry", slog.String("operation", operation),
+			slog.Int("attempt", attempt), slog.Duration("delay", delay), slog.String("error", err.Error()))
+		r.sleep(delay)
+	}
+}
+
+// isTransientErr reports whether the JetStream API may succeed on retry, e.g. while it is overloaded or the
+// meta leader is elected.
+func isTransientErr(err error) bool {
+	if errors.Is(err, nats.ErrTimeout) || errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, nats.ErrNoResponders) || errors.Is(err, nats.ErrJetStreamNotEnabled) {
+		return true
+	}
+	var apiErr *nats.APIError
+	return errors.As(err, &apiErr) && apiErr.Code == http.StatusServiceUnavailable
+}
@@ -0,0 +1,108 @@
+package vnats
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+func Test_startupRetry_do(t *testing.T) {
+	errPermanent := errors.New("permanent")
+	unavailable := &nats.APIError{Code: 503, ErrorCode: 10008, Description: "JetStream system temporarily unavailable"}
+	tests := []struct {
+		name      string
+		errs      []error
+		wantErr   error
+		wantCalls int
+	}{
+		{name: "Success", errs: nil, wantErr: nil, wantCalls: 1},
+		{name: "Transient errors", errs: []error{nats.ErrTimeout, fmt.Errorf("stream info: %w", unavailable)}, wantErr: nil, wantCalls: 3},
+		{name: "Permanent error", errs: []error{errPermanent}, wantErr: errPermanent, wantCalls: 1},
+		{name: "Attempts exhausted", errs: []error{nats.ErrNoResponders, nats.ErrNoResponders, nats.ErrNoResponders}, wantErr: nats.ErrNoResponders, wantCalls: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var delays []time.Duration
+			retry := startupRetry{
+				attempts: 3,
+				backoff:  LinearBackoff{Initial: time.Second, Step: time.Second},
+				sleep:    func(d time.Duration) { delays = append(delays, d) },
+			}
+
+			calls := 0
+			err := retry.do(slog.Default(), "test", func() error {
+				calls++
+				if calls <= len(tt.errs) {
+					return tt.errs[calls-1]
+				}
+				return nil
+			})
+			if !errors.Is(err, tt.wantErr) || (tt.wantErr == nil && err != nil) {
+				t.Errorf("do() error = %v, want %v", err, tt.wantErr)
+			}
+			if calls != tt.wantCalls || len(delays) != tt.wantCalls-1 {
+				t.Errorf("Got %d calls with delays %v, want %d calls", calls, delays, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestConnection_ConcurrentStartup(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	const streamName = "IntegrationTestsStartup"
+	cleanup := makeIntegrationTestConn(t)
+	js := cleanup.nats.(*natsBridge).jetStreamContext
+	if err := js.DeleteStream(streamName); err != nil && !errors.Is(err, nats.ErrStreamNotFound) {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := js.DeleteStream(streamName); err != nil {
+			t.Error(err)
+		}
+		if err := cleanup.Close(); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	var wg, started sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := 0; i < cap(errs); i++ {
+		wg.Add(1)
+		started.Add(1)
+		go func() {
+			defer wg.Done()
+			conn, err := Connect([]string{os.Getenv("NATS_SERVER_URL")})
+			if err != nil {
+				started.Done()
+				errs <- err
+				return
+			}
+			// Closing deletes the consumer, so close only after all connections subscribed. Otherwise nats.go
+			// may panic on a consumer deleted while it is subscribing.
+			defer conn.Close()
+			defer started.Wait()
+			defer started.Done()
+
+			if _, err := conn.NewPublisher(PublisherArgs{StreamName: streamName}); err != nil {
+				errs <- err
+				return
+			}
+			if _, err := conn.NewSubscriber(SubscriberArgs{ConsumerName: "TestConcurrentStartup", Subject: streamName + ".>"}); err != nil {
+				errs <- err
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Errorf("Concurrent startup failed: %v", err)
+	}
+}
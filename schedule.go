@@ -0,0 +1,38 @@
+package vnats
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+// DeliverAtHeader contains the time a message published with Msg.DeliverAfter becomes visible to the handler
+// of the Subscriber, formatted as RFC 3339 with nanoseconds.
+const DeliverAtHeader = "Vnats-Deliver-At"
+
+// deferScheduled NAKs the message until its DeliverAtHeader, if it is scheduled in the future. Messages of an
+// OrderedConsumer cannot be redelivered, so it waits until then instead.
+// It returns true, if the message was deferred and must not be handled now.
+func (s *Subscriber) deferScheduled(ctx context.Context, natsMsg *nats.Msg) bool {
+	value := natsMsg.Header.Get(DeliverAtHeader)
+	if value == "" {
+		return false
+	}
+	deliverAt, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		s.logger.Warn("Invalid deliver at header, handle message now", slog.String("value", value),
+			slog.String("error", err.Error()))
+		return false
+	}
+	delay := time.Until(deliverAt)
+	if delay <= 0 {
+		return false
+	}
+	if s.ordered {
+		return !s.pause(ctx, delay)
+	}
+	s.nak(natsMsg, delay)
+	return true
+}
@@ -0,0 +1,57 @@
+package vnats
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSubscriber_DeliverAfter(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	subject := integrationTestStreamName + ".deliverAfter"
+	conn := makeIntegrationTestConn(t)
+	pub, err := conn.NewPublisher(PublisherArgs{StreamName: integrationTestStreamName})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sub := createSubscriber(t, conn, "TestSubscriberDeliverAfter", subject, MultipleSubscribersAllowed)
+
+	published := time.Now()
+	if err := pub.Publish(&Msg{Subject: subject, MsgID: "scheduled", Data: []byte("later"), DeliverAfter: time.Millisecond * 300}); err != nil {
+		t.Fatal(err)
+	}
+	if err := pub.Publish(&Msg{Subject: subject, MsgID: "immediate", Data: []byte("now")}); err != nil {
+		t.Fatal(err)
+	}
+
+	received := make(chan string, 2)
+	handled := make(map[string]time.Duration)
+	if err := sub.StartWithContext(context.Background(), func(_ context.Context, msg Msg) error {
+		handled[string(msg.Data)] = time.Since(published)
+		received <- string(msg.Data)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	var order []string
+	for len(order) < 2 {
+		select {
+		case data := <-received:
+			order = append(order, data)
+		case <-time.After(time.Second * 3):
+			t.Fatalf("Got messages %v, expected both", order)
+		}
+	}
+	if order[0] != "now" || order[1] != "later" {
+		t.Errorf("Got messages in order %v, expected the scheduled message last", order)
+	}
+	if handled["later"] < time.Millisecond*300 {
+		t.Errorf("Scheduled message was handled after %v, expected at least 300ms", handled["later"])
+	}
+	if err := conn.Close(); err != nil {
+		t.Error(err)
+	}
+}
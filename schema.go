@@ -0,0 +1,20 @@
+package vnats
+
+// SchemaValidator validates the payload of a message against a schema, e.g. a JSON Schema.
+// vnats does not depend on a schema library; wrap the compiled schema of the library of your choice.
+//
+// Example for github.com/santhosh-tekuri/jsonschema:
+//
+//	type jsonSchema struct{ schema *jsonschema.Schema }
+//
+//	func (s jsonSchema) Validate(data []byte) error {
+//		var v any
+//		if err := json.Unmarshal(data, &v); err != nil {
+//			return err
+//		}
+//		return s.schema.Validate(v)
+//	}
+type SchemaValidator interface {
+	// Validate returns an error, if the data does not match the schema.
+	Validate(data []byte) error
+}
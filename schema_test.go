@@ -0,0 +1,56 @@
+package vnats
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+)
+
+type requiredFieldSchema struct {
+	field string
+}
+
+func (s requiredFieldSchema) Validate(data []byte) error {
+	var fields map[string]any
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	if _, ok := fields[s.field]; !ok {
+		return fmt.Errorf("field %s is required", s.field)
+	}
+	return nil
+}
+
+func TestSubscriber_Schema(t *testing.T) {
+	tests := []struct {
+		name        string
+		data        string
+		wantHandled bool
+	}{
+		{name: "Valid message is handled", data: `{"id":"42"}`, wantHandled: true},
+		{name: "Message without required field is not handled", data: `{"name":"test"}`, wantHandled: false},
+		{name: "Invalid JSON is not handled", data: `not json`, wantHandled: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handled, invalid := false, false
+			sub := makeTestSubscriber(SubscriberArgs{
+				Schema:       requiredFieldSchema{field: "id"},
+				OnInvalidMsg: func(_ Msg, _ error) { invalid = true },
+			}, func(_ context.Context, _ Msg) error {
+				handled = true
+				return nil
+			})
+
+			sub.handleMsg(context.Background(), makeTestJSMsg(integrationTestStreamName+".schema", []byte(tt.data), 1))
+
+			if handled != tt.wantHandled {
+				t.Errorf("Handler called = %v, want %v", handled, tt.wantHandled)
+			}
+			if invalid == tt.wantHandled {
+				t.Errorf("OnInvalidMsg called = %v, want %v", invalid, !tt.wantHandled)
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package vnats
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// HandleSignals blocks until SIGINT or SIGTERM is received or ctx is cancelled, then closes the Connection.
+// Closing drains all subscriptions and publishers, but returns an error if it takes longer than the drain
+// timeout, see WithDrainTimeout.
+// The signal handling is removed before HandleSignals returns.
+func (c *Connection) HandleSignals(ctx context.Context) error {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	return c.closeOnSignal(ctx, signals, c.drainTimeoutOrDefault())
+}
+
+func (c *Connection) closeOnSignal(ctx context.Context, signals <-chan os.Signal, timeout time.Duration) error {
+	select {
+	case sig := <-signals:
+		c.logger.Info("Received signal, closing NATS Connection.", slog.String("signal", sig.String()))
+	case <-ctx.Done():
+		c.logger.Info("Context cancelled, closing NATS Connection.")
+	}
+
+	closed := make(chan error, 1)
+	go func() {
+		closed <- c.Close()
+	}()
+
+	select {
+	case err := <-closed:
+		return err
+	case <-time.After(timeout):
+		return fmt.Errorf("NATS Connection could not be closed within %v", timeout)
+	}
+}
@@ -0,0 +1,47 @@
+package vnats
+
+import (
+	"context"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestConnection_closeOnSignal(t *testing.T) {
+	tests := []struct {
+		name   string
+		signal bool
+	}{
+		{name: "Close on signal", signal: true},
+		{name: "Close on context cancellation", signal: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn := makeTestConnection(t, "PRODUCTS", 1, nil, "", nil)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			signals := make(chan os.Signal, 1)
+			result := make(chan error)
+			go func() {
+				result <- conn.closeOnSignal(ctx, signals, time.Second)
+			}()
+
+			if tt.signal {
+				signals <- syscall.SIGTERM
+			} else {
+				cancel()
+			}
+
+			select {
+			case err := <-result:
+				if err != nil {
+					t.Errorf("closeOnSignal() error = %v", err)
+				}
+			case <-time.After(time.Second * 2):
+				t.Error("closeOnSignal() did not return")
+			}
+		})
+	}
+}
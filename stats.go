@@ -0,0 +1,79 @@
+package vnats
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// SubscriberStats is a snapshot of the statistics of a Subscriber since it was created.
+type SubscriberStats struct {
+	// Received is the number of messages received from the server.
+	Received uint64
+
+	// Processed is the number of received messages, whose processing is completed.
+	Processed uint64
+
+	// HandlerErrors is the number of messages, whose handler returned an error.
+	HandlerErrors uint64
+
+	// Acks, Naks and Terms are the number of messages successfully acknowledged with the respective type.
+	Acks  uint64
+	Naks  uint64
+	Terms uint64
+
+	// AvgHandlerLatency is the average duration of the handler calls.
+	AvgHandlerLatency time.Duration
+
+	// InFlight is the number of messages currently being processed.
+	InFlight int64
+
+	// RedeliveryRatio is the ratio of redelivered to delivered messages within the RedeliveryRatioWindow
+	// of the SubscriberArgs, based on the delivery count of each message.
+	RedeliveryRatio float64
+}
+
+type subscriberStats struct {
+	received      atomic.Uint64
+	processed     atomic.Uint64
+	handlerErrors atomic.Uint64
+	acks          atomic.Uint64
+	naks          atomic.Uint64
+	terms         atomic.Uint64
+	handlerCalls  atomic.Uint64
+	handlerTime   atomic.Int64
+	inFlight      atomic.Int64
+}
+
+// begin counts a received message as in flight until the returned function is called.
+func (s *subscriberStats) begin() (done func()) {
+	s.received.Add(1)
+	s.inFlight.Add(1)
+	return func() {
+		s.inFlight.Add(-1)
+		s.processed.Add(1)
+	}
+}
+
+func (s *subscriberStats) observeHandler(latency time.Duration, err error) {
+	s.handlerCalls.Add(1)
+	s.handlerTime.Add(int64(latency))
+	if err != nil {
+		s.handlerErrors.Add(1)
+	}
+}
+
+func (s *subscriberStats) snapshot() SubscriberStats {
+	stats := SubscriberStats{
+		Received:      s.received.Load(),
+		Processed:     s.processed.Load(),
+		HandlerErrors: s.handlerErrors.Load(),
+		Acks:          s.acks.Load(),
+		Naks:          s.naks.Load(),
+		Terms:         s.terms.Load(),
+		InFlight:      s.inFlight.Load(),
+	}
+	if calls := s.handlerCalls.Load(); calls > 0 {
+		stats.AvgHandlerLatency = time.Duration(s.handlerTime.Load() / int64(calls))
+	}
+	return stats
+}
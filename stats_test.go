@@ -0,0 +1,38 @@
+package vnats
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSubscriber_reportProgress(t *testing.T) {
+	reported := make(chan uint64, 10)
+	sub := makeTestSubscriber(SubscriberArgs{
+		ProgressCallback: func(processed uint64) { reported <- processed },
+		ProgressInterval: time.Millisecond * 10,
+	}, func(_ context.Context, _ Msg) error {
+		return nil
+	})
+	for i := 0; i < 2; i++ {
+		sub.handleMsg(context.Background(), makeTestJSMsg(integrationTestStreamName+".progress", []byte("hello"), 1))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		sub.reportProgress(ctx)
+		close(done)
+	}()
+
+	select {
+	case processed := <-reported:
+		if processed != 2 {
+			t.Errorf("Got %d processed messages, expected 2", processed)
+		}
+	case <-time.After(time.Second):
+		t.Error("Progress was not reported")
+	}
+	cancel()
+	<-done
+}
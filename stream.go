@@ -0,0 +1,197 @@
+package vnats
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+// RetentionPolicy defines when the messages of a stream are removed.
+type RetentionPolicy int
+
+const (
+	// RetentionLimits (default) keeps messages until the limits of the StreamConfig are reached.
+	RetentionLimits RetentionPolicy = iota
+
+	// RetentionInterest keeps messages as long as a consumer has not acknowledged them. Messages published
+	// while no consumer exists are removed immediately.
+	RetentionInterest
+
+	// RetentionWorkQueue removes a message as soon as it was acknowledged by a consumer.
+	RetentionWorkQueue
+)
+
+// DiscardPolicy defines what happens, if a limit of the stream is reached.
+type DiscardPolicy int
+
+const (
+	// DiscardOld (default) removes the oldest messages to make room for new ones.
+	DiscardOld DiscardPolicy = iota
+
+	// DiscardNew rejects new messages, so that Publish returns an error.
+	DiscardNew
+)
+
+// StorageType defines where the messages of a stream are stored.
+type StorageType int
+
+const (
+	// FileStorage (default) stores messages on disk, so that they survive restarts of the server.
+	FileStorage StorageType = iota
+
+	// MemoryStorage keeps messages in memory only, which lowers the latency, but loses them on restarts.
+	MemoryStorage
+)
+
+// StreamCompression defines how the messages of a stream are compressed in the storage of the server.
+type StreamCompression int
+
+const (
+	// StreamCompressionNone (default) stores the messages uncompressed.
+	StreamCompressionNone StreamCompression = iota
+
+	// StreamCompressionS2 compresses the stored messages with S2, which saves disk space of high-volume streams
+	// for a little CPU time of the server. It requires nats-server 2.10 or newer.
+	StreamCompressionS2
+)
+
+// StreamConfig contains the subjects, retention, limits, storage, compression, deduplication and replicas of a stream, which is
+// created by a Publisher. The config is applied when the stream is created only. If the stream exists with other
+// values, a warning is logged and the existing config is kept. Only missing Subjects are added to an existing stream.
+type StreamConfig struct {
+	// Subjects are subject patterns stored in the stream in addition to "STREAM_NAME.>", which the stream always
+	// contains, e.g. "ORDERS-DLQ.>" to keep the dead letters in the stream "ORDERS". The subjects must not overlap
+	// with the subjects of other streams. Subjects missing on an existing stream are added; if that fails,
+	// a warning is logged. Default is empty.
+	Subjects []string
+
+	// Retention defines when messages are removed. Default is RetentionLimits.
+	Retention RetentionPolicy
+
+	// MaxAge is the maximum age of a message in the stream. Default is 30 days.
+	MaxAge time.Duration
+
+	// MaxBytes is the maximum size of all messages in the stream. Default is 0, which means no limit.
+	MaxBytes int64
+
+	// MaxMsgs is the maximum number of messages in the stream. Default is 0, which means no limit.
+	MaxMsgs int64
+
+	// Discard defines what happens, if MaxBytes or MaxMsgs is reached. Default is DiscardOld.
+	Discard DiscardPolicy
+
+	// Storage defines where the messages are stored. Default is FileStorage.
+	Storage StorageType
+
+	// Compression defines how the messages are compressed in the storage. Default is StreamCompressionNone.
+	Compression StreamCompression
+
+	// DuplicateWindow is the duration, in which the server drops messages with the MsgID of a message
+	// published before. Publish retries and repeated publishes of the same message are deduplicated as long
+	// as they happen within the window, afterwards the message is stored again. It must not exceed MaxAge.
+	// Default is 30 minutes.
+	DuplicateWindow time.Duration
+
+	// Replicas is the number of copies of the messages in a NATS cluster, at most 5. Default is 1.
+	Replicas int
+
+	// Partitioning lets the server store the messages in partitions by a key token of their subject.
+	// Default is nil, which stores the messages under their published subject. See SubjectPartitioning.
+	Partitioning *SubjectPartitioning
+}
+
+// natsConfig returns the config of the stream with the given name, which contains all subjects of the stream.
+func (c StreamConfig) natsConfig(streamName string) *nats.StreamConfig {
+	cfg := &nats.StreamConfig{
+		Name:       streamName,
+		Subjects:   append([]string{streamName + ".>"}, c.Subjects...),
+		Retention:  nats.LimitsPolicy,
+		Storage:    defaultStorageType,
+		Replicas:   defaultReplicas,
+		Duplicates: defaultDuplicationWindow,
+		MaxAge:     c.MaxAge,
+		MaxBytes:   -1,
+		MaxMsgs:    -1,
+		Discard:    nats.DiscardOld,
+	}
+	switch c.Retention {
+	case RetentionInterest:
+		cfg.Retention = nats.InterestPolicy
+	case RetentionWorkQueue:
+		cfg.Retention = nats.WorkQueuePolicy
+	}
+	if c.Discard == DiscardNew {
+		cfg.Discard = nats.DiscardNew
+	}
+	if c.Storage == MemoryStorage {
+		cfg.Storage = nats.MemoryStorage
+	}
+	if c.Compression == StreamCompressionS2 {
+		cfg.Compression = nats.S2Compression
+	}
+	if cfg.MaxAge <= 0 {
+		cfg.MaxAge = defaultMaxAge
+	}
+	if c.MaxBytes > 0 {
+		cfg.MaxBytes = c.MaxBytes
+	}
+	if c.MaxMsgs > 0 {
+		cfg.MaxMsgs = c.MaxMsgs
+	}
+	if c.Replicas > 0 {
+		cfg.Replicas = c.Replicas
+	}
+	if c.DuplicateWindow > 0 {
+		cfg.Duplicates = c.DuplicateWindow
+	}
+	if c.Partitioning != nil {
+		cfg.SubjectTransform = c.Partitioning.subjectTransform(streamName)
+	}
+	return cfg
+}
+
+// missingSubjects returns the requested subjects, which are not matched by the subjects of the existing stream.
+func missingSubjects(existing, requested *nats.StreamConfig) []string {
+	var missing []string
+	for _, subject := range requested.Subjects {
+		if !slices.ContainsFunc(existing.Subjects, func(pattern string) bool {
+			return subjectIsSubset(subject, pattern)
+		}) {
+			missing = append(missing, subject)
+		}
+	}
+	return missing
+}
+
+// subjectTransformDestination returns the destination of the subject transform, or "none" without one.
+func subjectTransformDestination(cfg *nats.StreamConfig) string {
+	if cfg.SubjectTransform == nil {
+		return "none"
+	}
+	return cfg.SubjectTransform.Source + " -> " + cfg.SubjectTransform.Destination
+}
+
+// streamConfigConflicts describes the retention, limits, storage, compression, deduplication, replicas and
+// partitioning, in which the existing config of a stream differs
+// from the requested one.
+func streamConfigConflicts(existing, requested *nats.StreamConfig) []string {
+	var conflicts []string
+	add := func(name string, existing, requested any) {
+		if existing != requested {
+			conflicts = append(conflicts, fmt.Sprintf("%s is %v, requested %v", name, existing, requested))
+		}
+	}
+	add("retention", existing.Retention, requested.Retention)
+	add("max age", existing.MaxAge, requested.MaxAge)
+	add("max bytes", existing.MaxBytes, requested.MaxBytes)
+	add("max msgs", existing.MaxMsgs, requested.MaxMsgs)
+	add("discard", existing.Discard, requested.Discard)
+	add("storage", existing.Storage, requested.Storage)
+	add("compression", existing.Compression, requested.Compression)
+	add("replicas", existing.Replicas, requested.Replicas)
+	add("duplicate window", existing.Duplicates, requested.Duplicates)
+	add("subject transform", subjectTransformDestination(existing), subjectTransformDestination(requested))
+	return conflicts
+}
@@ -0,0 +1,249 @@
+package vnats
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+func TestStreamConfig_natsConfig(t *testing.T) {
+	defaults := StreamConfig{}.natsConfig("ORDERS")
+	if defaults.Retention != nats.LimitsPolicy || defaults.MaxAge != defaultMaxAge || defaults.MaxBytes != -1 ||
+		defaults.MaxMsgs != -1 || defaults.Discard != nats.DiscardOld || defaults.Storage != nats.FileStorage || defaults.Replicas != 1 ||
+		defaults.Compression != nats.NoCompression {
+		t.Errorf("Unexpected default config: %+v", defaults)
+	}
+	if len(defaults.Subjects) != 1 || defaults.Subjects[0] != "ORDERS.>" {
+		t.Errorf("Got subjects %v, expected ORDERS.>", defaults.Subjects)
+	}
+
+	cfg := StreamConfig{
+		Retention:   RetentionWorkQueue,
+		MaxAge:      time.Hour,
+		MaxBytes:    1024,
+		MaxMsgs:     10,
+		Discard:     DiscardNew,
+		Compression: StreamCompressionS2,
+	}.natsConfig("ORDERS")
+	if cfg.Retention != nats.WorkQueuePolicy || cfg.MaxAge != time.Hour || cfg.MaxBytes != 1024 ||
+		cfg.MaxMsgs != 10 || cfg.Discard != nats.DiscardNew || cfg.Compression != nats.S2Compression {
+		t.Errorf("Unexpected config: %+v", cfg)
+	}
+}
+
+func Test_streamConfigConflicts(t *testing.T) {
+	existing := StreamConfig{}.natsConfig("ORDERS")
+	if conflicts := streamConfigConflicts(existing, StreamConfig{}.natsConfig("ORDERS")); len(conflicts) != 0 {
+		t.Errorf("Got conflicts %v of equal configs", conflicts)
+	}
+
+	requested := StreamConfig{MaxMsgs: 10, Retention: RetentionInterest}.natsConfig("ORDERS")
+	if conflicts := streamConfigConflicts(existing, requested); len(conflicts) != 2 {
+		t.Errorf("Got conflicts %v, expected retention and max msgs", conflicts)
+	}
+
+	compressed := StreamConfig{Compression: StreamCompressionS2}.natsConfig("ORDERS")
+	if conflicts := streamConfigConflicts(existing, compressed); len(conflicts) != 1 {
+		t.Errorf("Got conflicts %v, expected compression", conflicts)
+	}
+}
+
+func TestConnection_NewPublisher_StreamConfig(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	streamName := "StreamConfigTests"
+	conn := makeIntegrationTestConn(t)
+	js := conn.nats.(*natsBridge).jetStreamContext
+	_ = js.DeleteStream(streamName)
+
+	if _, err := conn.NewPublisher(PublisherArgs{
+		StreamName:   streamName,
+		StreamConfig: StreamConfig{Retention: RetentionWorkQueue, MaxMsgs: 100, Discard: DiscardNew},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	info, err := js.StreamInfo(streamName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Config.Retention != nats.WorkQueuePolicy || info.Config.MaxMsgs != 100 || info.Config.Discard != nats.DiscardNew {
+		t.Errorf("Stream was not created with the requested config: %+v", info.Config)
+	}
+
+	if _, err := conn.NewPublisher(PublisherArgs{StreamName: streamName}); err != nil {
+		t.Errorf("Publisher of existing stream with other config should be created: %v", err)
+	}
+	if err := js.DeleteStream(streamName); err != nil {
+		t.Error(err)
+	}
+
+	if _, err := conn.NewPublisher(PublisherArgs{
+		StreamName:   streamName,
+		StreamConfig: StreamConfig{Storage: MemoryStorage},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if info, err = js.StreamInfo(streamName); err != nil {
+		t.Fatal(err)
+	}
+	if info.Config.Storage != nats.MemoryStorage {
+		t.Errorf("Got storage %v, expected memory storage", info.Config.Storage)
+	}
+	if err := js.DeleteStream(streamName); err != nil {
+		t.Error(err)
+	}
+
+	if _, err := conn.NewPublisher(PublisherArgs{
+		StreamName:   streamName,
+		StreamConfig: StreamConfig{Compression: StreamCompressionS2},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if info, err = js.StreamInfo(streamName); err != nil {
+		t.Fatal(err)
+	}
+	if info.Config.Compression != nats.S2Compression {
+		t.Errorf("Got compression %v, expected S2 compression", info.Config.Compression)
+	}
+	if err := js.DeleteStream(streamName); err != nil {
+		t.Error(err)
+	}
+	if err := conn.Close(); err != nil {
+		t.Error(err)
+	}
+}
+
+func Test_missingSubjects(t *testing.T) {
+	existing := StreamConfig{Subjects: []string{"ORDERS-DLQ.>"}}.natsConfig("ORDERS")
+	requested := StreamConfig{Subjects: []string{"ORDERS-DLQ.eu.*", "ORDERS-ARCHIVE.>"}}.natsConfig("ORDERS")
+	if missing := missingSubjects(existing, requested); len(missing) != 1 || missing[0] != "ORDERS-ARCHIVE.>" {
+		t.Errorf("Got missing subjects %v, expected ORDERS-ARCHIVE.>", missing)
+	}
+	if missing := missingSubjects(existing, existing); len(missing) != 0 {
+		t.Errorf("Got missing subjects %v of equal configs", missing)
+	}
+}
+
+func TestConnection_NewPublisher_Subjects(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	streamName := "SubjectsTests"
+	conn := makeIntegrationTestConn(t)
+	js := conn.nats.(*natsBridge).jetStreamContext
+	_ = js.DeleteStream(streamName)
+
+	if _, err := conn.NewPublisher(PublisherArgs{StreamName: streamName}); err != nil {
+		t.Fatal(err)
+	}
+	pub, err := conn.NewPublisher(PublisherArgs{
+		StreamName:   streamName,
+		StreamConfig: StreamConfig{Subjects: []string{streamName + "-DLQ.>"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := js.StreamInfo(streamName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{streamName + ".>", streamName + "-DLQ.>"}; !reflect.DeepEqual(info.Config.Subjects, want) {
+		t.Errorf("Got subjects %v, expected the missing subject added: %v", info.Config.Subjects, want)
+	}
+	if err := pub.Publish(NewMsg(streamName+"-DLQ.created", "msg-001", []byte("dead letter"))); err != nil {
+		t.Errorf("Publish() to additional subject error = %v", err)
+	}
+	if err := pub.Publish(NewMsg("Unknown.created", "msg-002", []byte("lost"))); err == nil {
+		t.Error("Publish() to subject outside the stream should fail")
+	}
+
+	if _, err := conn.NewPublisher(PublisherArgs{
+		StreamName:   streamName,
+		StreamConfig: StreamConfig{Subjects: []string{streamName + "-DLQ..>"}},
+	}); err == nil {
+		t.Error("NewPublisher() with invalid subject should fail")
+	}
+	if err := js.DeleteStream(streamName); err != nil {
+		t.Error(err)
+	}
+	if err := conn.Close(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestConnection_NewPublisher_Replicas(t *testing.T) {
+	tests := []struct {
+		name     string
+		replicas int
+		want     int
+	}{
+		{name: "Default replicas", replicas: 0, want: 1},
+		{name: "Single replica", replicas: 1, want: 1},
+		{name: "Multiple replicas", replicas: 3, want: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn := makeTestConnection(t, "ORDERS", 0, nil, "", nil)
+			if _, err := conn.NewPublisher(PublisherArgs{
+				StreamName:   "ORDERS",
+				StreamConfig: StreamConfig{Replicas: tt.replicas},
+			}); err != nil {
+				t.Fatal(err)
+			}
+			ensured := conn.nats.(*testBridge).ensured
+			if len(ensured) != 1 || ensured[0].Replicas != tt.want {
+				t.Errorf("Got stream configs %+v, expected %d replicas", ensured, tt.want)
+			}
+		})
+	}
+}
+
+func TestConnection_NewPublisher_DuplicateWindow(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	streamName := "DuplicateWindowTests"
+	conn := makeIntegrationTestConn(t)
+	js := conn.nats.(*natsBridge).jetStreamContext
+	_ = js.DeleteStream(streamName)
+
+	pub, err := conn.NewPublisher(PublisherArgs{
+		StreamName:   streamName,
+		StreamConfig: StreamConfig{DuplicateWindow: time.Millisecond * 500},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	publish := func() {
+		if err := pub.Publish(NewMsg(streamName+".created", "msg-001", []byte("test message"))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wantMsgs := func(want uint64) {
+		info, err := js.StreamInfo(streamName)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.State.Msgs != want {
+			t.Errorf("Got %d messages in stream, expected %d", info.State.Msgs, want)
+		}
+	}
+
+	publish()
+	publish()
+	wantMsgs(1)
+
+	time.Sleep(time.Second)
+	publish()
+	wantMsgs(2)
+
+	if err := js.DeleteStream(streamName); err != nil {
+		t.Error(err)
+	}
+	if err := conn.Close(); err != nil {
+		t.Error(err)
+	}
+}
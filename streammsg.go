@@ -0,0 +1,115 @@
+package vnats
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+// GetMsg returns the message with the given sequence directly from the stream, without a consumer.
+// It is meant for debugging and targeted replays. Messages sealed by a Cipher are decrypted by the Cipher of
+// their key among ciphers, like by SubscriberArgs.Ciphers, and compressed messages are decompressed. Without
+// ciphers, sealed messages are returned as stored, since they are compressed before they are encrypted.
+// If the message does not exist, the error wraps nats.ErrMsgNotFound.
+func (c *Connection) GetMsg(streamName string, seq uint64, ciphers ...Cipher) (Msg, error) {
+	rawMsg, err := c.nats.GetMsg(streamName, seq)
+	if err != nil {
+		return Msg{}, fmt.Errorf("message %d of stream %s could not be fetched: %w", seq, streamName, err)
+	}
+
+	msg := makeMsg(&nats.Msg{
+		Subject: rawMsg.Subject,
+		Data:    rawMsg.Data,
+		Header:  rawMsg.Header,
+	})
+	msg.Stream = streamName
+	msg.Sequence = rawMsg.Sequence
+	msg.Timestamp = rawMsg.Time
+	if len(ciphers) > 0 {
+		if msg, err = openMsg(msg, ciphers); err != nil {
+			return msg, err
+		}
+	} else if msg.Header.Get(CipherKeyHeader) != "" {
+		return msg, nil
+	}
+	return decompressMsg(msg)
+}
+
+// DeleteMsg removes the message with the given sequence from the stream, e.g. for erasure requests.
+// Without secureErase the message is only marked as deleted, with secureErase it is overwritten in the storage.
+// The deletion cannot be undone. Consumers, which have not received the message yet, skip it, while
+// consumers already past the sequence are not affected.
+func (c *Connection) DeleteMsg(streamName string, seq uint64, secureErase bool) error {
+	if err := c.nats.DeleteMsg(streamName, seq, secureErase); err != nil {
+		return fmt.Errorf("message %d of stream %s could not be deleted: %w", seq, streamName, err)
+	}
+	c.logger.Info("Deleted message", slog.String("stream", streamName), slog.Uint64("sequence", seq),
+		slog.Bool("secureErase", secureErase))
+	return nil
+}
+
+// StreamSubjects returns the number of messages per subject of the stream, e.g. to find the subjects driving
+// the storage growth. Subjects without messages are not included.
+func (c *Connection) StreamSubjects(streamName string) (map[string]uint64, error) {
+	subjects, err := c.nats.StreamSubjects(streamName, ">")
+	if err != nil {
+		return nil, fmt.Errorf("subjects of stream %s could not be fetched: %w", streamName, err)
+	}
+	return subjects, nil
+}
+
+// StreamInfo contains the subjects and the state of a stream, e.g. for dashboards.
+type StreamInfo struct {
+	// Name of the stream.
+	Name string
+
+	// Subjects stored in the stream.
+	Subjects []string
+
+	// Msgs is the number of messages in the stream.
+	Msgs uint64
+
+	// Bytes is the size of all messages in the stream.
+	Bytes uint64
+
+	// FirstSequence is the sequence of the oldest message, or 0 if the stream is empty.
+	FirstSequence uint64
+
+	// LastSequence is the sequence of the newest message. It is kept, if the stream is purged.
+	LastSequence uint64
+
+	// FirstTime is the time the oldest message was stored.
+	FirstTime time.Time
+
+	// LastTime is the time the newest message was stored.
+	LastTime time.Time
+
+	// Consumers is the number of consumers of the stream.
+	Consumers int
+
+	// Created is the time the stream was created.
+	Created time.Time
+}
+
+// StreamInfo returns the subjects and the state of the stream. If the stream does not exist, the error wraps
+// nats.ErrStreamNotFound.
+func (c *Connection) StreamInfo(streamName string) (StreamInfo, error) {
+	info, err := c.nats.StreamInfo(streamName)
+	if err != nil {
+		return StreamInfo{}, fmt.Errorf("info of stream %s could not be fetched: %w", streamName, err)
+	}
+	return StreamInfo{
+		Name:          info.Config.Name,
+		Subjects:      info.Config.Subjects,
+		Msgs:          info.State.Msgs,
+		Bytes:         info.State.Bytes,
+		FirstSequence: info.State.FirstSeq,
+		LastSequence:  info.State.LastSeq,
+		FirstTime:     info.State.FirstTime,
+		LastTime:      info.State.LastTime,
+		Consumers:     info.State.Consumers,
+		Created:       info.Created,
+	}, nil
+}
@@ -0,0 +1,181 @@
+package vnats
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/nats-io/nats.go"
+)
+
+func TestConnection_GetMsg(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	subject := integrationTestStreamName + ".getMsg"
+	conn := makeIntegrationTestConn(t)
+	publishStringMessages(t, conn, subject, []string{"first", "second"})
+	sub, err := conn.NewSubscriber(SubscriberArgs{ConsumerName: "TestConnectionGetMsg", Subject: subject})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg, err := conn.GetMsg(integrationTestStreamName, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.Subject != subject || string(msg.Data) != "second" || msg.MsgID != "msg-1" || msg.Stream != integrationTestStreamName ||
+		msg.Sequence != 2 || msg.Timestamp.IsZero() {
+		t.Errorf("Unexpected message: %+v", msg)
+	}
+	if backlog, err := sub.Backlog(); err != nil || backlog.Pending != 2 {
+		t.Errorf("Consumer has backlog %+v (error %v) after GetMsg, expected 2 pending messages", backlog, err)
+	}
+
+	if _, err := conn.GetMsg(integrationTestStreamName, 42); !errors.Is(err, nats.ErrMsgNotFound) {
+		t.Errorf("GetMsg() of missing message returned %v, expected %v", err, nats.ErrMsgNotFound)
+	}
+	if err := conn.Close(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestConnection_GetMsg_CompressedAndSealed(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	subject := integrationTestStreamName + ".getMsgCompressed"
+	conn := makeIntegrationTestConn(t)
+	cipher := xorCipher{keyID: "v1", key: 0x2a}
+	for _, args := range []PublisherArgs{
+		{StreamName: integrationTestStreamName, Compression: CompressionGzip},
+		{StreamName: integrationTestStreamName, Compression: CompressionGzip, Cipher: cipher},
+	} {
+		pub, err := conn.NewPublisher(args)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := pub.Publish(NewMsg(subject, fmt.Sprintf("msg-%v", args.Cipher != nil), []byte("hello"))); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		seq      uint64
+		ciphers  []Cipher
+		wantData bool
+	}{
+		{name: "Compressed", seq: 1, wantData: true},
+		{name: "Compressed and sealed without ciphers", seq: 2, wantData: false},
+		{name: "Compressed and sealed with ciphers", seq: 2, ciphers: []Cipher{cipher}, wantData: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg, err := conn.GetMsg(integrationTestStreamName, tt.seq, tt.ciphers...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := string(msg.Data) == "hello"; got != tt.wantData {
+				t.Errorf("Data = %q, want decoded %v", msg.Data, tt.wantData)
+			}
+			if tt.wantData && msg.Header.Get(ContentEncodingHeader) != "" {
+				t.Errorf("%s of decompressed message = %q, want none", ContentEncodingHeader,
+					msg.Header.Get(ContentEncodingHeader))
+			}
+		})
+	}
+	if err := conn.Close(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestConnection_DeleteMsg(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	subject := integrationTestStreamName + ".deleteMsg"
+	conn := makeIntegrationTestConn(t)
+	publishStringMessages(t, conn, subject, []string{"first", "second", "third"})
+
+	for _, tt := range []struct {
+		name        string
+		seq         uint64
+		secureErase bool
+	}{
+		{name: "Delete", seq: 1, secureErase: false},
+		{name: "Secure erase", seq: 2, secureErase: true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := conn.DeleteMsg(integrationTestStreamName, tt.seq, tt.secureErase); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := conn.GetMsg(integrationTestStreamName, tt.seq); !errors.Is(err, nats.ErrMsgNotFound) {
+				t.Errorf("GetMsg() of deleted message returned %v, expected %v", err, nats.ErrMsgNotFound)
+			}
+		})
+	}
+
+	if _, err := conn.GetMsg(integrationTestStreamName, 3); err != nil {
+		t.Errorf("Other message was deleted: %v", err)
+	}
+	if err := conn.Close(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestConnection_StreamSubjects(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	conn := makeIntegrationTestConn(t)
+	publishStringMessages(t, conn, integrationTestStreamName+".streamSubjects.orders", []string{"1", "2", "3"})
+	publishSubjectMessages(t, conn, integrationTestStreamName+".streamSubjects.invoices", 2)
+
+	subjects, err := conn.StreamSubjects(integrationTestStreamName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]uint64{
+		integrationTestStreamName + ".streamSubjects.orders":   3,
+		integrationTestStreamName + ".streamSubjects.invoices": 2,
+	}
+	if !reflect.DeepEqual(subjects, want) {
+		t.Errorf("StreamSubjects() = %v, want %v", subjects, want)
+	}
+	if err := conn.Close(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestConnection_StreamInfo(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	subject := integrationTestStreamName + ".streamInfo"
+	conn := makeIntegrationTestConn(t)
+	publishManyMessages(t, conn, subject, 3)
+	if _, err := conn.NewSubscriber(SubscriberArgs{ConsumerName: "TestConnectionStreamInfo", Subject: subject}); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := conn.StreamInfo(integrationTestStreamName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Name != integrationTestStreamName || info.Msgs != 3 || info.FirstSequence != 1 || info.LastSequence != 3 ||
+		info.Consumers != 1 {
+		t.Errorf("Got %+v, expected 3 messages with sequences 1 to 3 and 1 consumer", info)
+	}
+	if info.Bytes == 0 || info.FirstTime.IsZero() || info.LastTime.Before(info.FirstTime) {
+		t.Errorf("Got %+v, expected the size and times of the messages", info)
+	}
+
+	if _, err := conn.StreamInfo("StreamInfoUnknown"); !errors.Is(err, nats.ErrStreamNotFound) {
+		t.Errorf("StreamInfo() of unknown stream error = %v, want %v", err, nats.ErrStreamNotFound)
+	}
+	if err := conn.Close(); err != nil {
+		t.Error(err)
+	}
+}
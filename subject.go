@@ -0,0 +1,38 @@
+package vnats
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/nats-io/nats.go"
+)
+
+// ValidateSubject returns an error wrapping nats.ErrBadSubject, if the subject would be rejected or matched
+// unexpectedly by the server: if it is empty, contains empty tokens like "ORDERS..new" or ".ORDERS",
+// whitespace or control characters, or wildcards, which are not whole tokens, like "ORDERS.new*" or
+// "ORDERS.>.created". Publish, NewPublisher and NewSubscriber validate their subjects before any network call,
+// so the function is only needed to check subjects early, e.g. from configuration.
+func ValidateSubject(subject string) error {
+	if subject == "" {
+		return fmt.Errorf("%w: subject must not be empty", nats.ErrBadSubject)
+	}
+	tokens := strings.Split(subject, ".")
+	for i, token := range tokens {
+		switch {
+		case token == "":
+			return fmt.Errorf("%w: subject %q contains an empty token", nats.ErrBadSubject, subject)
+		case strings.IndexFunc(token, invalidSubjectRune) >= 0:
+			return fmt.Errorf("%w: subject token %q contains invalid characters", nats.ErrBadSubject, token)
+		case token == ">" && i < len(tokens)-1:
+			return fmt.Errorf("%w: wildcard > must be the last token of subject %s", nats.ErrBadSubject, subject)
+		case token != "*" && token != ">" && strings.ContainsAny(token, "*>"):
+			return fmt.Errorf("%w: wildcard in subject %s must be a whole token", nats.ErrBadSubject, subject)
+		}
+	}
+	return nil
+}
+
+func invalidSubjectRune(r rune) bool {
+	return unicode.IsSpace(r) || unicode.IsControl(r)
+}
@@ -0,0 +1,43 @@
+package vnats
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/nats-io/nats.go"
+)
+
+func TestValidateSubject(t *testing.T) {
+	tests := []struct {
+		subject string
+		wantErr bool
+	}{
+		{subject: "ORDERS.new", wantErr: false},
+		{subject: "ORDERS.*.created", wantErr: false},
+		{subject: "ORDERS.*.*", wantErr: false},
+		{subject: "ORDERS.>", wantErr: false},
+		{subject: "*.created", wantErr: false},
+		{subject: "ORDERS.eu-west_1", wantErr: false},
+		{subject: "", wantErr: true},
+		{subject: "ORDERS..created", wantErr: true},
+		{subject: "ORDERS.", wantErr: true},
+		{subject: ".ORDERS", wantErr: true},
+		{subject: "ORDERS. new", wantErr: true},
+		{subject: "ORDERS.new\t", wantErr: true},
+		{subject: "ORDERS.new\x00", wantErr: true},
+		{subject: "ORDERS.>.created", wantErr: true},
+		{subject: "ORDERS.new*", wantErr: true},
+		{subject: "ORDERS.>new", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.subject, func(t *testing.T) {
+			err := ValidateSubject(tt.subject)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateSubject() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr && !errors.Is(err, nats.ErrBadSubject) {
+				t.Errorf("ValidateSubject() error = %v, want %v", err, nats.ErrBadSubject)
+			}
+		})
+	}
+}
@@ -1,61 +1,320 @@
 package vnats
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"log/slog"
+	"strconv"
+	"sync"
+	"time"
 
 	"github.com/nats-io/nats.go"
 )
 
 // NewSubscriber creates a new Subscriber that subscribes to a NATS stream.
 func (c *Connection) NewSubscriber(args SubscriberArgs) (*Subscriber, error) {
-	subscription, err := c.nats.Subscribe(args.Subject, args.ConsumerName, args.Mode)
+	if args.Ephemeral {
+		if err := validateEphemeral(args); err != nil {
+			return nil, fmt.Errorf("subscriber could not be created: %w", err)
+		}
+	}
+	if args.Mode == OrderedConsumer {
+		if err := validateOrdered(args); err != nil {
+			return nil, fmt.Errorf("subscriber could not be created: %w", err)
+		}
+	}
+	if args.Push {
+		if err := validatePush(args); err != nil {
+			return nil, fmt.Errorf("subscriber could not be created: %w", err)
+		}
+	}
+	if args.QueueGroup != "" {
+		if err := validateQueueGroup(args); err != nil {
+			return nil, fmt.Errorf("subscriber could not be created: %w", err)
+		}
+		args.ConsumerName = args.QueueGroup
+	}
+	if args.Partitions > 0 {
+		if err := validatePartition(args.Partition, args.Partitions); err != nil {
+			return nil, fmt.Errorf("subscriber could not be created: %w", err)
+		}
+		if args.PartitionedByServer {
+			args.Subject = insertPartitionToken(args.Subject, strconv.Itoa(args.Partition))
+		} else {
+			args.Subject = partitionSubject(args.Subject, args.Partition)
+		}
+	}
+	if err := ValidateSubject(args.Subject); err != nil {
+		return nil, fmt.Errorf("subscriber could not be created: %w", err)
+	}
+	if err := validateDeliverPolicy(args); err != nil {
+		return nil, fmt.Errorf("subscriber could not be created: %w", err)
+	}
+	if args.MaxAckPending < 0 || args.MaxAckPending > 1 && args.Mode == SingleSubscriberStrictMessageOrder {
+		return nil, fmt.Errorf("subscriber could not be created: MaxAckPending %d is invalid for the mode", args.MaxAckPending)
+	}
+	if args.DeadLetterSubject != "" && args.MaxDeliver < 1 {
+		return nil, fmt.Errorf("subscriber could not be created: DeadLetterSubject requires MaxDeliver")
+	}
+	if args.OnSequenceGap != nil && args.Mode != SingleSubscriberStrictMessageOrder {
+		return nil, fmt.Errorf("subscriber could not be created: OnSequenceGap requires SingleSubscriberStrictMessageOrder")
+	}
+	if args.MaxRequestBatch > 0 && args.FetchBatchSize > args.MaxRequestBatch {
+		return nil, fmt.Errorf("subscriber could not be created: FetchBatchSize %d exceeds MaxRequestBatch %d",
+			args.FetchBatchSize, args.MaxRequestBatch)
+	}
+	if err := c.checkSubjectAllowed(args.Subject); err != nil {
+		return nil, fmt.Errorf("subscriber could not be created: %w", err)
+	}
+
+	streamName := args.StreamName
+	if streamName != "" {
+		if err := validateStreamName(streamName); err != nil {
+			return nil, fmt.Errorf("subscriber could not be created: %w", err)
+		}
+	} else if c.streamResolver != nil {
+		streamName = c.streamResolver(args.Subject)
+	}
+
+	subscription, err := c.nats.Subscribe(streamName, args)
 	if err != nil {
 		return nil, fmt.Errorf("subscriber could not be created: %w", err)
 	}
 
+	if args.Ephemeral {
+		info, err := subscription.ConsumerInfo()
+		if err != nil {
+			return nil, fmt.Errorf("subscriber could not be created: ephemeral consumer info could not be fetched: %w", err)
+		}
+		args.ConsumerName = info.Name
+	}
+
+	sub := newSubscriber(c, subscription, args)
+	c.mu.Lock()
+	c.subscribers = append(c.subscribers, sub)
+	c.mu.Unlock()
+	return sub, nil
+}
+
+func validateQueueGroup(args SubscriberArgs) error {
+	if args.ConsumerName != "" && args.ConsumerName != args.QueueGroup {
+		return fmt.Errorf("consumer name %s differs from queue group %s", args.ConsumerName, args.QueueGroup)
+	}
+	if args.Mode != MultipleSubscribersAllowed {
+		return fmt.Errorf("queue group %s requires mode MultipleSubscribersAllowed", args.QueueGroup)
+	}
+	return nil
+}
+
+func validateEphemeral(args SubscriberArgs) error {
+	switch {
+	case args.ConsumerName != "":
+		return fmt.Errorf("ephemeral consumer cannot be named %s", args.ConsumerName)
+	case args.QueueGroup != "":
+		return fmt.Errorf("ephemeral consumer cannot be shared by queue group %s", args.QueueGroup)
+	case args.WatermarkBucket != "":
+		return fmt.Errorf("ephemeral consumer cannot resume from watermark bucket %s", args.WatermarkBucket)
+	}
+	return nil
+}
+
+// validateDeliverPolicy checks that OptStartSeq and OptStartTime are set exactly for the policies using them.
+func validateDeliverPolicy(args SubscriberArgs) error {
+	bySeq := args.DeliverPolicy == nats.DeliverByStartSequencePolicy
+	byTime := args.DeliverPolicy == nats.DeliverByStartTimePolicy
+	switch {
+	case bySeq && args.OptStartSeq == 0:
+		return fmt.Errorf("DeliverByStartSequencePolicy requires OptStartSeq")
+	case !bySeq && args.OptStartSeq > 0:
+		return fmt.Errorf("OptStartSeq %d requires DeliverByStartSequencePolicy", args.OptStartSeq)
+	case byTime && args.OptStartTime.IsZero():
+		return fmt.Errorf("DeliverByStartTimePolicy requires OptStartTime")
+	case !byTime && !args.OptStartTime.IsZero():
+		return fmt.Errorf("OptStartTime %v requires DeliverByStartTimePolicy", args.OptStartTime)
+	case args.DeliverPolicy != nats.DeliverAllPolicy && args.WatermarkBucket != "":
+		return fmt.Errorf("DeliverPolicy cannot be combined with WatermarkBucket %s", args.WatermarkBucket)
+	}
+	return nil
+}
+
+func newSubscriber(c *Connection, subscription *nats.Subscription, args SubscriberArgs) *Subscriber {
 	sub := &Subscriber{
 		conn:         c,
 		subscription: subscription,
 		logger:       c.logger,
 		consumerName: args.ConsumerName,
 		quitSignal:   make(chan bool),
-	}
+		quitFetch:    make(chan struct{}),
+		breaker:      newCircuitBreaker(args.CircuitBreaker),
+		ackPending:   newAckPendingTracker(),
+		cancelAck:    args.CancelAckBehavior,
+		maxDeliver:   args.MaxDeliver,
+		fetchBatch:   max(args.FetchBatchSize, 1),
+		fetchWait:    defaultFetchWait,
+		fetchBytes:   args.MaxRequestMaxBytes,
+		redelivery:   newRedeliveryMonitor(args.RedeliveryAlarm),
 
-	c.subscribers = append(c.subscribers, sub)
-	return sub, nil
+		redeliveryRatio:   newRedeliveryRatio(args.RedeliveryRatioWindow),
+		onRedeliveryRatio: args.OnRedeliveryRatio,
+
+		maxProcessingAge:   args.MaxProcessingAge,
+		onMaxProcessingAge: args.OnMaxProcessingAgeExceeded,
+		schema:             args.Schema,
+		onInvalidMsg:       args.OnInvalidMsg,
+		middlewares:        args.Middlewares,
+		ciphers:            args.Ciphers,
+		onProgress:         args.ProgressCallback,
+		progressInterval:   args.ProgressInterval,
+		gaps:               newGapDetector(args.OnSequenceGap),
+		receiptSubject:     args.ReceiptSubject,
+		deadLetterSubject:  args.DeadLetterSubject,
+		nakBackoff:         args.NakBackoff,
+		correlationID:      args.CorrelationIDExtractor,
+
+		pendingAlarmInterval: args.PendingAlarmInterval,
+		replay:               newReplayPacer(args.ReplaySpeed),
+		watermark:            newWatermarkWriter(args),
+		byteBudget:           args.ByteBudget,
+		inProgressInterval:   inProgressInterval(args),
+		ackWait:              args.AckWait,
+		ordered:              args.Mode == OrderedConsumer,
+		push:                 args.Push || args.Mode == OrderedConsumer,
+	}
+	if sub.ackWait <= 0 {
+		sub.ackWait = args.Mode.defaultAckWait()
+	}
+	sub.firstDeliveries = newFirstDeliveries(args.MaxProcessingAge, sub.ackWait, args.MaxDeliver)
+	if args.FetchTimeout > 0 {
+		sub.fetchWait = args.FetchTimeout
+	}
+	if args.OnPendingAlarm != nil && args.PendingAlarmThreshold > 0 {
+		sub.pendingAlarm = &pendingAlarm{threshold: args.PendingAlarmThreshold, onAlarm: args.OnPendingAlarm}
+	}
+	return sub
 }
 
 // MsgHandler is the type of function the Subscriber has to implement to process an incoming message.
+// If it returns nil, the message is ACKed. If it returns an error wrapping ErrInvalidMsg, the message is
+// terminated and never redelivered, so return it only for messages that will never be processable.
+// Any other error NAKs the message, so that it is redelivered after the delay of the NakBackoff, or of
+// RetryAfter.
 type MsgHandler func(msg Msg) error
 
+// ContextMsgHandler is like MsgHandler, but additionally receives the context passed to StartWithContext.
+type ContextMsgHandler func(ctx context.Context, msg Msg) error
+
+// CancelAckBehavior defines how a message is acknowledged, if the handler returns an error after the
+// context of StartWithContext was cancelled.
+type CancelAckBehavior int
+
+const (
+	// NakOnCancel (default) NAKs the message without delay, so that it is redelivered immediately,
+	// e.g. to another Subscriber of the consumer during a graceful shutdown.
+	NakOnCancel CancelAckBehavior = iota
+
+	// LeaveInFlight neither ACKs nor NAKs the message. It is redelivered after the AckWait expired.
+	LeaveInFlight
+)
+
 // Subscriber subscribes to a NATS consumer and pulls messages to handle by MsgHandler.
 type Subscriber struct {
 	conn         *Connection
 	subscription *nats.Subscription
 	logger       *slog.Logger
 	consumerName string
-	handler      MsgHandler
+	handler      ContextMsgHandler
 	quitSignal   chan bool
+	quitOnce     sync.Once
+	quitFetch    chan struct{}
+	stopped      chan struct{}
+	breaker      *circuitBreaker
+	ackPending   *ackPendingTracker
+	cancelAck    CancelAckBehavior
+	maxDeliver   int
+	fetchBatch   int
+	fetchWait    time.Duration
+	fetchBytes   int
+	redelivery   *redeliveryMonitor
+
+	redeliveryRatio   *redeliveryRatio
+	onRedeliveryRatio func(ratio float64)
+
+	maxProcessingAge   time.Duration
+	onMaxProcessingAge func(msg Msg, age time.Duration)
+	firstDeliveries    *firstDeliveries
+	schema             SchemaValidator
+	onInvalidMsg       func(msg Msg, err error)
+	middlewares        []Middleware
+	ciphers            []Cipher
+	onProgress         func(processed uint64)
+	progressInterval   time.Duration
+	gaps               *gapDetector
+	receiptSubject     string
+	deadLetterSubject  string
+	nakBackoff         BackoffStrategy
+	correlationID      CorrelationIDExtractor
+	stats              subscriberStats
+
+	pendingAlarm         *pendingAlarm
+	pendingAlarmInterval time.Duration
+	replay               *replayPacer
+	watermark            *watermarkWriter
+	byteBudget           *ByteBudget
+	inProgressInterval   time.Duration
+	ackWait              time.Duration
+	ordered              bool
+	push                 bool
 }
 
 // Start subscribes to the NATS consumer and starts a go-routine that handles pulled messages.
 func (s *Subscriber) Start(handler MsgHandler) (err error) {
+	return s.StartWithContext(context.Background(), func(_ context.Context, msg Msg) error {
+		return handler(msg)
+	})
+}
+
+// StartWithContext works like Start, but passes ctx to the handler. Once ctx is cancelled, a pending fetch
+// is aborted and the go-routine returns without fetching new messages, see Done. The message in flight is
+// handled to the end, and if its handler returns nil, it is ACKed even though ctx is cancelled.
+// If the handler returns an error after ctx was cancelled, the message is acknowledged according to the
+// CancelAckBehavior of the SubscriberArgs.
+func (s *Subscriber) StartWithContext(ctx context.Context, handler ContextMsgHandler) error {
 	if s.handler != nil {
 		return fmt.Errorf("handler is already set, don't call Start() multiple times")
 	}
 
-	s.handler = handler
+	s.handler = chainMiddlewares(handler, s.middlewares)
+	s.stopped = make(chan struct{})
+	if s.onProgress != nil || s.onRedeliveryRatio != nil {
+		go s.reportProgress(ctx)
+	}
+	if s.pendingAlarm != nil {
+		go s.watchPending(ctx)
+	}
+	if s.watermark != nil {
+		go s.writeWatermarks(ctx)
+	}
 
 	go func() {
+		defer close(s.stopped)
 		for {
 			select {
+			case <-ctx.Done():
+				s.logger.Info("Context cancelled, quit subscription go-routine.", slog.String("name", s.consumerName))
+				return
 			case <-s.quitSignal:
 				s.logger.Info("Received signal to quit subscription go-routine.")
 				return
 			default:
-				s.processMessages()
+				if wait := s.breaker.wait(); wait > 0 {
+					if !s.pause(ctx, wait) {
+						s.logger.Info("Received signal to quit subscription go-routine.")
+						return
+					}
+					continue
+				}
+				s.processMessages(ctx, s.breaker.batchSize(s.fetchBatch), s.fetchWait)
 			}
 		}
 	}()
@@ -63,7 +322,56 @@ func (s *Subscriber) Start(handler MsgHandler) (err error) {
 	return nil
 }
 
-// Stop unsubscribes the consumer from the NATS stream.
+// Done returns a channel, which is closed once the go-routine of Start returned, because its context was
+// cancelled or the Subscriber was stopped by the Connection. It is nil before Start was called.
+func (s *Subscriber) Done() <-chan struct{} {
+	return s.stopped
+}
+
+// Unsubscribe stops the go-routine of Start and unsubscribes from the consumer, while the other Subscribers
+// of the Connection keep running. It waits until the handler of the message in flight returned, so it must not
+// be called from within the handler, which would wait for itself. Use UnsubscribeNoWait there instead.
+// A consumer created by the Subscriber is deleted, like on Connection.Close, which does not act on this
+// Subscriber anymore afterwards.
+func (s *Subscriber) Unsubscribe() error {
+	return s.unsubscribe(true)
+}
+
+// UnsubscribeNoWait works like Unsubscribe, but does not wait until the handler of the message in flight
+// returned, so that a handler can unsubscribe its own Subscriber. The go-routine of Start returns once the
+// handler returned, see Done.
+func (s *Subscriber) UnsubscribeNoWait() error {
+	return s.unsubscribe(false)
+}
+
+func (s *Subscriber) unsubscribe(wait bool) error {
+	s.conn.removeSubscriber(s)
+	s.signalQuit()
+	if wait {
+		s.quit()
+	}
+	// The consumer may be deleted by the server already, e.g. after its InactiveThreshold.
+	if err := s.subscription.Unsubscribe(); err != nil && !errors.Is(err, nats.ErrConsumerNotFound) {
+		return fmt.Errorf("consumer %s could not be unsubscribed: %w", s.consumerName, err)
+	}
+	s.logger.Info("Unsubscribed consumer", slog.String("name", s.consumerName))
+	return nil
+}
+
+// unsubscribeAll unsubscribes the Subscribers created before a later one failed, so that none of them is
+// left on the Connection. It returns the joined errors of failed unsubscribes.
+func unsubscribeAll(subs []*Subscriber) error {
+	var errs []error
+	for _, sub := range subs {
+		if err := sub.Unsubscribe(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Stop unsubscribes the consumer from the NATS stream. Unlike Unsubscribe, it does not stop the go-routine
+// of Start, so prefer Unsubscribe.
 func (s *Subscriber) Stop() error {
 	if err := s.subscription.Unsubscribe(); err != nil {
 		return err
@@ -75,25 +383,327 @@ func (s *Subscriber) Stop() error {
 	return nil
 }
 
-func (s *Subscriber) processMessages() {
-	natsMsgs, err := s.subscription.Fetch(1) // Fetch only one msg at once to keep the order
-	if errors.Is(err, nats.ErrTimeout) {     // ErrTimeout is expected/ no new messages, so we don't log it
-		return
+// AckPending returns the messages which were delivered to this Subscriber, but are not ACKed or terminated yet.
+// This includes fetched messages waiting in a batch and NAKed messages waiting for their redelivery, which the
+// server counts as ack pending as well. A NAKed message is dropped, if it was not redelivered to this
+// Subscriber within the NAK delay plus AckWait. The entries are ordered by stream sequence. Messages pending on
+// other Subscribers of the same consumer are not included.
+func (s *Subscriber) AckPending() ([]AckPendingEntry, error) {
+	if !s.subscription.IsValid() {
+		return nil, fmt.Errorf("subscription of consumer %s is not valid anymore", s.consumerName)
+	}
+	return s.ackPending.list(), nil
+}
+
+// Stats returns a snapshot of the statistics of the Subscriber.
+func (s *Subscriber) Stats() SubscriberStats {
+	stats := s.stats.snapshot()
+	stats.RedeliveryRatio = s.redeliveryRatio.ratio()
+	return stats
+}
+
+// reportProgress calls the ProgressCallback and OnRedeliveryRatio periodically until the Subscriber quits.
+func (s *Subscriber) reportProgress(ctx context.Context) {
+	interval := s.progressInterval
+	if interval <= 0 {
+		interval = defaultProgressInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.quitSignal:
+			return
+		case <-ticker.C:
+			if s.onProgress != nil {
+				s.onProgress(s.stats.processed.Load())
+			}
+			if s.onRedeliveryRatio != nil {
+				s.onRedeliveryRatio(s.redeliveryRatio.ratio())
+			}
+		}
+	}
+}
+
+// quit signals the go-routine of Start to quit and waits until it returned. It may be called repeatedly.
+func (s *Subscriber) quit() {
+	s.signalQuit()
+	if s.stopped != nil {
+		<-s.stopped
+	}
+}
+
+// signalQuit signals the go-routine of Start to quit without waiting. It may be called repeatedly.
+func (s *Subscriber) signalQuit() {
+	s.quitOnce.Do(func() {
+		close(s.quitSignal)
+		close(s.quitFetch)
+	})
+}
+
+// quitting returns true, if the Subscriber was signalled to quit.
+func (s *Subscriber) quitting() bool {
+	select {
+	case <-s.quitSignal:
+		return true
+	default:
+		return false
+	}
+}
+
+// pause blocks for the given duration. It returns false, if the quit signal was received or ctx was
+// cancelled meanwhile.
+func (s *Subscriber) pause(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	case <-s.quitSignal:
+		return false
+	}
+}
+
+// processMessages fetches up to batch messages and handles them. The fetch waits at most wait for
+// messages and is aborted, if ctx is cancelled or the Subscriber quits.
+func (s *Subscriber) processMessages(ctx context.Context, batch int, wait time.Duration) {
+	s.handleMessages(ctx, s.fetchMessages(ctx, batch, wait))
+}
+
+// fetchMessages fetches up to batch messages. It returns no messages, if none arrived within wait, the fetch
+// failed, ctx was cancelled or the Subscriber quit.
+func (s *Subscriber) fetchMessages(ctx context.Context, batch int, wait time.Duration) []*nats.Msg {
+	fetchCtx, cancel := context.WithTimeout(ctx, wait)
+	defer cancel()
+	go func() {
+		select {
+		case <-s.quitFetch:
+			cancel()
+		case <-fetchCtx.Done():
+		}
+	}()
+
+	natsMsgs, err := s.fetch(fetchCtx, batch)
+	if errors.Is(err, nats.ErrTimeout) || fetchCtx.Err() != nil { // ErrTimeout is expected/ no new messages, so we don't log it
+		return nil
 	} else if err != nil {
 		s.logger.Error("Failed to receive msg", slog.String("error", err.Error()))
+		return nil
+	}
+	for _, natsMsg := range natsMsgs {
+		if meta, err := natsMsg.Metadata(); err == nil {
+			s.ackPending.add(meta.Sequence.Stream, meta.NumDelivered)
+		}
+	}
+	return natsMsgs
+}
+
+// handleMessages handles the fetched messages one after another. Once ctx is cancelled, the Subscriber quits
+// or the circuit breaker opened, the rest of the batch is not handled anymore.
+func (s *Subscriber) handleMessages(ctx context.Context, natsMsgs []*nats.Msg) {
+	for i, natsMsg := range natsMsgs {
+		if ctx.Err() != nil || s.quitting() || s.breaker.isOpen() {
+			// Release the rest of the batch, instead of leaving it in flight until AckWait expires.
+			for _, unhandled := range natsMsgs[i:] {
+				s.nak(unhandled, 0)
+			}
+			return
+		}
+		s.handleMsg(ctx, natsMsg)
+	}
+}
+
+func (s *Subscriber) handleMsg(ctx context.Context, natsMsg *nats.Msg) {
+	defer s.stats.begin()()
+
+	meta, err := natsMsg.Metadata()
+	if err != nil {
+		s.logger.Error("Failed to read msg metadata", slog.String("error", err.Error()))
+		return
+	}
+	if s.deferScheduled(ctx, natsMsg) {
+		return
+	}
+	s.redelivery.observe(meta.NumDelivered)
+	s.redeliveryRatio.observe(meta.NumDelivered)
+	s.gaps.observe(meta)
+
+	msg := makeMsg(natsMsg)
+	msg.Stream = meta.Stream
+	msg.Sequence = meta.Sequence.Stream
+	msg.Timestamp = meta.Timestamp
+	msg.NumDelivered = meta.NumDelivered
+	if msg, err = openMsg(msg, s.ciphers); err != nil {
+		s.logger.Error("Message decryption error, will be NAKed", slog.String("error", err.Error()))
+		s.nak(natsMsg, defaultNakDelay)
+		return
+	}
+	if msg, err = decompressMsg(msg); err != nil {
+		s.logger.Error("Message decompression error, will be NAKed", slog.String("error", err.Error()))
+		s.nak(natsMsg, defaultNakDelay)
+		return
+	}
+	if s.exceedsMaxProcessingAge(natsMsg, msg, meta) {
+		return
+	}
+	if !s.isValid(natsMsg, msg) {
 		return
 	}
+	if !s.waitReplay(ctx, meta.Timestamp) {
+		s.nak(natsMsg, 0)
+		return
+	}
+	size := int64(len(natsMsg.Data))
+	if !s.byteBudget.acquire(ctx, s.quitSignal, size) {
+		s.nak(natsMsg, 0)
+		return
+	}
+	defer s.byteBudget.release(size)
+
+	if s.correlationID != nil {
+		if id := s.correlationID(msg); id != "" {
+			ctx = ContextWithCorrelationID(ctx, id)
+		}
+	}
+
+	end := func(error) {}
+	if s.conn.tracer != nil {
+		ctx, end = s.conn.tracer.StartHandle(ctx, s.consumerName, msg)
+	}
+	start := time.Now()
+	stopInProgress := s.keepInProgress(natsMsg)
+	err = s.handle(ctx, msg)
+	stopInProgress()
+	end(err)
+	duration := time.Since(start)
+	s.stats.observeHandler(duration, err)
+	if s.conn.metrics != nil {
+		s.conn.metrics.ObserveHandle(s.consumerName, msg, duration, err)
+	}
+	s.breaker.record(err)
+	if err != nil && ctx.Err() != nil {
+		s.handleCancelled(natsMsg, err)
+		return
+	}
+	if errors.Is(err, ErrInvalidMsg) {
+		s.terminateInvalid(natsMsg, msg, err)
+		return
+	}
+	if err != nil && s.isLastDelivery(meta) && s.deadLetterSubject != "" {
+		s.deadLetter(ctx, natsMsg, msg, meta, err)
+		return
+	}
+	if err != nil && s.isLastDelivery(meta) {
+		s.logger.WarnContext(ctx, "Message handle error on last delivery, will not be redelivered",
+			append(correlationAttrs(ctx), slog.String("msgID", msg.MsgID), slog.String("subject", msg.Subject),
+				slog.Uint64("numDelivered", meta.NumDelivered), slog.String("error", err.Error()))...)
+		s.nak(natsMsg, 0)
+		return
+	}
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Message handle error, will be NAKed",
+			append(correlationAttrs(ctx), slog.String("error", err.Error()))...)
+		s.nak(natsMsg, s.nakDelay(meta, err))
+		return
+	}
+
+	s.ack(natsMsg)
+}
 
-	msg := makeMsg(natsMsgs[0])
-	if err = s.handler(msg); err != nil {
-		s.logger.Error("Message handle error, will be NAKed", slog.String("error", err.Error()))
-		if err := natsMsgs[0].NakWithDelay(defaultNakDelay); err != nil {
-			s.logger.Error("natsMsg.Nak() failed", slog.String("error", err.Error()))
+func (s *Subscriber) ack(natsMsg *nats.Msg) {
+	if !s.ordered { // ordered consumers do not acknowledge
+		if err := natsMsg.Ack(); err != nil {
+			s.logger.Error("natsMsg.Ack() failed:", slog.String("error", err.Error()))
+			return
 		}
+	}
+	s.stats.acks.Add(1)
+	s.gaps.settle(natsMsg)
+	s.firstDeliveries.settle(natsMsg)
+	s.settleAckPending(natsMsg)
+	s.publishReceipt(natsMsg, ReceiptAcked)
+}
+
+// nak NAKs the message, so that it is redelivered after the delay. A zero delay redelivers immediately.
+func (s *Subscriber) nak(natsMsg *nats.Msg, delay time.Duration) {
+	if s.ordered {
+		return // the message of an ordered consumer cannot be redelivered
+	}
+	if err := natsMsg.NakWithDelay(delay); err != nil {
+		s.logger.Error("natsMsg.Nak() failed", slog.String("error", err.Error()))
 		return
 	}
+	s.stats.naks.Add(1)
+	s.expireAckPending(natsMsg, delay)
+}
+
+// nakDelay returns the delay of the redelivery of a message, whose handler returned err.
+func (s *Subscriber) nakDelay(meta *nats.MsgMetadata, err error) time.Duration {
+	if delay, ok := retryAfterDelay(err); ok {
+		return delay
+	}
+	if s.nakBackoff == nil {
+		return defaultNakDelay
+	}
+	return s.nakBackoff.Next(int(meta.NumDelivered))
+}
+
+// isLastDelivery returns true, if the message is not redelivered anymore after this delivery.
+func (s *Subscriber) isLastDelivery(meta *nats.MsgMetadata) bool {
+	return s.maxDeliver > 0 && meta.NumDelivered >= uint64(s.maxDeliver)
+}
+
+func (s *Subscriber) term(natsMsg *nats.Msg) {
+	if !s.ordered { // ordered consumers do not acknowledge
+		if err := natsMsg.Term(); err != nil {
+			s.logger.Error("natsMsg.Term() failed", slog.String("error", err.Error()))
+			return
+		}
+	}
+	s.stats.terms.Add(1)
+	s.gaps.settle(natsMsg)
+	s.firstDeliveries.settle(natsMsg)
+	s.settleAckPending(natsMsg)
+	s.publishReceipt(natsMsg, ReceiptTerminated)
+}
+
+// isValid validates the message against the schema. Invalid messages are terminated.
+func (s *Subscriber) isValid(natsMsg *nats.Msg, msg Msg) bool {
+	if s.schema == nil {
+		return true
+	}
+	err := s.schema.Validate(msg.Data)
+	if err == nil {
+		return true
+	}
+
+	s.terminateInvalid(natsMsg, msg, fmt.Errorf("message does not match schema: %w", err))
+	return false
+}
+
+// terminateInvalid terminates a message, which will never be processable, and passes it to OnInvalidMsg.
+func (s *Subscriber) terminateInvalid(natsMsg *nats.Msg, msg Msg, err error) {
+	s.logger.Warn("Message is invalid, will be terminated",
+		slog.String("msgID", msg.MsgID), slog.String("subject", msg.Subject), slog.String("error", err.Error()))
+	s.term(natsMsg)
+	if s.onInvalidMsg != nil {
+		s.onInvalidMsg(msg, err)
+	}
+}
 
-	if err = natsMsgs[0].Ack(); err != nil {
-		s.logger.Error("natsMsg.Ack() failed:", slog.String("error", err.Error()))
+func (s *Subscriber) handleCancelled(natsMsg *nats.Msg, err error) {
+	switch s.cancelAck {
+	case LeaveInFlight:
+		s.logger.Info("Message handle error after context cancellation, leave in flight", slog.String("error", err.Error()))
+		s.expireAckPending(natsMsg, 0)
+	default:
+		s.logger.Info("Message handle error after context cancellation, will be NAKed", slog.String("error", err.Error()))
+		s.nak(natsMsg, 0)
 	}
 }
@@ -1,10 +1,18 @@
 package vnats
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"os"
 	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
+
+	"github.com/nats-io/nats.go"
 )
 
 type subscribeStringsConfig struct {
@@ -372,6 +380,10 @@ func TestSubscriberMultiple(t *testing.T) {
 					t.Errorf("Subscriber %d: Too less messages were successful %d < %d", idx, subState.SuccessfulMsgs, tt.args.subscribers[idx].minSuccessfulMsgs)
 				}
 			}
+			// The subscribers must not keep fetching from the consumer of the same name in the next test case.
+			if err := conn.Close(); err != nil {
+				t.Error(err)
+			}
 		})
 	}
 }
@@ -389,3 +401,1373 @@ func makeHandlerSubscriber(t *testing.T, alwaysFail bool, s *subscriptionState,
 	}
 	return handler
 }
+
+func TestSubscriber_AckPending(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	subject := integrationTestStreamName + ".ackPending"
+	conn := makeIntegrationTestConn(t)
+	publishStringMessages(t, conn, subject, []string{"hello"})
+	sub := createSubscriber(t, conn, "TestSubscriberAckPending", subject, SingleSubscriberStrictMessageOrder)
+
+	done := make(chan []AckPendingEntry)
+	handler := func(_ Msg) error {
+		entries, err := sub.AckPending()
+		if err != nil {
+			t.Error(err)
+		}
+		done <- entries
+		return nil
+	}
+	if err := sub.Start(handler); err != nil {
+		t.Error(err)
+	}
+
+	select {
+	case entries := <-done:
+		if len(entries) != 1 || entries[0].Sequence != 1 || entries[0].NumDelivered != 1 {
+			t.Errorf("Unexpected ack pending entries: %v", entries)
+		}
+	case <-time.After(time.Second * 5):
+		t.Error("Message was not received")
+	}
+	if err := conn.Close(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSubscriber_AckPending_BatchAndNak(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	subject := integrationTestStreamName + ".ackPendingBatch"
+	conn := makeIntegrationTestConn(t)
+	publishStringMessages(t, conn, subject, []string{"first", "second", "third"})
+	sub, err := conn.NewSubscriber(SubscriberArgs{
+		ConsumerName:   "TestSubscriberAckPendingBatch",
+		Subject:        subject,
+		FetchBatchSize: 3,
+		NakBackoff:     LinearBackoff{Initial: time.Minute},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	inHandler := make(chan []AckPendingEntry, 3)
+	if err := sub.Start(func(msg Msg) error {
+		entries, err := sub.AckPending()
+		if err != nil {
+			t.Error(err)
+		}
+		inHandler <- entries
+		if string(msg.Data) == "first" {
+			return fmt.Errorf("REST-Endpoint is down, retry later")
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The NAKed first message stays ack pending, while the ACKed second one is removed.
+	for _, want := range []int{3, 3, 2} {
+		select {
+		case entries := <-inHandler:
+			if len(entries) != want {
+				t.Errorf("Got %d ack pending entries while handling the batch, expected %d: %v", len(entries), want, entries)
+			}
+		case <-time.After(time.Second * 5):
+			t.Fatal("Message was not received")
+		}
+	}
+	entries, err := sub.AckPending()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Sequence != 1 {
+		t.Errorf("Got ack pending entries %v after the batch, expected only the NAKed message 1", entries)
+	}
+	if err := conn.Close(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSubscriber_CancelAckBehavior(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	tests := []struct {
+		name          string
+		behavior      CancelAckBehavior
+		wantRedeliver bool
+	}{
+		{name: "NakOnCancel redelivers immediately", behavior: NakOnCancel, wantRedeliver: true},
+		{name: "LeaveInFlight waits for AckWait", behavior: LeaveInFlight, wantRedeliver: false},
+	}
+	subject := integrationTestStreamName + ".cancelAckBehavior"
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn := makeIntegrationTestConn(t)
+			publishStringMessages(t, conn, subject, []string{"hello"})
+
+			args := SubscriberArgs{
+				ConsumerName:      "TestSubscriberCancelAckBehavior",
+				Subject:           subject,
+				CancelAckBehavior: tt.behavior,
+			}
+			first, err := conn.NewSubscriber(args)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			cancelled := make(chan bool)
+			if err := first.StartWithContext(ctx, func(ctx context.Context, _ Msg) error {
+				cancel()
+				cancelled <- true
+				return ctx.Err()
+			}); err != nil {
+				t.Error(err)
+			}
+			<-cancelled
+
+			second, err := conn.NewSubscriber(args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			redelivered := make(chan bool, 1)
+			if err := second.Start(func(_ Msg) error {
+				redelivered <- true
+				return nil
+			}); err != nil {
+				t.Error(err)
+			}
+
+			select {
+			case <-redelivered:
+				if !tt.wantRedeliver {
+					t.Error("Message was redelivered before AckWait expired")
+				}
+			case <-time.After(time.Second * 2):
+				if tt.wantRedeliver {
+					t.Error("Message was not redelivered after context cancellation")
+				}
+			}
+			if err := conn.Close(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestSubscriber_StartWithContext_Cancel(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	subject := integrationTestStreamName + ".startWithContextCancel"
+	conn := makeIntegrationTestConn(t)
+	publishStringMessages(t, conn, subject, []string{"first", "second"})
+
+	sub, err := conn.NewSubscriber(SubscriberArgs{
+		ConsumerName: "TestSubscriberStartWithContextCancel",
+		Subject:      subject,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var handled []string
+	if err := sub.StartWithContext(ctx, func(_ context.Context, msg Msg) error {
+		cancel()
+		time.Sleep(time.Millisecond * 100) // the in-flight message still finishes
+		handled = append(handled, string(msg.Data))
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-sub.Done():
+	case <-time.After(defaultFetchWait / 2):
+		t.Fatal("Subscriber did not return after context cancellation")
+	}
+	if len(handled) != 1 || handled[0] != "first" {
+		t.Errorf("Handled %v, expected only the first message", handled)
+	}
+	if acks := sub.Stats().Acks; acks != 1 {
+		t.Errorf("Got %d ACKs, expected the first message ACKed", acks)
+	}
+	if err := conn.Close(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSubscriber_FetchBatchSize(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	subject := integrationTestStreamName + ".fetchBatchSize"
+	conn := makeIntegrationTestConn(t)
+	publishStringMessages(t, conn, subject, []string{"a", "b", "c", "d", "e"})
+
+	sub, err := conn.NewSubscriber(SubscriberArgs{
+		ConsumerName:   "TestSubscriberFetchBatchSize",
+		Subject:        subject,
+		FetchBatchSize: 5,
+		NakBackoff:     LinearBackoff{Initial: time.Millisecond * 10},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var mu sync.Mutex
+	var handled []string
+	done := make(chan bool)
+	if err := sub.Start(func(msg Msg) error {
+		mu.Lock()
+		defer mu.Unlock()
+		handled = append(handled, string(msg.Data))
+		if string(msg.Data) == "c" && msg.NumDelivered == 1 {
+			return errors.New("failed mid-batch")
+		}
+		if len(handled) == 6 {
+			close(done)
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-done:
+	case <-time.After(time.Second * 5):
+		t.Fatal("Not all messages were handled")
+	}
+	mu.Lock()
+	if want := []string{"a", "b", "c", "d", "e", "c"}; !reflect.DeepEqual(handled, want) {
+		t.Errorf("Handled %v, expected %v", handled, want)
+	}
+	mu.Unlock()
+	if stats := sub.Stats(); stats.Acks != 5 || stats.Naks != 1 {
+		t.Errorf("Got %d ACKs and %d NAKs, expected only the failed message NAKed", stats.Acks, stats.Naks)
+	}
+	if err := conn.Close(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSubscriber_FetchTimeout(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	conn := makeIntegrationTestConn(t)
+	sub, err := conn.NewSubscriber(SubscriberArgs{
+		ConsumerName: "TestSubscriberFetchTimeout",
+		Subject:      integrationTestStreamName + ".fetchTimeout",
+		FetchTimeout: time.Minute,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sub.fetchWait != time.Minute {
+		t.Errorf("Subscriber waits %v per fetch, expected %v", sub.fetchWait, time.Minute)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := sub.StartWithContext(ctx, func(_ context.Context, _ Msg) error { return nil }); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(time.Millisecond * 100) // the fetch waits for messages of the idle subject
+	cancel()
+	select {
+	case <-sub.Done():
+	case <-time.After(time.Second):
+		t.Fatal("Subscriber waited for the FetchTimeout after context cancellation")
+	}
+	if err := conn.Close(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestConnection_NewSubscriber_FetchBatchSizeExceedsMaxRequestBatch(t *testing.T) {
+	conn := makeTestConnection(t, "EVENTS", 0, nil, "", nil)
+	_, err := conn.NewSubscriber(SubscriberArgs{
+		ConsumerName:    "orders",
+		Subject:         "EVENTS.created",
+		FetchBatchSize:  20,
+		MaxRequestBatch: 10,
+	})
+	if err == nil {
+		t.Error("NewSubscriber() with FetchBatchSize above MaxRequestBatch should fail")
+	}
+}
+
+func TestConnection_NewSubscriber_StreamName(t *testing.T) {
+	resolver := func(_ string) string { return "RESOLVED" }
+	tests := []struct {
+		name       string
+		streamName string
+		resolver   StreamResolver
+		want       string
+		wantErr    bool
+	}{
+		{name: "Looked up by subject", want: ""},
+		{name: "Resolved", resolver: resolver, want: "RESOLVED"},
+		{name: "Explicit", streamName: "ORDERS", want: "ORDERS"},
+		{name: "Explicit before resolved", streamName: "ORDERS", resolver: resolver, want: "ORDERS"},
+		{name: "Invalid", streamName: "ORDERS.eu", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn := makeTestConnection(t, "", 0, nil, "", nil)
+			conn.streamResolver = tt.resolver
+			_, err := conn.NewSubscriber(SubscriberArgs{ConsumerName: "orders", Subject: "eu.orders.created", StreamName: tt.streamName})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewSubscriber() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if bound := conn.nats.(*testBridge).boundStreams; !tt.wantErr && (len(bound) != 1 || bound[0] != tt.want) {
+				t.Errorf("Subscriber bound to streams %q, want %q", bound, tt.want)
+			}
+		})
+	}
+}
+
+func TestSubscriber_ExplicitStreamName(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	const streamName = "IntegrationTestsExplicit"
+	subject := "explicitStream.orders.created"
+	conn := makeIntegrationTestConn(t)
+	nb := conn.nats.(*natsBridge)
+	if err := deleteStream(nb, streamName); err != nil && !errors.Is(err, nats.ErrStreamNotFound) {
+		t.Fatal(err)
+	}
+	if err := nb.EnsureStreamExists(&nats.StreamConfig{Name: streamName, Subjects: []string{"explicitStream.>"}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := nb.jetStreamContext.Publish(subject, []byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+
+	sub, err := conn.NewSubscriber(SubscriberArgs{ConsumerName: "TestSubscriberExplicitStreamName", Subject: subject, StreamName: streamName})
+	if err != nil {
+		t.Fatal(err)
+	}
+	received := make(chan string, 1)
+	if err := sub.Start(func(msg Msg) error {
+		received <- string(msg.Data)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case data := <-received:
+		if data != "hello" {
+			t.Errorf("Got message %q, expected %q", data, "hello")
+		}
+	case <-time.After(time.Second * 5):
+		t.Error("Message of explicit stream was not received")
+	}
+	if err := conn.Close(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestConnection_NewSubscriber_EphemeralArgs(t *testing.T) {
+	tests := []struct {
+		name string
+		args SubscriberArgs
+	}{
+		{name: "Named", args: SubscriberArgs{ConsumerName: "orders"}},
+		{name: "Queue group", args: SubscriberArgs{QueueGroup: "orders"}},
+		{name: "Watermark", args: SubscriberArgs{WatermarkBucket: "watermarks"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn := makeTestConnection(t, "EVENTS", 0, nil, "", nil)
+			tt.args.Subject = "EVENTS.created"
+			tt.args.Ephemeral = true
+			if _, err := conn.NewSubscriber(tt.args); err == nil {
+				t.Error("NewSubscriber() should fail")
+			}
+		})
+	}
+}
+
+func TestSubscriber_Ephemeral(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	subject := integrationTestStreamName + ".ephemeral"
+	conn := makeIntegrationTestConn(t)
+	publishStringMessages(t, conn, subject, []string{"hello"})
+
+	sub, err := conn.NewSubscriber(SubscriberArgs{Subject: subject, Ephemeral: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	received := make(chan string, 1)
+	if err := sub.Start(func(msg Msg) error {
+		received <- string(msg.Data)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-received:
+	case <-time.After(time.Second * 5):
+		t.Fatal("Message was not received by ephemeral consumer")
+	}
+
+	js := conn.nats.(*natsBridge).jetStreamContext
+	info, err := js.ConsumerInfo(integrationTestStreamName, sub.consumerName)
+	if err != nil {
+		t.Fatalf("Ephemeral consumer %q was not found: %v", sub.consumerName, err)
+	}
+	if info.Config.Durable != "" {
+		t.Errorf("Consumer is durable with name %s", info.Config.Durable)
+	}
+
+	if err := sub.Unsubscribe(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := js.ConsumerInfo(integrationTestStreamName, sub.consumerName); !errors.Is(err, nats.ErrConsumerNotFound) {
+		t.Errorf("ConsumerInfo() after Unsubscribe error = %v, want %v", err, nats.ErrConsumerNotFound)
+	}
+	if err := conn.Close(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSubscriber_InactiveThreshold(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	conn := makeIntegrationTestConn(t)
+	sub, err := conn.NewSubscriber(SubscriberArgs{
+		ConsumerName:      "TestSubscriberInactiveThreshold",
+		Subject:           integrationTestStreamName + ".inactiveThreshold",
+		InactiveThreshold: time.Second,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	js := conn.nats.(*natsBridge).jetStreamContext
+	info, err := js.ConsumerInfo(integrationTestStreamName, sub.consumerName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Config.InactiveThreshold != time.Second {
+		t.Errorf("Consumer has InactiveThreshold %v, expected %v", info.Config.InactiveThreshold, time.Second)
+	}
+
+	// The Subscriber was not started, so the consumer is inactive.
+	deadline := time.Now().Add(time.Second * 10)
+	for {
+		_, err := js.ConsumerInfo(integrationTestStreamName, sub.consumerName)
+		if errors.Is(err, nats.ErrConsumerNotFound) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Inactive consumer was not deleted, ConsumerInfo() error = %v", err)
+		}
+		time.Sleep(time.Millisecond * 200)
+	}
+	if err := conn.Close(); err != nil {
+		t.Error(err)
+	}
+}
+
+func Test_validateDeliverPolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    SubscriberArgs
+		wantErr bool
+	}{
+		{name: "Default", args: SubscriberArgs{}, wantErr: false},
+		{name: "New", args: SubscriberArgs{DeliverPolicy: nats.DeliverNewPolicy}, wantErr: false},
+		{name: "Start sequence", args: SubscriberArgs{DeliverPolicy: nats.DeliverByStartSequencePolicy, OptStartSeq: 42}, wantErr: false},
+		{name: "Start time", args: SubscriberArgs{DeliverPolicy: nats.DeliverByStartTimePolicy, OptStartTime: time.Now()}, wantErr: false},
+		{name: "Start sequence missing", args: SubscriberArgs{DeliverPolicy: nats.DeliverByStartSequencePolicy}, wantErr: true},
+		{name: "Start sequence without policy", args: SubscriberArgs{OptStartSeq: 42}, wantErr: true},
+		{name: "Start time missing", args: SubscriberArgs{DeliverPolicy: nats.DeliverByStartTimePolicy}, wantErr: true},
+		{name: "Start time with sequence policy", args: SubscriberArgs{DeliverPolicy: nats.DeliverByStartSequencePolicy, OptStartSeq: 42, OptStartTime: time.Now()}, wantErr: true},
+		{name: "Watermark", args: SubscriberArgs{DeliverPolicy: nats.DeliverLastPolicy, WatermarkBucket: "watermarks"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateDeliverPolicy(tt.args); (err != nil) != tt.wantErr {
+				t.Errorf("validateDeliverPolicy() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSubscriber_DeliverPolicy(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	tests := []struct {
+		name string
+		args SubscriberArgs
+		want []string
+	}{
+		{name: "All", args: SubscriberArgs{}, want: []string{"msg-0", "msg-1", "msg-2"}},
+		{name: "Last", args: SubscriberArgs{DeliverPolicy: nats.DeliverLastPolicy}, want: []string{"msg-2"}},
+		{name: "New", args: SubscriberArgs{DeliverPolicy: nats.DeliverNewPolicy}, want: []string{}},
+		{name: "Start sequence", args: SubscriberArgs{DeliverPolicy: nats.DeliverByStartSequencePolicy, OptStartSeq: 2},
+			want: []string{"msg-1", "msg-2"}},
+		{name: "Start time", args: SubscriberArgs{DeliverPolicy: nats.DeliverByStartTimePolicy, OptStartTime: time.Now().Add(time.Hour)},
+			want: []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subject := integrationTestStreamName + ".deliverPolicy"
+			conn := makeIntegrationTestConn(t)
+			publishManyMessages(t, conn, subject, 3)
+
+			tt.args.ConsumerName = "TestSubscriberDeliverPolicy"
+			tt.args.Subject = subject
+			sub, err := conn.NewSubscriber(tt.args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var mu sync.Mutex
+			received := []string{}
+			if err := sub.Start(func(msg Msg) error {
+				mu.Lock()
+				defer mu.Unlock()
+				received = append(received, string(msg.Data))
+				return nil
+			}); err != nil {
+				t.Fatal(err)
+			}
+
+			time.Sleep(time.Millisecond * 500)
+			mu.Lock()
+			if !reflect.DeepEqual(received, tt.want) {
+				t.Errorf("Received %v, expected %v", received, tt.want)
+			}
+			mu.Unlock()
+			if err := conn.Close(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestSubscriber_WildcardSubject(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	prefix := integrationTestStreamName + ".wildcard"
+	conn := makeIntegrationTestConn(t)
+	for _, subject := range []string{prefix + ".eu.created", prefix + ".us.created", prefix + ".eu.deleted", prefix + ".eu.created.late"} {
+		publishSubjectMessages(t, conn, subject, 2)
+	}
+
+	sub, err := conn.NewSubscriber(SubscriberArgs{ConsumerName: "TestSubscriberWildcardSubject", Subject: prefix + ".*.created"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var mu sync.Mutex
+	handled := map[string]int{}
+	if err := sub.Start(func(msg Msg) error {
+		mu.Lock()
+		defer mu.Unlock()
+		handled[msg.Subject]++
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(time.Second)
+	mu.Lock()
+	want := map[string]int{prefix + ".eu.created": 2, prefix + ".us.created": 2}
+	if !reflect.DeepEqual(handled, want) {
+		t.Errorf("Handled %v, expected %v", handled, want)
+	}
+	mu.Unlock()
+	if err := conn.Close(); err != nil {
+		t.Error(err)
+	}
+}
+
+func BenchmarkSubscriber_FetchBatchSize(b *testing.B) {
+	if os.Getenv("NATS_SERVER_URL") == "" {
+		b.Skip("skipping integration benchmark")
+	}
+	subject := integrationTestStreamName + ".benchmarkFetchBatchSize"
+	for _, batch := range []int{1, 10, 100} {
+		b.Run(fmt.Sprintf("batch-%d", batch), func(b *testing.B) {
+			conn := makeIntegrationTestConn(b)
+			publishManyMessages(b, conn, subject, b.N)
+
+			sub, err := conn.NewSubscriber(SubscriberArgs{
+				ConsumerName:   "BenchmarkSubscriberFetchBatchSize",
+				Subject:        subject,
+				FetchBatchSize: batch,
+			})
+			if err != nil {
+				b.Fatal(err)
+			}
+
+			b.ResetTimer()
+			var handled atomic.Int64
+			done := make(chan bool)
+			if err := sub.Start(func(_ Msg) error {
+				if handled.Add(1) == int64(b.N) {
+					close(done)
+				}
+				return nil
+			}); err != nil {
+				b.Fatal(err)
+			}
+			<-done
+			b.StopTimer()
+
+			if err := conn.Close(); err != nil {
+				b.Error(err)
+			}
+		})
+	}
+}
+
+func TestSubscriber_Unsubscribe(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	subject := integrationTestStreamName + ".unsubscribe"
+	conn := makeIntegrationTestConn(t)
+
+	removed, err := conn.NewSubscriber(SubscriberArgs{ConsumerName: "TestSubscriberUnsubscribeRemoved", Subject: subject})
+	if err != nil {
+		t.Fatal(err)
+	}
+	kept, err := conn.NewSubscriber(SubscriberArgs{ConsumerName: "TestSubscriberUnsubscribeKept", Subject: subject})
+	if err != nil {
+		t.Fatal(err)
+	}
+	received := make(chan string, 1)
+	for _, sub := range []*Subscriber{removed, kept} {
+		name := sub.consumerName
+		if err := sub.Start(func(_ Msg) error {
+			received <- name
+			return nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	start := time.Now()
+	if err := removed.Unsubscribe(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-removed.Done():
+		if elapsed := time.Since(start); elapsed >= defaultFetchWait {
+			t.Errorf("Unsubscribe() took %v, expected it to abort the pending fetch", elapsed)
+		}
+	default:
+		t.Error("Unsubscribe() returned before the go-routine of Start")
+	}
+	if len(conn.subscribers) != 1 || conn.subscribers[0] != kept {
+		t.Errorf("Connection still tracks %d subscribers, expected only the kept one", len(conn.subscribers))
+	}
+
+	publishStringMessages(t, conn, subject, []string{"hello"})
+	select {
+	case name := <-received:
+		if name != kept.consumerName {
+			t.Errorf("Message was received by %s, expected %s", name, kept.consumerName)
+		}
+	case <-time.After(time.Second * 5):
+		t.Error("Message was not received by the kept Subscriber")
+	}
+	if err := conn.Close(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSubscriber_UnsubscribeNoWait(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	subject := integrationTestStreamName + ".unsubscribeNoWait"
+	conn := makeIntegrationTestConn(t)
+	publishStringMessages(t, conn, subject, []string{"first", "second", "third"})
+
+	sub, err := conn.NewSubscriber(SubscriberArgs{
+		ConsumerName:   "TestSubscriberUnsubscribeNoWait",
+		Subject:        subject,
+		FetchBatchSize: 3,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var handled []string
+	if err := sub.Start(func(msg Msg) error {
+		handled = append(handled, string(msg.Data))
+		return sub.UnsubscribeNoWait()
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-sub.Done():
+	case <-time.After(time.Second * 2):
+		t.Fatal("UnsubscribeNoWait() from the handler did not stop the go-routine of Start")
+	}
+	if !reflect.DeepEqual(handled, []string{"first"}) {
+		t.Errorf("Handled %v, expected the rest of the batch to be released after unsubscribing", handled)
+	}
+	if err := conn.Close(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSubscriber_MaxProcessingAge(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	subject := integrationTestStreamName + ".maxProcessingAge"
+	conn := makeIntegrationTestConn(t)
+	publishStringMessages(t, conn, subject, []string{"hello"})
+
+	terminated := make(chan Msg, 1)
+	sub, err := conn.NewSubscriber(SubscriberArgs{
+		ConsumerName:               "TestSubscriberMaxProcessingAge",
+		Subject:                    subject,
+		MaxProcessingAge:           time.Millisecond * 100,
+		OnMaxProcessingAgeExceeded: func(msg Msg, _ time.Duration) { terminated <- msg },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	callCount := 0
+	if err := sub.Start(func(_ Msg) error {
+		callCount++
+		return fmt.Errorf("REST-Endpoint is down, retry later")
+	}); err != nil {
+		t.Error(err)
+	}
+
+	select {
+	case msg := <-terminated:
+		if string(msg.Data) != "hello" {
+			t.Errorf("Wrong message terminated: %s", msg.Data)
+		}
+	case <-time.After(defaultNakDelay * 2):
+		t.Error("Message was not terminated")
+	}
+	if callCount != 1 {
+		t.Errorf("Handler should be called once before termination, but was called %d times", callCount)
+	}
+	if err := conn.Close(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSubscriber_MaxProcessingAge_OldMessage(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	subject := integrationTestStreamName + ".maxProcessingAgeOld"
+	conn := makeIntegrationTestConn(t)
+	publishStringMessages(t, conn, subject, []string{"hello"})
+	time.Sleep(time.Millisecond * 1500) // the message is older than the MaxProcessingAge when it is consumed
+
+	terminated := make(chan Msg, 1)
+	sub, err := conn.NewSubscriber(SubscriberArgs{
+		ConsumerName:               "TestSubscriberMaxProcessingAgeOld",
+		Subject:                    subject,
+		MaxProcessingAge:           time.Second,
+		NakBackoff:                 LinearBackoff{Initial: time.Millisecond * 10},
+		OnMaxProcessingAgeExceeded: func(msg Msg, _ time.Duration) { terminated <- msg },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handled := make(chan Msg, 1)
+	callCount := 0
+	if err := sub.Start(func(msg Msg) error {
+		callCount++
+		if callCount == 1 {
+			return fmt.Errorf("REST-Endpoint is down, retry later")
+		}
+		handled <- msg
+		return nil
+	}); err != nil {
+		t.Error(err)
+	}
+
+	select {
+	case msg := <-handled:
+		if string(msg.Data) != "hello" {
+			t.Errorf("Wrong message handled: %s", msg.Data)
+		}
+	case msg := <-terminated:
+		t.Errorf("Message %s was terminated after a single failure", msg.Data)
+	case <-time.After(defaultNakDelay):
+		t.Error("Message was not redelivered")
+	}
+	if err := conn.Close(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestMultiStreamSubscriber(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	secondStreamName := integrationTestStreamName + "Second"
+	conn := makeIntegrationTestConn(t)
+	nb := conn.nats.(*natsBridge)
+	if err := deleteStream(nb, secondStreamName); err != nil && !errors.Is(err, nats.ErrStreamNotFound) {
+		t.Fatal(err)
+	}
+	if err := createStream(nb, secondStreamName); err != nil {
+		t.Fatal(err)
+	}
+
+	firstSubject := integrationTestStreamName + ".multiStream"
+	secondSubject := secondStreamName + ".multiStream"
+	publishStringMessages(t, conn, firstSubject, []string{"a1", "a2", "a3"})
+	pub, err := conn.NewPublisher(PublisherArgs{StreamName: secondStreamName})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, data := range []string{"b1", "b2", "b3"} {
+		if err := pub.Publish(NewMsg(secondSubject, data, []byte(data))); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	multi, err := conn.NewMultiStreamSubscriber(MultiStreamSubscriberArgs{
+		ConsumerName: "TestMultiStreamSubscriber",
+		Sources:      []MultiStreamSource{{Subject: firstSubject}, {Subject: secondSubject}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var mu sync.Mutex
+	var received []string
+	done := make(chan bool)
+	if err := multi.Start(func(msg Msg) error {
+		mu.Lock()
+		defer mu.Unlock()
+		received = append(received, msg.Stream+":"+string(msg.Data))
+		if len(received) == 6 {
+			done <- true
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-done:
+	case <-time.After(time.Second * 5):
+		t.Fatalf("Not all messages were received: %v", received)
+	}
+	// The sources are fetched concurrently, so only the order within each stream is fixed.
+	var first, second []string
+	for _, r := range received {
+		if strings.HasPrefix(r, secondStreamName+":") {
+			second = append(second, r)
+		} else {
+			first = append(first, r)
+		}
+	}
+	wantFirst := []string{integrationTestStreamName + ":a1", integrationTestStreamName + ":a2", integrationTestStreamName + ":a3"}
+	wantSecond := []string{secondStreamName + ":b1", secondStreamName + ":b2", secondStreamName + ":b3"}
+	if !reflect.DeepEqual(first, wantFirst) || !reflect.DeepEqual(second, wantSecond) {
+		t.Errorf("Got %v, expected %v and %v in order", received, wantFirst, wantSecond)
+	}
+	if err := conn.Close(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestMultiStreamSubscriber_IdleSources(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	conn := makeIntegrationTestConn(t)
+	nb := conn.nats.(*natsBridge)
+	sources := []MultiStreamSource{{Subject: integrationTestStreamName + ".multiStreamBusy"}}
+	for _, streamName := range []string{integrationTestStreamName + "Idle1", integrationTestStreamName + "Idle2"} {
+		if err := deleteStream(nb, streamName); err != nil && !errors.Is(err, nats.ErrStreamNotFound) {
+			t.Fatal(err)
+		}
+		if err := createStream(nb, streamName); err != nil {
+			t.Fatal(err)
+		}
+		sources = append(sources, MultiStreamSource{Subject: streamName + ".multiStream"})
+	}
+	busySubject := sources[0].Subject
+	messages := make([]string, 100)
+	for i := range messages {
+		messages[i] = fmt.Sprintf("msg-%d", i)
+	}
+	publishStringMessages(t, conn, busySubject, messages)
+
+	multi, err := conn.NewMultiStreamSubscriber(MultiStreamSubscriberArgs{
+		ConsumerName: "TestMultiStreamSubscriberIdleSources",
+		Sources:      sources,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	received := make(chan Msg, len(messages))
+	if err := multi.StartWithContext(ctx, func(_ context.Context, msg Msg) error {
+		received <- msg
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Idle sources wait up to the fetch timeout of 5 seconds, which must not delay the busy source.
+	timeout := time.After(time.Second * 2)
+	for i := range messages {
+		select {
+		case <-received:
+		case <-timeout:
+			t.Fatalf("Received only %d of %d messages of the busy source", i, len(messages))
+		}
+	}
+
+	cancel()
+	select {
+	case <-multi.sources[0].sub.Done():
+	case <-time.After(time.Second):
+		t.Error("Go-routine did not return after the context was cancelled")
+	}
+	if err := conn.Close(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSubscriber_PullRequestLimits(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	conn := makeIntegrationTestConn(t)
+	sub, err := conn.NewSubscriber(SubscriberArgs{
+		ConsumerName:       "TestSubscriberPullRequestLimits",
+		Subject:            integrationTestStreamName + ".pullRequestLimits",
+		MaxRequestBatch:    10,
+		MaxRequestMaxBytes: 1024,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := sub.subscription.ConsumerInfo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Config.MaxRequestBatch != 10 || info.Config.MaxRequestMaxBytes != 1024 {
+		t.Errorf("Got MaxRequestBatch=%d MaxRequestMaxBytes=%d, expected 10 and 1024",
+			info.Config.MaxRequestBatch, info.Config.MaxRequestMaxBytes)
+	}
+	if err := conn.Close(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSubscriber_MaxRequestMaxBytes(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	subject := integrationTestStreamName + ".maxRequestMaxBytes"
+	conn := makeIntegrationTestConn(t)
+	large := strings.Repeat("x", 1000)
+	publishStringMessages(t, conn, subject, []string{large, large, large, large, large})
+
+	sub, err := conn.NewSubscriber(SubscriberArgs{
+		ConsumerName:       "TestSubscriberMaxRequestMaxBytes",
+		Subject:            subject,
+		FetchBatchSize:     5,
+		MaxRequestMaxBytes: 2500,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	natsMsgs, err := sub.fetch(ctx, sub.fetchBatch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(natsMsgs) != 2 {
+		t.Errorf("Fetched %d messages of 1000 bytes with max 2500 bytes, want 2", len(natsMsgs))
+	}
+	if err := conn.Close(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSubscriber_Stats(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	subject := integrationTestStreamName + ".stats"
+	conn := makeIntegrationTestConn(t)
+	publishStringMessages(t, conn, subject, []string{"hello", "world"})
+	sub := createSubscriber(t, conn, "TestSubscriberStats", subject, MultipleSubscribersAllowed)
+
+	failed := false
+	if err := sub.Start(func(msg Msg) error {
+		if string(msg.Data) == "hello" && !failed {
+			failed = true
+			return fmt.Errorf("REST-Endpoint is down, retry later")
+		}
+		return nil
+	}); err != nil {
+		t.Error(err)
+	}
+
+	deadline := time.Now().Add(defaultNakDelay * 2)
+	for sub.Stats().Acks < 2 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond * 50)
+	}
+
+	stats := sub.Stats()
+	if stats.Received != 3 || stats.Processed != 3 || stats.HandlerErrors != 1 || stats.Acks != 2 || stats.Naks != 1 || stats.Terms != 0 || stats.InFlight != 0 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+	if err := conn.Close(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSubscriber_AckWait(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	tests := []struct {
+		name    string
+		mode    SubscriptionMode
+		ackWait time.Duration
+		want    time.Duration
+	}{
+		{name: "Default of multiple subscribers", mode: MultipleSubscribersAllowed, want: DefaultAckWaitMultipleSubscribers},
+		{name: "Default of strict message order", mode: SingleSubscriberStrictMessageOrder, want: DefaultAckWaitStrictMessageOrder},
+		{name: "Overridden", mode: SingleSubscriberStrictMessageOrder, ackWait: time.Minute, want: time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn := makeIntegrationTestConn(t)
+			sub, err := conn.NewSubscriber(SubscriberArgs{
+				ConsumerName: "TestSubscriberAckWait",
+				Subject:      integrationTestStreamName + ".ackWait",
+				Mode:         tt.mode,
+				AckWait:      tt.ackWait,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			info, err := sub.subscription.ConsumerInfo()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.Config.AckWait != tt.want {
+				t.Errorf("Got AckWait=%v, expected %v", info.Config.AckWait, tt.want)
+			}
+			if err := conn.Close(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestSubscriber_handleMsg_Metadata(t *testing.T) {
+	var got Msg
+	sub := makeTestSubscriber(SubscriberArgs{}, func(_ context.Context, msg Msg) error {
+		got = msg
+		return nil
+	})
+	natsMsg := makeTestJSMsg(integrationTestStreamName+".metadata", []byte("hello"), 3)
+	natsMsg.Header.Set("Trace-Id", "42")
+	sub.handleMsg(context.Background(), natsMsg)
+
+	if got.Stream != integrationTestStreamName || got.Sequence != 1 || got.NumDelivered != 3 || got.Timestamp.IsZero() {
+		t.Errorf("Metadata of message was not passed to the handler: %+v", got)
+	}
+	if got.Header.Get("Trace-Id") != "42" {
+		t.Errorf("Header of message was not passed to the handler: %v", got.Header)
+	}
+}
+
+func TestSubscriber_AckWaitMismatch(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	conn := makeIntegrationTestConn(t)
+	args := SubscriberArgs{
+		ConsumerName: "TestSubscriberAckWaitMismatch",
+		Subject:      integrationTestStreamName + ".ackWaitMismatch",
+		AckWait:      time.Minute,
+	}
+	if _, err := conn.NewSubscriber(args); err != nil {
+		t.Fatal(err)
+	}
+
+	args.AckWait = time.Minute * 2
+	_, err := conn.NewSubscriber(args)
+	if err == nil || !strings.Contains(err.Error(), "ack wait") {
+		t.Errorf("NewSubscriber() with other AckWait than the existing consumer error = %v, want ack wait mismatch", err)
+	}
+	if err := conn.Close(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSubscriber_MaxAckPending(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	conn := makeIntegrationTestConn(t)
+	args := SubscriberArgs{
+		ConsumerName:  "TestSubscriberMaxAckPending",
+		Subject:       integrationTestStreamName + ".maxAckPending",
+		MaxAckPending: 100,
+	}
+	sub, err := conn.NewSubscriber(args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := sub.subscription.ConsumerInfo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Config.MaxAckPending != 100 {
+		t.Errorf("Consumer has MaxAckPending %d, expected 100", info.Config.MaxAckPending)
+	}
+
+	if _, err := conn.NewSubscriber(args); err != nil {
+		t.Errorf("NewSubscriber() with the same MaxAckPending error = %v", err)
+	}
+	args.MaxAckPending = 50
+	_, err = conn.NewSubscriber(args)
+	var mismatch *ModeMismatchError
+	if !errors.As(err, &mismatch) || mismatch.ExistingMaxAckPending != 100 || mismatch.RequestedMaxAckPending != 50 {
+		t.Errorf("NewSubscriber() with other MaxAckPending than the existing consumer error = %v, want ModeMismatchError", err)
+	}
+	if err := conn.Close(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSubscriber_ModeMismatch(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	conn := makeIntegrationTestConn(t)
+	args := SubscriberArgs{
+		ConsumerName: "TestSubscriberModeMismatch",
+		Subject:      integrationTestStreamName + ".modeMismatch",
+		Mode:         SingleSubscriberStrictMessageOrder,
+	}
+	if _, err := conn.NewSubscriber(args); err != nil {
+		t.Fatal(err)
+	}
+
+	args.Mode = MultipleSubscribersAllowed
+	_, err := conn.NewSubscriber(args)
+	if !errors.Is(err, ErrSubscriptionModeMismatch) {
+		t.Fatalf("NewSubscriber() with other mode error = %v, want %v", err, ErrSubscriptionModeMismatch)
+	}
+	var mismatch *ModeMismatchError
+	if !errors.As(err, &mismatch) {
+		t.Fatalf("NewSubscriber() error = %v, want ModeMismatchError", err)
+	}
+	if mismatch.Existing != SingleSubscriberStrictMessageOrder || mismatch.Requested != MultipleSubscribersAllowed {
+		t.Errorf("Got modes %s and %s, expected the existing strict order and the requested multiple subscribers",
+			mismatch.Existing, mismatch.Requested)
+	}
+	if err := conn.Close(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestConnection_NewSubscriber_MaxAckPendingStrictOrder(t *testing.T) {
+	conn := makeTestConnection(t, "EVENTS", 0, nil, "", nil)
+	_, err := conn.NewSubscriber(SubscriberArgs{
+		Subject:       "EVENTS.created",
+		Mode:          SingleSubscriberStrictMessageOrder,
+		MaxAckPending: 10,
+	})
+	if err == nil {
+		t.Error("NewSubscriber() with MaxAckPending above 1 in strict message order should fail")
+	}
+}
+
+func TestSubscriber_MaxDeliver(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	subject := integrationTestStreamName + ".maxDeliver"
+	conn := makeIntegrationTestConn(t)
+	publishStringMessages(t, conn, subject, []string{"poison", "next"})
+	sub, err := conn.NewSubscriber(SubscriberArgs{
+		ConsumerName: "TestSubscriberMaxDeliver",
+		Subject:      subject,
+		Mode:         SingleSubscriberStrictMessageOrder,
+		MaxDeliver:   2,
+		NakBackoff:   LinearBackoff{Initial: time.Millisecond * 10},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var poisonDeliveries atomic.Int32
+	done := make(chan struct{})
+	if err := sub.Start(func(msg Msg) error {
+		if string(msg.Data) == "poison" {
+			poisonDeliveries.Add(1)
+			return errors.New("cannot handle poison")
+		}
+		close(done)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-done:
+	case <-time.After(time.Second * 5):
+		t.Fatal("Message after the poison message was not handled")
+	}
+	if got := poisonDeliveries.Load(); got != 2 {
+		t.Errorf("Poison message was delivered %d times, expected 2", got)
+	}
+	info, err := sub.subscription.ConsumerInfo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Config.MaxDeliver != 2 {
+		t.Errorf("Got MaxDeliver=%d, expected 2", info.Config.MaxDeliver)
+	}
+	if err := conn.Close(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSubscriber_MaxDeliverDrift(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	tests := []struct {
+		name    string
+		first   int
+		second  int
+		wantErr bool
+	}{
+		{name: "Same limit", first: 5, second: 5, wantErr: false},
+		{name: "Unlimited again", first: 0, second: 0, wantErr: false},
+		{name: "Other limit", first: 5, second: 3, wantErr: true},
+		{name: "Limit removed", first: 5, second: 0, wantErr: true},
+		{name: "Limit added", first: 0, second: 5, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn := makeIntegrationTestConn(t)
+			subject := integrationTestStreamName + ".maxDeliverDrift"
+			if _, err := conn.NewSubscriber(SubscriberArgs{ConsumerName: "TestSubscriberMaxDeliverDrift", Subject: subject,
+				MaxDeliver: tt.first}); err != nil {
+				t.Fatal(err)
+			}
+
+			_, err := conn.NewSubscriber(SubscriberArgs{ConsumerName: "TestSubscriberMaxDeliverDrift", Subject: subject,
+				MaxDeliver: tt.second})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewSubscriber() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err := conn.Close(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestSubscriber_FilterSubjectDrift(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	tests := []struct {
+		name    string
+		first   string
+		second  string
+		wantErr bool
+	}{
+		{name: "Same subject", first: integrationTestStreamName + ".drift.orders", second: integrationTestStreamName + ".drift.orders", wantErr: false},
+		{name: "Whole stream again", first: integrationTestStreamName + ".>", second: integrationTestStreamName + ".>", wantErr: false},
+		{name: "Other subject", first: integrationTestStreamName + ".drift.orders", second: integrationTestStreamName + ".drift.invoices", wantErr: true},
+		{name: "Narrowed from whole stream", first: integrationTestStreamName + ".>", second: integrationTestStreamName + ".drift.orders", wantErr: true},
+		{name: "Whole stream without filter", first: "", second: integrationTestStreamName + ".>", wantErr: false},
+		{name: "Narrowed without filter", first: "", second: integrationTestStreamName + ".drift.orders", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn := makeIntegrationTestConn(t)
+			if tt.first == "" { // Consumer without filter subject, like created by the NATS CLI
+				if _, err := conn.nats.(*natsBridge).jetStreamContext.AddConsumer(integrationTestStreamName, &nats.ConsumerConfig{
+					Durable:   "TestSubscriberFilterSubjectDrift",
+					AckPolicy: nats.AckExplicitPolicy,
+					AckWait:   DefaultAckWaitMultipleSubscribers,
+				}); err != nil {
+					t.Fatal(err)
+				}
+			} else if _, err := conn.NewSubscriber(SubscriberArgs{ConsumerName: "TestSubscriberFilterSubjectDrift", Subject: tt.first}); err != nil {
+				t.Fatal(err)
+			}
+
+			_, err := conn.NewSubscriber(SubscriberArgs{ConsumerName: "TestSubscriberFilterSubjectDrift", Subject: tt.second})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewSubscriber() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr && !errors.Is(err, nats.ErrSubjectMismatch) {
+				t.Errorf("NewSubscriber() error = %v, want %v", err, nats.ErrSubjectMismatch)
+			}
+			if err := conn.Close(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestConnection_NewMultiStreamSubscriber_Cleanup(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	conn := makeIntegrationTestConn(t)
+	_, err := conn.NewMultiStreamSubscriber(MultiStreamSubscriberArgs{
+		ConsumerName: "TestMultiStreamCleanup",
+		Sources: []MultiStreamSource{
+			{Subject: integrationTestStreamName + ".multiStreamCleanup"},
+			{Subject: "MissingStream.multiStreamCleanup"},
+		},
+	})
+	if err == nil {
+		t.Fatal("NewMultiStreamSubscriber() with a missing stream should fail")
+	}
+	if len(conn.subscribers) != 0 {
+		t.Errorf("Got %d subscribers of the failed multi-stream subscriber, want 0", len(conn.subscribers))
+	}
+	if err := conn.Close(); err != nil {
+		t.Error(err)
+	}
+}
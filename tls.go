@@ -0,0 +1,29 @@
+package vnats
+
+import (
+	"crypto/tls"
+
+	"github.com/nats-io/nats.go"
+)
+
+// WithTLS secures the connection by the TLS config, e.g. to set the root CAs and client certificates
+// loaded from a secret store.
+// This option can be passed in the Connect function.
+// Without this option, TLS is only used if required by the server and verified by the system root CAs.
+func WithTLS(config *tls.Config) Option {
+	return withNATSOptions(nats.Secure(config))
+}
+
+// WithRootCAs secures the connection and verifies the server certificate by the CA certificates of the
+// PEM files at the paths instead of the system root CAs.
+// This option can be passed in the Connect function. Connect fails, if a file cannot be read.
+func WithRootCAs(paths ...string) Option {
+	return withNATSOptions(nats.RootCAs(paths...))
+}
+
+// WithClientCert secures the connection and authenticates it by the client certificate and key of the
+// PEM files, as required by servers verifying client certificates.
+// This option can be passed in the Connect function. Connect fails, if a file cannot be read.
+func WithClientCert(certFile, keyFile string) Option {
+	return withNATSOptions(nats.ClientCert(certFile, keyFile))
+}
@@ -0,0 +1,27 @@
+package vnats
+
+import "context"
+
+// Tracer propagates the trace context across NATS, e.g. by OpenTelemetry with the package vnatsotel.
+// The trace context is passed in the Header of the messages.
+type Tracer interface {
+	// StartPublish is called before a message is sent to NATS with the context of PublishWithContext.
+	// It may inject the trace context into the Header of msg, which is the copy sent to NATS.
+	// The returned function is called with the final error of the publish, or nil.
+	StartPublish(ctx context.Context, msg Msg) (end func(err error))
+
+	// StartHandle is called before the handler of a message on the Subscriber of the consumer. It may
+	// extract the trace context from the Header of msg. The returned context is passed to the handler and
+	// the returned function is called with the error of the handler, or nil.
+	StartHandle(ctx context.Context, consumer string, msg Msg) (context.Context, func(err error))
+}
+
+// WithTracer traces the published and handled messages of all Publishers and Subscribers by tracer.
+// Handlers receive the context of the handle span by StartWithContext.
+// This option can be passed in the Connect function.
+// Without this option, messages are not traced and no trace context is propagated.
+func WithTracer(tracer Tracer) Option {
+	return func(c *Connection) {
+		c.tracer = tracer
+	}
+}
@@ -0,0 +1,73 @@
+package vnats
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+type traceKey struct{}
+
+// testTracer propagates a fixed trace ID in the "Trace-Id" header and records the errors of the spans.
+type testTracer struct {
+	publishErrs []error
+	handleErrs  []error
+}
+
+func (t *testTracer) StartPublish(ctx context.Context, msg Msg) func(err error) {
+	if id, ok := ctx.Value(traceKey{}).(string); ok {
+		msg.Header.Set("Trace-Id", id)
+	}
+	return func(err error) { t.publishErrs = append(t.publishErrs, err) }
+}
+
+func (t *testTracer) StartHandle(ctx context.Context, _ string, msg Msg) (context.Context, func(err error)) {
+	ctx = context.WithValue(ctx, traceKey{}, msg.Header.Get("Trace-Id"))
+	return ctx, func(err error) { t.handleErrs = append(t.handleErrs, err) }
+}
+
+func TestWithTracer_Publish(t *testing.T) {
+	tracer := &testTracer{}
+	conn := makeTestConnection(t, "MESSAGES", 1, []byte("test message"), "msg-001", nil)
+	WithTracer(tracer)(conn)
+	pub := &Publisher{conn: conn, logger: conn.logger, streamName: "MESSAGES", backoff: defaultRetryBackoff}
+
+	msg := NewMsg("MESSAGES.Important", "msg-001", []byte("test message"))
+	ctx := context.WithValue(context.Background(), traceKey{}, "trace-42")
+	if err := pub.PublishWithContext(ctx, msg); err != nil {
+		t.Fatal(err)
+	}
+
+	published := conn.nats.(*testBridge).published
+	if got := published[0].Header.Get("Trace-Id"); got != "trace-42" {
+		t.Errorf("Published Trace-Id header = %q, want trace-42", got)
+	}
+	if got := msg.Header.Get("Trace-Id"); got != "" {
+		t.Errorf("Header of the caller was modified: Trace-Id = %q", got)
+	}
+	if len(tracer.publishErrs) != 1 || tracer.publishErrs[0] != nil {
+		t.Errorf("Publish span ended with %v, want a single nil error", tracer.publishErrs)
+	}
+}
+
+func TestWithTracer_Handle(t *testing.T) {
+	tracer := &testTracer{}
+	handlerErr := errors.New("downstream unavailable")
+	var gotTraceID string
+	sub := makeTestSubscriber(SubscriberArgs{ConsumerName: "TestConsumer"}, func(ctx context.Context, _ Msg) error {
+		gotTraceID, _ = ctx.Value(traceKey{}).(string)
+		return handlerErr
+	})
+	WithTracer(tracer)(sub.conn)
+
+	natsMsg := makeTestJSMsg(integrationTestStreamName+".tracing", []byte("hello"), 1)
+	natsMsg.Header.Set("Trace-Id", "trace-42")
+	sub.handleMsg(context.Background(), natsMsg)
+
+	if gotTraceID != "trace-42" {
+		t.Errorf("Handler got trace ID %q, want trace-42", gotTraceID)
+	}
+	if len(tracer.handleErrs) != 1 || !errors.Is(tracer.handleErrs[0], handlerErr) {
+		t.Errorf("Handle span ended with %v, want the handler error", tracer.handleErrs)
+	}
+}
@@ -0,0 +1,96 @@
+package vnats
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+const defaultTypeField = "type"
+
+// TypeRegistry maps type discriminators to constructors of the concrete Go types.
+// A constructor must return a pointer, so that the payload can be decoded into it.
+type TypeRegistry map[string]func() any
+
+// TypedMsgHandler is the type of function to process a message, which was decoded into the registered type.
+type TypedMsgHandler func(msg Msg, value any) error
+
+// TypeDispatchArgs contains the arguments for creating a MsgHandler with DispatchByType.
+type TypeDispatchArgs struct {
+	// Registry maps the type discriminators to the constructors of the concrete types.
+	Registry TypeRegistry
+
+	// Handlers maps the type discriminators to the handler of the decoded value.
+	// Every discriminator needs a constructor in the Registry.
+	Handlers map[string]TypedMsgHandler
+
+	// Header is the name of the header, which contains the discriminator. If empty or not set on a message,
+	// the discriminator is read from the JSON payload. Messages of other encodings need the Header.
+	Header string
+
+	// Field is the name of the JSON field in the payload, which contains the discriminator. Default is "type".
+	Field string
+}
+
+// DispatchByType returns a MsgHandler that reads the type discriminator of each message, decodes the payload
+// into the registered type according to the Encoding of the message, which defaults to EncJSON, and calls the
+// handler of that type. Messages without a readable discriminator, without a registered type or handler, or
+// which cannot be decoded result in an error wrapping ErrInvalidMsg, like in DecodeHandler.
+func DispatchByType(args TypeDispatchArgs) (MsgHandler, error) {
+	for discriminator := range args.Handlers {
+		if _, ok := args.Registry[discriminator]; !ok {
+			return nil, fmt.Errorf("no type registered for handler of %q", discriminator)
+		}
+	}
+	if args.Field == "" {
+		args.Field = defaultTypeField
+	}
+
+	return func(msg Msg) error {
+		discriminator, err := args.discriminator(msg)
+		if err != nil {
+			return fmt.Errorf("%w: message with msgID: %s: %w", ErrInvalidMsg, msg.MsgID, err)
+		}
+		handler, ok := args.Handlers[discriminator]
+		if !ok {
+			return fmt.Errorf("%w: message with msgID: %s: no handler registered for type %q", ErrInvalidMsg,
+				msg.MsgID, discriminator)
+		}
+
+		encoding := msg.Encoding
+		if encoding == "" {
+			encoding = EncJSON
+		}
+		value := args.Registry[discriminator]()
+		if err := encoding.Unmarshal(msg.Data, value); errors.Is(err, ErrUnsupportedType) {
+			return fmt.Errorf("message with msgID: %s of type %q could not be decoded: %w", msg.MsgID, discriminator, err)
+		} else if err != nil {
+			return fmt.Errorf("%w: message with msgID: %s of type %q could not be decoded: %w", ErrInvalidMsg,
+				msg.MsgID, discriminator, err)
+		}
+		return handler(msg, value)
+	}, nil
+}
+
+func (a *TypeDispatchArgs) discriminator(msg Msg) (string, error) {
+	if a.Header != "" {
+		if discriminator := msg.Header.Get(a.Header); discriminator != "" {
+			return discriminator, nil
+		}
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(msg.Data, &fields); err != nil {
+		return "", fmt.Errorf("type of message could not be read: %w", err)
+	}
+	var discriminator string
+	if raw, ok := fields[a.Field]; ok {
+		if err := json.Unmarshal(raw, &discriminator); err != nil {
+			return "", fmt.Errorf("type field %q of message is not a string: %w", a.Field, err)
+		}
+	}
+	if discriminator == "" {
+		return "", fmt.Errorf("message contains no type field %q", a.Field)
+	}
+	return discriminator, nil
+}
@@ -0,0 +1,123 @@
+package vnats
+
+import (
+	"errors"
+	"testing"
+)
+
+type orderCreated struct {
+	Type    string `json:"type"`
+	OrderID string `json:"orderId"`
+}
+
+type orderCancelled struct {
+	Type   string `json:"type"`
+	Reason string `json:"reason"`
+}
+
+func TestDispatchByType(t *testing.T) {
+	var got any
+	handler, err := DispatchByType(TypeDispatchArgs{
+		Registry: TypeRegistry{
+			"order.created":   func() any { return &orderCreated{} },
+			"order.cancelled": func() any { return &orderCancelled{} },
+			"order.raw":       func() any { return &[]byte{} },
+		},
+		Handlers: map[string]TypedMsgHandler{
+			"order.created":   func(_ Msg, value any) error { got = value; return nil },
+			"order.cancelled": func(_ Msg, value any) error { got = value; return nil },
+			"order.raw":       func(_ Msg, value any) error { got = value; return nil },
+		},
+		Header: "Event-Type",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rawOrder := []byte("raw bytes")
+	tests := []struct {
+		name    string
+		msg     Msg
+		want    any
+		wantErr bool
+	}{
+		{
+			name: "Discriminator in payload",
+			msg:  Msg{Data: []byte(`{"type":"order.created","orderId":"42"}`)},
+			want: &orderCreated{Type: "order.created", OrderID: "42"},
+		},
+		{
+			name: "Discriminator in header",
+			msg: Msg{
+				Data:   []byte(`{"reason":"out of stock"}`),
+				Header: Header{"Event-Type": []string{"order.cancelled"}},
+			},
+			want: &orderCancelled{Reason: "out of stock"},
+		},
+		{
+			name: "Encoding of message",
+			msg: Msg{
+				Data:     rawOrder,
+				Header:   Header{"Event-Type": []string{"order.raw"}},
+				Encoding: EncRaw,
+			},
+			want: &rawOrder,
+		},
+		{
+			name:    "Unknown discriminator",
+			msg:     Msg{Data: []byte(`{"type":"order.shipped"}`)},
+			wantErr: true,
+		},
+		{
+			name:    "Missing discriminator",
+			msg:     Msg{Data: []byte(`{"orderId":"42"}`)},
+			wantErr: true,
+		},
+		{
+			name:    "Invalid payload",
+			msg:     Msg{Data: []byte(`not json`)},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got = nil
+			err := handler(tt.msg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("handler() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidMsg) {
+					t.Errorf("handler() error = %v, want %v", err, ErrInvalidMsg)
+				}
+				return
+			}
+			switch want := tt.want.(type) {
+			case *orderCreated:
+				if value, ok := got.(*orderCreated); !ok || *value != *want {
+					t.Errorf("handler() got = %v, want %v", got, want)
+				}
+			case *orderCancelled:
+				if value, ok := got.(*orderCancelled); !ok || *value != *want {
+					t.Errorf("handler() got = %v, want %v", got, want)
+				}
+			case *[]byte:
+				if value, ok := got.(*[]byte); !ok || string(*value) != string(*want) {
+					t.Errorf("handler() got = %v, want %v", got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestDispatchByType_MissingConstructor(t *testing.T) {
+	_, err := DispatchByType(TypeDispatchArgs{
+		Registry: TypeRegistry{},
+		Handlers: map[string]TypedMsgHandler{
+			"order.created": func(_ Msg, _ any) error { return nil },
+		},
+	})
+	if err == nil {
+		t.Error("DispatchByType() should fail for handler without registered type")
+	}
+}
@@ -0,0 +1,116 @@
+// Package vnatsotel propagates OpenTelemetry traces across NATS. It is a separate module, so that
+// applications without OpenTelemetry do not depend on it.
+package vnatsotel
+
+import (
+	"context"
+
+	"github.com/fond-of-vertigo/vnats"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/fond-of-vertigo/vnats/vnatsotel"
+
+// Tracer implements vnats.Tracer by OpenTelemetry. Pass it to vnats.Connect by vnats.WithTracer.
+// A publish starts a producer span and injects its context into the message header. A handled message
+// starts a consumer span, whose parent is the extracted context of the publisher.
+type Tracer struct {
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+}
+
+// Option is an optional configuration argument for the New function.
+type Option func(*Tracer)
+
+// WithTracerProvider sets the provider of the tracer. Without this option, otel.GetTracerProvider is used.
+func WithTracerProvider(provider trace.TracerProvider) Option {
+	return func(t *Tracer) {
+		t.tracer = provider.Tracer(instrumentationName)
+	}
+}
+
+// WithPropagator sets the propagator of the trace context. Without this option, otel.GetTextMapPropagator
+// is used.
+func WithPropagator(propagator propagation.TextMapPropagator) Option {
+	return func(t *Tracer) {
+		t.propagator = propagator
+	}
+}
+
+// New creates a Tracer.
+func New(options ...Option) *Tracer {
+	t := &Tracer{}
+	for _, option := range options {
+		option(t)
+	}
+	if t.tracer == nil {
+		t.tracer = otel.GetTracerProvider().Tracer(instrumentationName)
+	}
+	if t.propagator == nil {
+		t.propagator = otel.GetTextMapPropagator()
+	}
+	return t
+}
+
+// StartPublish implements vnats.Tracer.
+func (t *Tracer) StartPublish(ctx context.Context, msg vnats.Msg) func(err error) {
+	ctx, span := t.tracer.Start(ctx, msg.Subject+" publish",
+		trace.WithSpanKind(trace.SpanKindProducer), trace.WithAttributes(msgAttributes(msg)...))
+	t.propagator.Inject(ctx, headerCarrier(msg.Header))
+	return func(err error) {
+		end(span, err)
+	}
+}
+
+// StartHandle implements vnats.Tracer.
+func (t *Tracer) StartHandle(ctx context.Context, consumer string, msg vnats.Msg) (context.Context, func(err error)) {
+	ctx = t.propagator.Extract(ctx, headerCarrier(msg.Header))
+	attrs := append(msgAttributes(msg),
+		attribute.String("messaging.consumer.group.name", consumer),
+		attribute.Int64("messaging.nats.num_delivered", int64(msg.NumDelivered)),
+	)
+	ctx, span := t.tracer.Start(ctx, msg.Subject+" process",
+		trace.WithSpanKind(trace.SpanKindConsumer), trace.WithAttributes(attrs...))
+	return ctx, func(err error) {
+		end(span, err)
+	}
+}
+
+func msgAttributes(msg vnats.Msg) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.String("messaging.system", "nats"),
+		attribute.String("messaging.destination.name", msg.Subject),
+		attribute.String("messaging.message.id", msg.MsgID),
+	}
+}
+
+func end(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// headerCarrier adapts the message header to a propagation.TextMapCarrier.
+type headerCarrier vnats.Header
+
+func (c headerCarrier) Get(key string) string {
+	return vnats.Header(c).Get(key)
+}
+
+func (c headerCarrier) Set(key, value string) {
+	vnats.Header(c).Set(key, value)
+}
+
+func (c headerCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for key := range c {
+		keys = append(keys, key)
+	}
+	return keys
+}
@@ -0,0 +1,65 @@
+package vnatsotel
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/fond-of-vertigo/vnats"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var _ vnats.Tracer = (*Tracer)(nil)
+
+func TestTracer(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	tracer := New(WithTracerProvider(provider), WithPropagator(propagation.TraceContext{}))
+
+	ctx, parent := provider.Tracer("test").Start(context.Background(), "request")
+	msg := vnats.Msg{Subject: "ORDERS.created", MsgID: "order-42", Header: vnats.Header{}}
+	tracer.StartPublish(ctx, msg)(nil)
+	parent.End()
+	if msg.Header.Get("traceparent") == "" {
+		t.Fatalf("StartPublish() did not inject the trace context, header is %v", msg.Header)
+	}
+
+	msg.NumDelivered = 1
+	handlerCtx, end := tracer.StartHandle(context.Background(), "billing", msg)
+	if got := trace.SpanContextFromContext(handlerCtx).TraceID(); got != parent.SpanContext().TraceID() {
+		t.Errorf("Handler context has trace ID %v, want %v", got, parent.SpanContext().TraceID())
+	}
+	end(errors.New("downstream unavailable"))
+
+	spans := exporter.GetSpans()
+	if len(spans) != 3 {
+		t.Fatalf("Got %d spans, expected request, publish and process", len(spans))
+	}
+	publish, process := spans[0], spans[2]
+	if publish.Name != "ORDERS.created publish" || publish.SpanKind != trace.SpanKindProducer {
+		t.Errorf("Got publish span %s of kind %v", publish.Name, publish.SpanKind)
+	}
+	if publish.Parent.SpanID() != parent.SpanContext().SpanID() {
+		t.Error("Publish span is not a child of the active span")
+	}
+	if process.Name != "ORDERS.created process" || process.SpanKind != trace.SpanKindConsumer {
+		t.Errorf("Got process span %s of kind %v", process.Name, process.SpanKind)
+	}
+	if process.Parent.SpanID() != publish.SpanContext.SpanID() {
+		t.Error("Process span is not a child of the publish span")
+	}
+	if process.Status.Code != codes.Error {
+		t.Errorf("Process span has status %v, want Error", process.Status.Code)
+	}
+	attrs := map[string]string{}
+	for _, attr := range process.Attributes {
+		attrs[string(attr.Key)] = attr.Value.Emit()
+	}
+	if attrs["messaging.destination.name"] != "ORDERS.created" || attrs["messaging.message.id"] != "order-42" {
+		t.Errorf("Process span has attributes %v, want the subject and MsgID", attrs)
+	}
+}
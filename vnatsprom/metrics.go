@@ -0,0 +1,95 @@
+// Package vnatsprom exports the metrics of vnats to Prometheus. It is a separate module, so that
+// applications without Prometheus do not depend on its client.
+package vnatsprom
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/fond-of-vertigo/vnats"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Metrics implements vnats.Metrics by Prometheus collectors. Pass it to vnats.Connect by vnats.WithMetrics.
+type Metrics struct {
+	published       *prometheus.CounterVec
+	publishErrors   *prometheus.CounterVec
+	consumed        *prometheus.CounterVec
+	handlerErrors   *prometheus.CounterVec
+	handlerDuration *prometheus.HistogramVec
+	redelivered     *prometheus.CounterVec
+}
+
+// New creates the collectors and registers them at the registerer, e.g. prometheus.DefaultRegisterer:
+//
+//	vnats_published_messages_total{subject}      messages published to NATS
+//	vnats_publish_errors_total{subject}          messages which could not be published after all retries
+//	vnats_consumed_messages_total{consumer}      messages passed to a handler
+//	vnats_handler_errors_total{consumer}         messages whose handler returned an error
+//	vnats_handler_duration_seconds{consumer}     duration of the handlers
+//	vnats_redelivered_messages_total{consumer}   messages passed to a handler more than once
+func New(registerer prometheus.Registerer) (*Metrics, error) {
+	m := &Metrics{
+		published: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "vnats",
+			Name:      "published_messages_total",
+			Help:      "Number of messages published to NATS.",
+		}, []string{"subject"}),
+		publishErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "vnats",
+			Name:      "publish_errors_total",
+			Help:      "Number of messages which could not be published after all retries.",
+		}, []string{"subject"}),
+		consumed: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "vnats",
+			Name:      "consumed_messages_total",
+			Help:      "Number of messages passed to a handler.",
+		}, []string{"consumer"}),
+		handlerErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "vnats",
+			Name:      "handler_errors_total",
+			Help:      "Number of messages whose handler returned an error.",
+		}, []string{"consumer"}),
+		handlerDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "vnats",
+			Name:      "handler_duration_seconds",
+			Help:      "Duration of the message handlers.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"consumer"}),
+		redelivered: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "vnats",
+			Name:      "redelivered_messages_total",
+			Help:      "Number of messages passed to a handler more than once.",
+		}, []string{"consumer"}),
+	}
+
+	for _, collector := range []prometheus.Collector{
+		m.published, m.publishErrors, m.consumed, m.handlerErrors, m.handlerDuration, m.redelivered,
+	} {
+		if err := registerer.Register(collector); err != nil {
+			return nil, fmt.Errorf("vnats metrics could not be registered: %w", err)
+		}
+	}
+	return m, nil
+}
+
+// ObservePublish implements vnats.Metrics.
+func (m *Metrics) ObservePublish(subject string, err error) {
+	if err != nil {
+		m.publishErrors.WithLabelValues(subject).Inc()
+		return
+	}
+	m.published.WithLabelValues(subject).Inc()
+}
+
+// ObserveHandle implements vnats.Metrics.
+func (m *Metrics) ObserveHandle(consumer string, msg vnats.Msg, duration time.Duration, err error) {
+	m.consumed.WithLabelValues(consumer).Inc()
+	m.handlerDuration.WithLabelValues(consumer).Observe(duration.Seconds())
+	if err != nil {
+		m.handlerErrors.WithLabelValues(consumer).Inc()
+	}
+	if msg.NumDelivered > 1 {
+		m.redelivered.WithLabelValues(consumer).Inc()
+	}
+}
@@ -0,0 +1,58 @@
+package vnatsprom
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fond-of-vertigo/vnats"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+var _ vnats.Metrics = (*Metrics)(nil)
+
+func TestMetrics(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	metrics, err := New(registry)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	metrics.ObservePublish("ORDERS.created", nil)
+	metrics.ObservePublish("ORDERS.created", nil)
+	metrics.ObservePublish("ORDERS.created", errors.New("no responders"))
+	metrics.ObserveHandle("billing", vnats.Msg{NumDelivered: 1}, time.Millisecond*20, errors.New("downstream unavailable"))
+	metrics.ObserveHandle("billing", vnats.Msg{NumDelivered: 2}, time.Millisecond*30, nil)
+
+	want := `
+# HELP vnats_consumed_messages_total Number of messages passed to a handler.
+# TYPE vnats_consumed_messages_total counter
+vnats_consumed_messages_total{consumer="billing"} 2
+# HELP vnats_handler_errors_total Number of messages whose handler returned an error.
+# TYPE vnats_handler_errors_total counter
+vnats_handler_errors_total{consumer="billing"} 1
+# HELP vnats_publish_errors_total Number of messages which could not be published after all retries.
+# TYPE vnats_publish_errors_total counter
+vnats_publish_errors_total{subject="ORDERS.created"} 1
+# HELP vnats_published_messages_total Number of messages published to NATS.
+# TYPE vnats_published_messages_total counter
+vnats_published_messages_total{subject="ORDERS.created"} 2
+# HELP vnats_redelivered_messages_total Number of messages passed to a handler more than once.
+# TYPE vnats_redelivered_messages_total counter
+vnats_redelivered_messages_total{consumer="billing"} 1
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(want),
+		"vnats_consumed_messages_total", "vnats_handler_errors_total", "vnats_publish_errors_total",
+		"vnats_published_messages_total", "vnats_redelivered_messages_total"); err != nil {
+		t.Error(err)
+	}
+	if got := testutil.CollectAndCount(metrics.handlerDuration); got != 1 {
+		t.Errorf("Got %d handler duration series, expected 1", got)
+	}
+
+	if _, err := New(registry); err == nil {
+		t.Error("New() should fail to register the collectors twice")
+	}
+}
@@ -0,0 +1,77 @@
+// Package vnatstest provides helpers for testing components which publish and subscribe via vnats.
+package vnatstest
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/fond-of-vertigo/vnats"
+)
+
+const loopbackBufferSize = 1024
+
+var loopbackCounter atomic.Uint64
+
+// NextMsgFunc blocks until the next message is received and returns its decoded Data, or fails after the
+// timeout elapsed. It also fails, if the Data of the next message could not be decoded.
+type NextMsgFunc[T any] func(timeout time.Duration) (*T, error)
+
+type loopbackResult[T any] struct {
+	value *T
+	err   error
+}
+
+// Loopback creates a Publisher for the stream of the subject and a Subscriber on the same subject.
+// The Publisher labels the messages with the encoding, which defaults to vnats.EncJSON. The returned
+// NextMsgFunc blocks for the next message received by the Subscriber and decodes it into a new T according to
+// its Encoding, so that tests neither need to sleep until messages are collected nor decode them by hand.
+// The Subscriber uses its own consumer and is closed together with the Connection.
+func Loopback[T any](conn *vnats.Connection, subject string, encoding vnats.Encoding) (*vnats.Publisher, NextMsgFunc[T], error) {
+	if encoding == "" {
+		encoding = vnats.EncJSON
+	}
+	streamName, _, _ := strings.Cut(subject, ".")
+	pub, err := conn.NewPublisher(vnats.PublisherArgs{StreamName: streamName, Encoding: encoding})
+	if err != nil {
+		return nil, nil, fmt.Errorf("loopback publisher could not be created: %w", err)
+	}
+
+	sub, err := conn.NewSubscriber(vnats.SubscriberArgs{
+		ConsumerName: fmt.Sprintf("vnatstest_loopback_%d_%d", time.Now().UnixNano(), loopbackCounter.Add(1)),
+		Subject:      subject,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("loopback subscriber could not be created: %w", err)
+	}
+
+	results := make(chan loopbackResult[T], loopbackBufferSize)
+	if err := sub.Start(func(msg vnats.Msg) error {
+		if msg.Encoding == "" {
+			msg.Encoding = encoding
+		}
+		result := loopbackResult[T]{value: new(T)}
+		if err := msg.Decode(result.value); err != nil {
+			result = loopbackResult[T]{err: fmt.Errorf("message with msgID: %s could not be decoded: %w", msg.MsgID, err)}
+		}
+		select {
+		case results <- result:
+			return nil
+		default:
+			return fmt.Errorf("loopback buffer is full, call NextMsgFunc to receive messages")
+		}
+	}); err != nil {
+		return nil, nil, err
+	}
+
+	next := func(timeout time.Duration) (*T, error) {
+		select {
+		case result := <-results:
+			return result.value, result.err
+		case <-time.After(timeout):
+			return nil, fmt.Errorf("no message received on %s within %v", subject, timeout)
+		}
+	}
+	return pub, next, nil
+}
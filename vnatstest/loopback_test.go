@@ -0,0 +1,68 @@
+package vnatstest
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/fond-of-vertigo/vnats"
+)
+
+type order struct {
+	ID    string `json:"id"`
+	Total int    `json:"total"`
+}
+
+func TestLoopback(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	url := os.Getenv("NATS_SERVER_URL")
+	if url == "" {
+		t.Fatal("Env-Var `NATS_SERVER_URL` is empty!")
+	}
+	conn, err := vnats.Connect([]string{url})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := conn.Close(); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	// The stream outlives the test, so use a new subject to not receive messages of former runs.
+	subject := fmt.Sprintf("LoopbackTests.created%d", time.Now().UnixNano())
+	pub, next, err := Loopback[order](conn, subject, vnats.EncJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []order{{ID: "1", Total: 10}, {ID: "2", Total: 20}} {
+		data, err := vnats.EncJSON.Marshal(want)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := pub.Publish(vnats.NewMsg(subject, subject+want.ID, data)); err != nil {
+			t.Fatal(err)
+		}
+		got, err := next(time.Second * 5)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if *got != want {
+			t.Errorf("Got %v, expected %v", *got, want)
+		}
+	}
+
+	if err := pub.Publish(vnats.NewMsg(subject, subject+"invalid", []byte("not json"))); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := next(time.Second * 5); err == nil {
+		t.Error("Expected decode error, but received a message")
+	}
+	if _, err := next(time.Millisecond * 100); err == nil {
+		t.Error("Expected timeout error, but received a message")
+	}
+}
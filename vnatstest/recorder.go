@@ -0,0 +1,113 @@
+package vnatstest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+
+	"github.com/fond-of-vertigo/vnats"
+)
+
+// RecordedMsg is a message published to a Recorder.
+type RecordedMsg struct {
+	vnats.Msg
+
+	// Value is the Data decoded by the Encoding of the message into an untyped value, like
+	// map[string]any for JSON. It is nil, if the message has no Encoding or its Encoding cannot decode into
+	// an untyped value, like EncProtobuf and EncRaw. Use Decode for such messages.
+	Value any
+}
+
+// Decode decodes the Data of the message into v according to its Encoding.
+func (m RecordedMsg) Decode(v any) error {
+	return m.Msg.Decode(v)
+}
+
+// Recorder is a test double of vnats.Publisher, which records the published messages instead of sending them.
+// It has the Publish methods of vnats.Publisher, so code depending on an interface with this method can be
+// tested without NATS server. It is safe for concurrent use.
+type Recorder struct {
+	encoding vnats.Encoding
+
+	mu   sync.Mutex
+	msgs []RecordedMsg
+}
+
+// NewRecorder creates a Recorder, whose default Encoding is used for messages without Encoding like the
+// Encoding of vnats.PublisherArgs. An empty encoding means the messages are not decoded.
+func NewRecorder(encoding vnats.Encoding) *Recorder {
+	return &Recorder{encoding: encoding}
+}
+
+// Publish records the message. It fails, if the Data cannot be decoded by the Encoding of the message.
+func (r *Recorder) Publish(msg *vnats.Msg) error {
+	recorded := RecordedMsg{Msg: *msg}
+	recorded.Data = slices.Clone(msg.Data)
+	recorded.Header = maps.Clone(msg.Header)
+	if recorded.Encoding == "" {
+		recorded.Encoding = r.encoding
+	}
+	if recorded.Encoding != "" {
+		err := recorded.Encoding.Unmarshal(recorded.Data, &recorded.Value)
+		if err != nil && !errors.Is(err, vnats.ErrUnsupportedType) {
+			return fmt.Errorf("message with msgID: %s could not be decoded: %w", msg.MsgID, err)
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.msgs = append(r.msgs, recorded)
+	return nil
+}
+
+// PublishWithContext records the message like Publish, unless ctx is done already.
+func (r *Recorder) PublishWithContext(ctx context.Context, msg *vnats.Msg) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("message with msgID: %s could not be published: %w", msg.MsgID, err)
+	}
+	return r.Publish(msg)
+}
+
+// Msgs returns all recorded messages in the order they were published.
+func (r *Recorder) Msgs() []RecordedMsg {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.msgs)
+}
+
+// PublishedTo returns the recorded messages of the subject in the order they were published.
+func (r *Recorder) PublishedTo(subject string) []RecordedMsg {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var msgs []RecordedMsg
+	for _, msg := range r.msgs {
+		if msg.Subject == subject {
+			msgs = append(msgs, msg)
+		}
+	}
+	return msgs
+}
+
+// WithMsgID returns the recorded message with the MsgID and whether it was found.
+func (r *Recorder) WithMsgID(msgID string) (RecordedMsg, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, msg := range r.msgs {
+		if msg.MsgID == msgID {
+			return msg, true
+		}
+	}
+	return RecordedMsg{}, false
+}
+
+// Reset removes all recorded messages.
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.msgs = nil
+}
@@ -0,0 +1,104 @@
+package vnatstest
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/fond-of-vertigo/vnats"
+)
+
+// publisher is the interface a service under test depends on, which is implemented by both
+// vnats.Publisher and Recorder.
+type publisher interface {
+	Publish(msg *vnats.Msg) error
+	PublishWithContext(ctx context.Context, msg *vnats.Msg) error
+}
+
+var (
+	_ publisher = (*vnats.Publisher)(nil)
+	_ publisher = (*Recorder)(nil)
+)
+
+func TestRecorder(t *testing.T) {
+	rec := NewRecorder(vnats.EncJSON)
+	msgs := []*vnats.Msg{
+		vnats.NewMsg("ORDERS.created", "order-1", []byte(`{"id":"1"}`)),
+		vnats.NewMsg("ORDERS.cancelled", "order-1-cancelled", []byte(`{"id":"1"}`)),
+		{Subject: "ORDERS.created", MsgID: "order-2", Data: []byte("invoice.pdf"), Encoding: "application/pdf"},
+	}
+	for _, msg := range msgs[:2] {
+		if err := rec.Publish(msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := rec.Publish(msgs[2]); err == nil {
+		t.Error("Publish() of message with unsupported encoding should fail")
+	}
+
+	created := rec.PublishedTo("ORDERS.created")
+	if len(created) != 1 || created[0].MsgID != "order-1" {
+		t.Fatalf("PublishedTo() = %v, want order-1", created)
+	}
+	if value, ok := created[0].Value.(map[string]any); !ok || value["id"] != "1" {
+		t.Errorf("Value = %v, want decoded JSON", created[0].Value)
+	}
+	var order struct{ ID string }
+	if err := created[0].Decode(&order); err != nil || order.ID != "1" {
+		t.Errorf("Decode() = %v (error %v), want order 1", order, err)
+	}
+
+	msgs[0].Data[2] = 'x'
+	if string(created[0].Data) != `{"id":"1"}` {
+		t.Errorf("Recorded data was modified by the caller: %s", created[0].Data)
+	}
+	if _, ok := rec.WithMsgID("order-1-cancelled"); !ok {
+		t.Error("WithMsgID() did not find order-1-cancelled")
+	}
+	rec.Reset()
+	if len(rec.Msgs()) != 0 {
+		t.Errorf("Msgs() after Reset() = %v, want none", rec.Msgs())
+	}
+}
+
+func TestRecorder_PublishWithContext(t *testing.T) {
+	recorder := NewRecorder("")
+	if err := recorder.PublishWithContext(context.Background(), vnats.NewMsg("ORDERS.created", "msg-001", nil)); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := recorder.PublishWithContext(ctx, vnats.NewMsg("ORDERS.created", "msg-002", nil)); !errors.Is(err, context.Canceled) {
+		t.Errorf("PublishWithContext() with cancelled context error = %v, want context.Canceled", err)
+	}
+	if got := len(recorder.Msgs()); got != 1 {
+		t.Errorf("Got %d recorded messages, expected 1", got)
+	}
+}
+
+func TestRecorder_UntypedEncodings(t *testing.T) {
+	recorder := NewRecorder("")
+	for _, encoding := range []vnats.Encoding{vnats.EncRaw, vnats.EncProtobuf} {
+		msg := vnats.NewMsg("ORDERS.created", "msg-"+string(encoding), nil)
+		msg.Encoding = encoding
+		msg.Data = []byte{0x0a, 0x03, 0x61, 0x62, 0x63}
+		if err := recorder.Publish(msg); err != nil {
+			t.Errorf("Publish() with %s error = %v", encoding, err)
+		}
+	}
+
+	msgs := recorder.Msgs()
+	if len(msgs) != 2 {
+		t.Fatalf("Got %d recorded messages, expected 2", len(msgs))
+	}
+	var raw []byte
+	if err := msgs[0].Decode(&raw); err != nil || string(raw) != string(msgs[0].Data) {
+		t.Errorf("Decode() = %v, %v, want the raw Data", raw, err)
+	}
+	for _, msg := range msgs {
+		if msg.Value != nil {
+			t.Errorf("Value of %s message = %v, want nil", msg.Encoding, msg.Value)
+		}
+	}
+}
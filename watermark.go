@@ -0,0 +1,69 @@
+package vnats
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+type watermarkWriter struct {
+	bucket   string
+	key      string
+	interval time.Duration
+	written  uint64
+}
+
+func newWatermarkWriter(args SubscriberArgs) *watermarkWriter {
+	if args.WatermarkBucket == "" {
+		return nil
+	}
+	interval := args.WatermarkInterval
+	if interval <= 0 {
+		interval = defaultWatermarkInterval
+	}
+	return &watermarkWriter{bucket: args.WatermarkBucket, key: watermarkKey(args), interval: interval}
+}
+
+func watermarkKey(args SubscriberArgs) string {
+	if args.WatermarkKey != "" {
+		return args.WatermarkKey
+	}
+	return args.ConsumerName
+}
+
+// writeWatermarks writes the ack floor of the consumer back as watermark periodically until the Subscriber quits.
+func (s *Subscriber) writeWatermarks(ctx context.Context) {
+	ticker := time.NewTicker(s.watermark.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.quitSignal:
+			return
+		case <-ticker.C:
+			if err := s.writeWatermark(); err != nil {
+				s.logger.Error("Watermark could not be written", slog.String("name", s.consumerName),
+					slog.String("error", err.Error()))
+			}
+		}
+	}
+}
+
+// writeWatermark stores the ack floor of the consumer, if it advanced since the last write.
+func (s *Subscriber) writeWatermark() error {
+	info, err := s.subscription.ConsumerInfo()
+	if err != nil {
+		return err
+	}
+	floor := info.AckFloor.Stream
+	if floor <= s.watermark.written {
+		return nil
+	}
+	if err := s.conn.nats.PutWatermark(s.watermark.bucket, s.watermark.key, floor); err != nil {
+		return err
+	}
+	s.watermark.written = floor
+	return nil
+}
@@ -0,0 +1,69 @@
+package vnats
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+func TestSubscriber_Watermark(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	subject := integrationTestStreamName + ".watermark"
+	consumerName := "TestSubscriberWatermark"
+	conn := makeIntegrationTestConn(t)
+	publishStringMessages(t, conn, subject, []string{"first", "second", "third", "fourth"})
+
+	js := conn.nats.(*natsBridge).jetStreamContext
+	bucket := "TestSubscriberWatermark"
+	_ = js.DeleteKeyValue(bucket)
+	if _, err := js.CreateKeyValue(&nats.KeyValueConfig{Bucket: bucket}); err != nil {
+		t.Fatal(err)
+	}
+	if err := conn.nats.PutWatermark(bucket, consumerName, 2); err != nil {
+		t.Fatal(err)
+	}
+
+	sub, err := conn.NewSubscriber(SubscriberArgs{
+		ConsumerName:    consumerName,
+		Subject:         subject,
+		Mode:            SingleSubscriberStrictMessageOrder,
+		WatermarkBucket: bucket,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var received []string
+	sub.handler = func(_ context.Context, msg Msg) error {
+		received = append(received, string(msg.Data))
+		return nil
+	}
+	sub.processMessages(context.Background(), 1, defaultFetchWait)
+	if len(received) != 1 || received[0] != "third" {
+		t.Fatalf("Got messages %v, expected the third message after the watermark", received)
+	}
+
+	deadline := time.Now().Add(time.Second * 5)
+	for time.Now().Before(deadline) && sub.watermark.written < 3 {
+		if err := sub.writeWatermark(); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(time.Millisecond * 50)
+	}
+	watermark, err := conn.nats.Watermark(bucket, consumerName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if watermark != 3 {
+		t.Errorf("Got watermark %d, expected 3", watermark)
+	}
+	if err := js.DeleteKeyValue(bucket); err != nil {
+		t.Error(err)
+	}
+	if err := conn.Close(); err != nil {
+		t.Error(err)
+	}
+}